@@ -3,8 +3,6 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/containerd/meta-viewer/internal/database"
-	"github.com/containerd/meta-viewer/internal/formatters"
 	"github.com/spf13/cobra"
 )
 
@@ -19,7 +17,7 @@ This command shows the bucket structure and key counts for each bucket.`,
 
 func runBuckets(cmd *cobra.Command, args []string) error {
 	// Create database reader
-	reader, err := database.NewMetaReader(dbPath)
+	reader, err := newReader()
 	if err != nil {
 		return fmt.Errorf("failed to create database reader: %w", err)
 	}
@@ -32,15 +30,11 @@ func runBuckets(cmd *cobra.Command, args []string) error {
 	}
 
 	// Format output
-	if output == "json" {
-		formatter := formatters.NewJSONFormatter(verbose)
-		return formatter.FormatBuckets(buckets)
-	} else {
-		formatter := formatters.NewTableFormatter()
-		return formatter.FormatBuckets(buckets)
-	}
+	return formatOutput(reader, buckets)
 }
 
 func init() {
 	rootCmd.AddCommand(bucketsCmd)
+
+	addListFlags(bucketsCmd)
 }
\ No newline at end of file
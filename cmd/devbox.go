@@ -3,11 +3,25 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/blockdev"
 	"github.com/containerd/meta-viewer/internal/formatters"
+	"github.com/containerd/meta-viewer/internal/lvm"
+	"github.com/containerd/meta-viewer/internal/mounts"
+	"github.com/containerd/meta-viewer/internal/procs"
 	"github.com/spf13/cobra"
 )
 
+var (
+	devboxMountInfoPath string
+	devboxLVSFile       string
+	devboxVGs           []string
+	devboxVGSFile       string
+	devboxDataThreshold float64
+	devboxMetaThreshold float64
+	devboxDFThreshold   float64
+	devboxProcRoot      string
+)
+
 // devboxCmd represents the devbox command
 var devboxCmd = &cobra.Command{
 	Use:   "devbox",
@@ -45,69 +59,322 @@ This is useful for understanding which LVM volumes are mounted where.`,
 	RunE: runDevboxLvmMap,
 }
 
+// devboxVerifyCmd represents the devbox verify command
+var devboxVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify devbox mount paths against the live mount table",
+	Long: `Check every devbox storage entry against the mount table (mountinfo).
+For each entry the mount state, source device, filesystem type and mount
+options are shown. Entries that are active but not mounted, removed but
+still mounted, or mounted from a different LV than recorded are flagged.
+
+By default the host mount table is read from /proc/1/mountinfo (under
+--host-root); use --mountinfo to read a captured file instead.`,
+	RunE: runDevboxVerify,
+}
+
+// devboxLvmReconcileCmd represents the devbox lvm-reconcile command
+var devboxLvmReconcileCmd = &cobra.Command{
+	Use:   "lvm-reconcile",
+	Short: "Reconcile devbox storage entries with LVM logical volumes",
+	Long: `Match LVM logical volumes (from lvs --reportformat json) to the lv_name
+of every devbox storage entry. Reports LVs that have no storage entry
+(orphan-lv), entries whose LV no longer exists (missing-lv) and removed
+entries whose LV still exists (removed-lv-present), with LV size and
+attributes.
+
+LVs are matched by volume group and name. The volume group of an entry is
+taken from its vg_name field or a vg/lv lv_name, otherwise from --vg; an
+entry matching same-named LVs in several volume groups is ambiguous-lv.
+Orphans are only looked for in the volume groups given with --vg, or by
+default in the volume groups holding at least one recorded LV. Use
+--lvs-file to read a captured report instead of running lvs.`,
+	RunE: runDevboxLvmReconcile,
+}
+
+// devboxCapacityCmd represents the devbox capacity command
+var devboxCapacityCmd = &cobra.Command{
+	Use:   "capacity",
+	Short: "Report volume group and thin pool capacity for devbox storage",
+	Long: `Combine vgs/lvs JSON reports with the devbox storage entries to show how
+full the backing volume groups and thin pools are: VG size and free space,
+thin pool data% and metadata% with the virtual size allocated from each
+pool, and allocated versus used space per devbox storage status.
+
+Pools above --data-threshold or --metadata-threshold are flagged. Use
+--vgs-file and --lvs-file to read captured reports instead of running
+vgs and lvs.`,
+	RunE: runDevboxCapacity,
+}
+
+// devboxDevicesCmd represents the devbox devices command
+var devboxDevicesCmd = &cobra.Command{
+	Use:   "devices",
+	Short: "Resolve devbox LVs to kernel block devices",
+	Long: `Resolve the lv_name of every devbox storage entry to its device-mapper
+device by reading /sys/block/dm-*/dm/name. For each entry the /dev/mapper
+name, dm-N kernel name, major:minor, device size and the holders/slaves
+of the device are shown.
+
+Devices are matched by volume group and LV name. The volume group of an
+entry is taken from its vg_name field or a vg/lv lv_name, otherwise from
+--vg; an entry matching same-named LVs in several volume groups is marked
+ambiguous and left unresolved.
+
+/sys and /dev are read under --host-root, so a fake tree can be used.`,
+	RunE: runDevboxDevices,
+}
+
+// devboxDFCmd represents the devbox df command
+var devboxDFCmd = &cobra.Command{
+	Use:   "df",
+	Short: "Show filesystem usage of each devbox mount",
+	Long: `Run statfs on the mount path of every devbox storage entry and report
+total, used and available bytes and inodes, fullest first. Entries whose
+path is missing or is not a mountpoint are marked; for the latter the
+usage of the filesystem the path lives on is shown.
+
+Use --threshold to only show entries at or above a usage percentage.`,
+	RunE: runDevboxDF,
+}
+
+// devboxUsersCmd represents the devbox users command
+var devboxUsersCmd = &cobra.Command{
+	Use:   "users [content-id]",
+	Short: "Find processes using a devbox path",
+	Long: `Scan /proc/*/fd, /proc/*/cwd and per-process mountinfo for open files,
+working directories and mounts under the path of a devbox storage entry,
+and report each process with its command line and container cgroup.
+Check this before unmounting or removing the entry.
+
+Mounts are reported once per mount namespace. Processes that cannot be
+inspected are skipped, so run as root to see every process.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDevboxUsers,
+}
+
 func runDevboxList(cmd *cobra.Command, args []string) error {
-	reader, err := database.NewMetaReader(dbPath)
+	reader, err := newReader()
 	if err != nil {
 		return fmt.Errorf("failed to create database reader: %w", err)
 	}
 	defer reader.Close()
 
+	if err := requireCommandGroup(reader, cmd); err != nil {
+		return err
+	}
+
 	storage, err := reader.ListDevboxStorage()
 	if err != nil {
 		return fmt.Errorf("failed to list devbox storage: %w", err)
 	}
 
-	if output == "json" {
-		formatter := formatters.NewJSONFormatter(verbose)
-		return formatter.FormatDevboxStorage(storage)
-	} else {
-		formatter := formatters.NewTableFormatter()
-		return formatter.FormatDevboxStorage(storage)
-	}
+	return formatOutput(reader, storage)
 }
 
 func runDevboxGet(cmd *cobra.Command, args []string) error {
 	contentID := args[0]
 
-	reader, err := database.NewMetaReader(dbPath)
+	reader, err := newReader()
 	if err != nil {
 		return fmt.Errorf("failed to create database reader: %w", err)
 	}
 	defer reader.Close()
 
+	if err := requireCommandGroup(reader, cmd); err != nil {
+		return err
+	}
+
 	storage, err := reader.GetDevboxStorage(contentID)
 	if err != nil {
 		return fmt.Errorf("failed to get devbox storage %s: %w", contentID, err)
 	}
 
-	if output == "json" {
-		formatter := formatters.NewJSONFormatter(verbose)
-		return formatter.FormatDevboxStorageItem(storage)
-	} else {
-		formatter := formatters.NewTableFormatter()
-		return formatter.FormatDevboxStorageItem(storage)
-	}
+	return formatOutput(reader, storage)
 }
 
 func runDevboxLvmMap(cmd *cobra.Command, args []string) error {
-	reader, err := database.NewMetaReader(dbPath)
+	reader, err := newReader()
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	if err := requireCommandGroup(reader, cmd); err != nil {
+		return err
+	}
+
+	storage, err := reader.ListDevboxStorage()
+	if err != nil {
+		return fmt.Errorf("failed to list devbox storage: %w", err)
+	}
+
+	return formatOutput(reader, formatters.LVMMappings(storage))
+}
+
+func runDevboxVerify(cmd *cobra.Command, args []string) error {
+	reader, err := newReader()
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	if err := requireCommandGroup(reader, cmd); err != nil {
+		return err
+	}
+
+	storage, err := reader.ListDevboxStorage()
+	if err != nil {
+		return fmt.Errorf("failed to list devbox storage: %w", err)
+	}
+
+	table, err := mounts.ReadMountInfo(devboxMountInfoPath)
+	if err != nil {
+		return fmt.Errorf("failed to read mount table: %w", err)
+	}
+
+	checks := mounts.VerifyDevbox(storage, table)
+
+	return formatOutput(reader, checks)
+}
+
+func runDevboxLvmReconcile(cmd *cobra.Command, args []string) error {
+	reader, err := newReader()
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	if err := requireCommandGroup(reader, cmd); err != nil {
+		return err
+	}
+
+	storage, err := reader.ListDevboxStorage()
+	if err != nil {
+		return fmt.Errorf("failed to list devbox storage: %w", err)
+	}
+
+	lvs, err := lvm.ListLogicalVolumes(devboxLVSFile)
+	if err != nil {
+		return fmt.Errorf("failed to list logical volumes: %w", err)
+	}
+
+	results := lvm.Reconcile(storage, lvs, devboxVGs)
+
+	return formatOutput(reader, results)
+}
+
+func runDevboxCapacity(cmd *cobra.Command, args []string) error {
+	reader, err := newReader()
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	if err := requireCommandGroup(reader, cmd); err != nil {
+		return err
+	}
+
+	storage, err := reader.ListDevboxStorage()
+	if err != nil {
+		return fmt.Errorf("failed to list devbox storage: %w", err)
+	}
+
+	vgs, err := lvm.ListVolumeGroups(devboxVGSFile)
+	if err != nil {
+		return fmt.Errorf("failed to list volume groups: %w", err)
+	}
+
+	lvs, err := lvm.ListLogicalVolumes(devboxLVSFile)
+	if err != nil {
+		return fmt.Errorf("failed to list logical volumes: %w", err)
+	}
+
+	report := lvm.Capacity(storage, vgs, lvs, devboxVGs, lvm.Thresholds{
+		DataPercent:     devboxDataThreshold,
+		MetadataPercent: devboxMetaThreshold,
+	})
+
+	return formatOutput(reader, report)
+}
+
+func runDevboxDevices(cmd *cobra.Command, args []string) error {
+	reader, err := newReader()
 	if err != nil {
 		return fmt.Errorf("failed to create database reader: %w", err)
 	}
 	defer reader.Close()
 
+	if err := requireCommandGroup(reader, cmd); err != nil {
+		return err
+	}
+
 	storage, err := reader.ListDevboxStorage()
 	if err != nil {
 		return fmt.Errorf("failed to list devbox storage: %w", err)
 	}
 
-	if output == "json" {
-		formatter := formatters.NewJSONFormatter(verbose)
-		return formatter.FormatLVMMap(storage)
-	} else {
-		formatter := formatters.NewTableFormatter()
-		return formatter.FormatLVMMap(storage)
+	devices, err := blockdev.ListDMDevices(blockdev.DefaultRoots)
+	if err != nil {
+		return fmt.Errorf("failed to list device-mapper devices: %w", err)
 	}
+
+	resolved := blockdev.ResolveDevbox(storage, devices, devboxVGs)
+
+	return formatOutput(reader, resolved)
+}
+
+func runDevboxDF(cmd *cobra.Command, args []string) error {
+	reader, err := newReader()
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	if err := requireCommandGroup(reader, cmd); err != nil {
+		return err
+	}
+
+	storage, err := reader.ListDevboxStorage()
+	if err != nil {
+		return fmt.Errorf("failed to list devbox storage: %w", err)
+	}
+
+	usages := mounts.DevboxUsage(storage)
+	if devboxDFThreshold > 0 {
+		usages = mounts.FilterUsage(usages, devboxDFThreshold)
+	}
+
+	return formatOutput(reader, usages)
+}
+
+func runDevboxUsers(cmd *cobra.Command, args []string) error {
+	contentID := args[0]
+
+	reader, err := newReader()
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	if err := requireCommandGroup(reader, cmd); err != nil {
+		return err
+	}
+
+	storage, err := reader.GetDevboxStorage(contentID)
+	if err != nil {
+		return fmt.Errorf("failed to get devbox storage %s: %w", contentID, err)
+	}
+	if storage.Path == "" {
+		return fmt.Errorf("devbox storage %s has no path", contentID)
+	}
+
+	users, err := procs.FindUsers(devboxProcRoot, []string{storage.Path})
+	if err != nil {
+		return fmt.Errorf("failed to find processes: %w", err)
+	}
+
+	return formatOutput(reader, users)
 }
 
 func init() {
@@ -115,4 +382,25 @@ func init() {
 	devboxCmd.AddCommand(devboxListCmd)
 	devboxCmd.AddCommand(devboxGetCmd)
 	devboxCmd.AddCommand(devboxLvmMapCmd)
+	devboxCmd.AddCommand(devboxVerifyCmd)
+	devboxCmd.AddCommand(devboxLvmReconcileCmd)
+	devboxCmd.AddCommand(devboxCapacityCmd)
+	devboxCmd.AddCommand(devboxDevicesCmd)
+	devboxCmd.AddCommand(devboxDFCmd)
+	devboxCmd.AddCommand(devboxUsersCmd)
+
+	addListFlags(devboxListCmd)
+	addFilterFlag(devboxLvmMapCmd, devboxVerifyCmd, devboxLvmReconcileCmd, devboxDevicesCmd, devboxDFCmd, devboxUsersCmd)
+
+	devboxVerifyCmd.Flags().StringVar(&devboxMountInfoPath, "mountinfo", "", "Path to a mountinfo file (default: /proc/1/mountinfo under --host-root)")
+	devboxLvmReconcileCmd.Flags().StringVar(&devboxLVSFile, "lvs-file", "", "Read a captured 'lvs --reportformat json' report instead of running lvs")
+	devboxLvmReconcileCmd.Flags().StringSliceVar(&devboxVGs, "vg", nil, "Volume groups to look for orphan LVs in (default: groups holding recorded LVs)")
+	devboxCapacityCmd.Flags().StringVar(&devboxLVSFile, "lvs-file", "", "Read a captured 'lvs --reportformat json' report instead of running lvs")
+	devboxCapacityCmd.Flags().StringVar(&devboxVGSFile, "vgs-file", "", "Read a captured 'vgs --reportformat json' report instead of running vgs")
+	devboxCapacityCmd.Flags().StringSliceVar(&devboxVGs, "vg", nil, "Volume groups to report on (default: groups holding recorded LVs)")
+	devboxCapacityCmd.Flags().Float64Var(&devboxDataThreshold, "data-threshold", lvm.DefaultCapacityThreshold, "Flag thin pools whose data usage exceeds this percentage")
+	devboxCapacityCmd.Flags().Float64Var(&devboxMetaThreshold, "metadata-threshold", lvm.DefaultCapacityThreshold, "Flag thin pools whose metadata usage exceeds this percentage")
+	devboxDevicesCmd.Flags().StringSliceVar(&devboxVGs, "vg", nil, "Volume groups to look for LVs of entries not recording one (default: all)")
+	devboxDFCmd.Flags().Float64Var(&devboxDFThreshold, "threshold", 0, "Only show entries whose usage is at least this percentage")
+	devboxUsersCmd.Flags().StringVar(&devboxProcRoot, "proc-root", "", "Process table to scan (default: /proc under --host-root)")
 }
\ No newline at end of file
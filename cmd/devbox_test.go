@@ -1,8 +1,17 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/containerd/meta-viewer/internal/blockdev"
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/lvm"
+	"github.com/containerd/meta-viewer/internal/mounts"
 	"github.com/spf13/cobra"
 )
 
@@ -53,6 +62,12 @@ func TestDevboxSubcommands(t *testing.T) {
 		"list",
 		"get",
 		"lvm-map",
+		"verify",
+		"lvm-reconcile",
+		"capacity",
+		"devices",
+		"df",
+		"users",
 	}
 
 	for _, expected := range expectedSubcommands {
@@ -213,4 +228,391 @@ func TestDevboxCmdRegistration(t *testing.T) {
 	if !found {
 		t.Error("Expected devbox command to be registered under root command")
 	}
-}
\ No newline at end of file
+}
+func TestDevboxVerifyCmd(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"devbox", "verify"})
+	if err != nil || cmd.Name() != "verify" {
+		t.Fatalf("Expected devbox verify command to exist, got error: %v", err)
+	}
+
+	if cmd.RunE == nil {
+		t.Error("Expected devbox verify command to have RunE function")
+	}
+
+	flag := cmd.Flags().Lookup("mountinfo")
+	if flag == nil {
+		t.Fatal("Expected devbox verify command to have a mountinfo flag")
+	}
+	if flag.DefValue != "" {
+		t.Errorf("Expected mountinfo flag default to be empty, got %s", flag.DefValue)
+	}
+}
+
+// fixtureMountInfo mounts content-1 from its LV, content-2 although it was
+// removed and content-4 from the LV of another entry
+const fixtureMountInfo = `22 1 253:0 / / rw,relatime shared:1 - ext4 /dev/mapper/vg0-root rw
+30 22 253:3 / /var/lib/devbox/content-1 rw,relatime shared:10 - ext4 /dev/mapper/devbox--vg-lv--content--1 rw
+31 22 253:4 / /var/lib/devbox/content-2 rw,relatime shared:11 - ext4 /dev/mapper/devbox--vg-lv--content--2 rw
+32 22 253:6 / /var/lib/devbox/content-4 rw,relatime shared:12 - ext4 /dev/mapper/devbox--vg-lv--content--9 rw
+`
+
+func TestDevboxVerifyCmd_Run(t *testing.T) {
+	dbPath := setupFixtureDB(t, "devbox")
+	addFixtureStorage(t, dbPath,
+		database.DevboxStorageInfo{ContentID: "content-3", LvName: "lv-content-3", Path: "/var/lib/devbox/content-3", Status: "active"},
+		database.DevboxStorageInfo{ContentID: "content-4", LvName: "lv-content-4", Path: "/var/lib/devbox/content-4", Status: "active"},
+		database.DevboxStorageInfo{ContentID: "content-5", LvName: "lv-content-5", Status: "active"},
+		database.DevboxStorageInfo{ContentID: "content-6", LvName: "lv-content-6", Path: "/var/lib/devbox/content-6", Status: "migrating"},
+	)
+	mountInfo := writeFixtureFile(t, filepath.Join(t.TempDir(), "mountinfo"), fixtureMountInfo)
+
+	var checks []mounts.DevboxCheck
+	executeJSON(t, &checks, "--db-path", dbPath, "devbox", "verify", "--mountinfo", mountInfo)
+
+	results := make(map[string]string)
+	for _, check := range checks {
+		results[check.ContentID] = check.Result
+	}
+	expected := map[string]string{
+		"content-1": mounts.VerifyOK,
+		"content-2": mounts.VerifyRemovedMounted,
+		"content-3": mounts.VerifyActiveNotMounted,
+		"content-4": mounts.VerifyLVMismatch,
+		"content-5": mounts.VerifyNoPath,
+		"content-6": mounts.VerifyUnknownStatus,
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected results %v, got %v", expected, results)
+	}
+	if checks[3].Source != "/dev/mapper/devbox--vg-lv--content--9" {
+		t.Errorf("Expected content-4 to report the mounted LV, got %+v", checks[3])
+	}
+
+	if _, err := executeCommand(t, "--db-path", dbPath, "devbox", "verify", "--mountinfo", filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for a missing mountinfo file")
+	}
+}
+
+func TestDevboxVerifyCmd_HostRoot(t *testing.T) {
+	hostRoot, dbPath := setupFixtureHostRoot(t)
+
+	// Without --mountinfo the host's mount table is read under --host-root
+	writeFixtureFile(t, filepath.Join(hostRoot, "proc/1/mountinfo"), fixtureMountInfo)
+
+	var checks []mounts.DevboxCheck
+	executeJSON(t, &checks, "--host-root", hostRoot, "--db-path", dbPath, "devbox", "verify")
+	if len(checks) != 2 || checks[0].Result != mounts.VerifyOK || checks[1].Result != mounts.VerifyRemovedMounted {
+		t.Errorf("Expected the host mount table to be verified, got %+v", checks)
+	}
+}
+
+func TestDevboxLvmReconcileCmd(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"devbox", "lvm-reconcile"})
+	if err != nil || cmd.Name() != "lvm-reconcile" {
+		t.Fatalf("Expected devbox lvm-reconcile command to exist, got error: %v", err)
+	}
+
+	if cmd.RunE == nil {
+		t.Error("Expected devbox lvm-reconcile command to have RunE function")
+	}
+
+	for _, name := range []string{"lvs-file", "vg"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("Expected devbox lvm-reconcile command to have a %s flag", name)
+		}
+	}
+}
+
+// fixtureLVSReport holds the thin pool, the LV of content-1, the LV of
+// removed content-2, a leftover LV no storage entry records and the root LV
+// of another volume group
+const fixtureLVSReport = `{"report": [{"lv": [
+  {"lv_name":"thinpool", "vg_name":"devbox-vg", "lv_attr":"twi-aotz--", "lv_size":"107374182400", "pool_lv":"", "data_percent":"90.00", "metadata_percent":"10.00"},
+  {"lv_name":"lv-content-1", "vg_name":"devbox-vg", "lv_attr":"Vwi-aotz--", "lv_size":"10737418240", "pool_lv":"thinpool", "data_percent":"50.00"},
+  {"lv_name":"lv-content-2", "vg_name":"devbox-vg", "lv_attr":"Vwi---tz--", "lv_size":"10737418240", "pool_lv":"thinpool", "data_percent":""},
+  {"lv_name":"lv-leftover", "vg_name":"devbox-vg", "lv_attr":"Vwi-a-tz--", "lv_size":"5368709120", "pool_lv":"thinpool", "data_percent":"0.00"},
+  {"lv_name":"root", "vg_name":"system", "lv_attr":"-wi-ao----", "lv_size":"53687091200", "pool_lv":""}
+]}]}`
+
+// reconcileResults maps the LV names of reconciliation results to their result
+func reconcileResults(results []lvm.Reconciliation) map[string]string {
+	byLV := make(map[string]string, len(results))
+	for _, result := range results {
+		byLV[result.LvName] = result.Result
+	}
+	return byLV
+}
+
+func TestDevboxLvmReconcileCmd_Run(t *testing.T) {
+	dbPath := setupFixtureDB(t, "devbox")
+	addFixtureStorage(t, dbPath,
+		database.DevboxStorageInfo{ContentID: "content-3", LvName: "lv-content-3", Path: "/var/lib/devbox/content-3", Status: "active"},
+		database.DevboxStorageInfo{ContentID: "content-4", LvName: "lv-content-4", Path: "/var/lib/devbox/content-4", Status: "removed"},
+	)
+	lvsFile := writeFixtureFile(t, filepath.Join(t.TempDir(), "lvs.json"), fixtureLVSReport)
+
+	// Orphans are only looked for in devbox-vg, which holds recorded LVs, and
+	// the thin pool is never one
+	var results []lvm.Reconciliation
+	executeJSON(t, &results, "--db-path", dbPath, "devbox", "lvm-reconcile", "--lvs-file", lvsFile)
+	expected := map[string]string{
+		"lv-content-1": lvm.ReconcileOK,
+		"lv-content-2": lvm.ReconcileRemovedLVPresent,
+		"lv-content-3": lvm.ReconcileMissingLV,
+		"lv-content-4": lvm.ReconcileOK,
+		"lv-leftover":  lvm.ReconcileOrphanLV,
+	}
+	if byLV := reconcileResults(results); !reflect.DeepEqual(byLV, expected) {
+		t.Errorf("Expected results %v, got %v", expected, byLV)
+	}
+	for _, result := range results {
+		if result.LvName == "lv-content-1" && (result.ContentID != "content-1" || result.VGName != "devbox-vg" || !result.Active) {
+			t.Errorf("Expected lv-content-1 to be joined to its storage entry, got %+v", result)
+		}
+	}
+
+	// --vg replaces the inferred volume groups
+	executeJSON(t, &results, "--db-path", dbPath, "devbox", "lvm-reconcile", "--lvs-file", lvsFile, "--vg", "system")
+	byLV := reconcileResults(results)
+	if byLV["root"] != lvm.ReconcileOrphanLV {
+		t.Errorf("Expected the root LV of --vg system to be an orphan, got %v", byLV)
+	}
+	if _, ok := byLV["lv-leftover"]; ok {
+		t.Errorf("Expected LVs outside --vg to be ignored, got %v", byLV)
+	}
+
+	if _, err := executeCommand(t, "--db-path", dbPath, "devbox", "lvm-reconcile", "--lvs-file", filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for a missing lvs report")
+	}
+}
+
+func TestDevboxCapacityCmd(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"devbox", "capacity"})
+	if err != nil || cmd.Name() != "capacity" {
+		t.Fatalf("Expected devbox capacity command to exist, got error: %v", err)
+	}
+
+	tests := []struct {
+		flagName    string
+		flagDefault string
+	}{
+		{"lvs-file", ""},
+		{"vgs-file", ""},
+		{"vg", "[]"},
+		{"data-threshold", "80"},
+		{"metadata-threshold", "80"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flagName, func(t *testing.T) {
+			flag := cmd.Flags().Lookup(tt.flagName)
+			if flag == nil {
+				t.Fatalf("Expected flag %s to exist", tt.flagName)
+			}
+			if flag.DefValue != tt.flagDefault {
+				t.Errorf("Expected flag %s default value = %s, got %s", tt.flagName, tt.flagDefault, flag.DefValue)
+			}
+		})
+	}
+}
+
+// fixtureVGSReport holds the devbox volume group and the system volume group
+const fixtureVGSReport = `{"report": [{"vg": [
+  {"vg_name":"devbox-vg", "vg_size":"214748364800", "vg_free":"107374182400", "lv_count":"4"},
+  {"vg_name":"system", "vg_size":"107374182400", "vg_free":"0", "lv_count":"1"}
+]}]}`
+
+func TestDevboxCapacityCmd_Run(t *testing.T) {
+	dbPath := setupFixtureDB(t, "devbox")
+	addFixtureStorage(t, dbPath,
+		database.DevboxStorageInfo{ContentID: "content-3", LvName: "lv-content-3", Path: "/var/lib/devbox/content-3", Status: "active"},
+	)
+	dir := t.TempDir()
+	lvsFile := writeFixtureFile(t, filepath.Join(dir, "lvs.json"), fixtureLVSReport)
+	vgsFile := writeFixtureFile(t, filepath.Join(dir, "vgs.json"), fixtureVGSReport)
+
+	var report lvm.CapacityReport
+	executeJSON(t, &report, "--db-path", dbPath, "devbox", "capacity", "--lvs-file", lvsFile, "--vgs-file", vgsFile)
+
+	// Only the volume group holding recorded LVs is reported
+	if len(report.VolumeGroups) != 1 || report.VolumeGroups[0].Name != "devbox-vg" {
+		t.Errorf("Expected devbox-vg only, got %+v", report.VolumeGroups)
+	}
+	if len(report.Pools) != 1 {
+		t.Fatalf("Expected one thin pool, got %+v", report.Pools)
+	}
+	pool := report.Pools[0]
+	if pool.Name != "thinpool" || pool.Allocated != 25<<30 || !reflect.DeepEqual(pool.Flags, []string{"data>80%"}) {
+		t.Errorf("Expected thinpool with 25GiB allocated flagged above the data threshold only, got %+v", pool)
+	}
+
+	statuses := make(map[string]lvm.StatusUsage)
+	for _, status := range report.Statuses {
+		statuses[status.Status] = status
+	}
+	if active := statuses["active"]; active.Entries != 2 || active.MissingLV != 1 || active.Allocated != 10<<30 || active.Used != 5<<30 {
+		t.Errorf("Expected content-1 to use half of its 10GiB LV and content-3 to miss its LV, got %+v", active)
+	}
+	if removed := statuses["removed"]; removed.Entries != 1 || removed.MissingLV != 0 {
+		t.Errorf("Expected removed content-2 to still have its LV, got %+v", removed)
+	}
+
+	// Thresholds apply to data and metadata separately
+	report = lvm.CapacityReport{}
+	executeJSON(t, &report, "--db-path", dbPath, "devbox", "capacity", "--lvs-file", lvsFile, "--vgs-file", vgsFile,
+		"--data-threshold", "95", "--metadata-threshold", "5")
+	if report.Flagged != 1 || !reflect.DeepEqual(report.Pools[0].Flags, []string{"metadata>5%"}) {
+		t.Errorf("Expected the pool to be flagged for metadata only, got %+v", report.Pools)
+	}
+
+	// A volume group without a thin pool has no pools to report
+	report = lvm.CapacityReport{}
+	executeJSON(t, &report, "--db-path", dbPath, "devbox", "capacity", "--lvs-file", lvsFile, "--vgs-file", vgsFile, "--vg", "system")
+	if len(report.VolumeGroups) != 1 || report.VolumeGroups[0].Name != "system" || len(report.Pools) != 0 || report.Flagged != 0 {
+		t.Errorf("Expected the system volume group only, got %+v", report)
+	}
+}
+
+// writeFixtureDMDevice adds a device-mapper device to the fake sysfs under
+// hostRoot, with a /dev/mapper node when mapped is set
+func writeFixtureDMDevice(t *testing.T, hostRoot, kernel, name string, sectors int64, mapped bool, slaves ...string) {
+	t.Helper()
+	dir := filepath.Join(hostRoot, "sys/block", kernel)
+	writeFixtureFile(t, filepath.Join(dir, "dm/name"), name+"\n")
+	writeFixtureFile(t, filepath.Join(dir, "dev"), "253:"+strings.TrimPrefix(kernel, "dm-")+"\n")
+	writeFixtureFile(t, filepath.Join(dir, "size"), strconv.FormatInt(sectors, 10)+"\n")
+	for _, slave := range slaves {
+		writeFixtureFile(t, filepath.Join(dir, "slaves", slave), "")
+	}
+	if mapped {
+		writeFixtureFile(t, filepath.Join(hostRoot, "dev/mapper", name), "")
+	}
+}
+
+func TestDevboxDevicesCmd_Run(t *testing.T) {
+	hostRoot, dbPath := setupFixtureHostRoot(t)
+	addFixtureStorage(t, hostRoot+dbPath,
+		database.DevboxStorageInfo{ContentID: "content-3", LvName: "lv-content-3", Path: "/var/lib/devbox/content-3", Status: "active"},
+		database.DevboxStorageInfo{ContentID: "content-4", Path: "/var/lib/devbox/content-4", Status: "active"},
+	)
+
+	// The LV of content-1 is active on the thin pool, the LV of content-3
+	// lost its /dev/mapper node, content-2's LV is inactive and content-4
+	// records no LV. cryptdata is not an LV.
+	writeFixtureDMDevice(t, hostRoot, "dm-0", "devbox--vg-thinpool-tpool", 209715200, false)
+	writeFixtureDMDevice(t, hostRoot, "dm-1", "devbox--vg-lv--content--1", 20971520, true, "dm-0")
+	writeFixtureDMDevice(t, hostRoot, "dm-3", "devbox--vg-lv--content--3", 20971520, false, "dm-0")
+	writeFixtureDMDevice(t, hostRoot, "dm-5", "cryptdata", 2097152, true)
+
+	var devices []blockdev.DevboxDevice
+	executeJSON(t, &devices, "--host-root", hostRoot, "--db-path", dbPath, "devbox", "devices")
+	if len(devices) != 4 {
+		t.Fatalf("Expected 4 devices, got %d", len(devices))
+	}
+
+	found := devices[0]
+	if found.ContentID != "content-1" || !found.Found || found.Device == nil {
+		t.Fatalf("Expected content-1 to be resolved, got %+v", found)
+	}
+	device := found.Device
+	if device.Kernel != "dm-1" || device.VGName != "devbox-vg" || device.MajorMinor != "253:1" || device.Size != 10<<30 {
+		t.Errorf("Unexpected device for content-1: %+v", device)
+	}
+	if device.MapperPath != "/dev/mapper/devbox--vg-lv--content--1" {
+		t.Errorf("Expected host mapper path, got %s", device.MapperPath)
+	}
+	if !reflect.DeepEqual(device.Slaves, []string{"dm-0"}) {
+		t.Errorf("Expected dm-0 as slave, got %v", device.Slaves)
+	}
+
+	if devices[1].ContentID != "content-2" || devices[1].Found {
+		t.Errorf("Expected content-2 without an active LV to be unresolved, got %+v", devices[1])
+	}
+	if devices[2].ContentID != "content-3" || !devices[2].Found || devices[2].Device.MapperPath != "" {
+		t.Errorf("Expected content-3 to be resolved without a mapper path, got %+v", devices[2])
+	}
+	if devices[3].ContentID != "content-4" || devices[3].Found {
+		t.Errorf("Expected content-4 without an LV name to be unresolved, got %+v", devices[3])
+	}
+}
+
+func TestDevboxDFCmd_Run(t *testing.T) {
+	hostRoot, dbPath := setupFixtureHostRoot(t)
+	addFixtureStorage(t, hostRoot+dbPath,
+		database.DevboxStorageInfo{ContentID: "content-3", LvName: "lv-content-3", Status: "active"},
+	)
+
+	// content-1 has its directory but nothing mounted on it, content-2 is
+	// gone and content-3 records no path
+	if err := os.MkdirAll(filepath.Join(hostRoot, "var/lib/devbox/content-1"), 0755); err != nil {
+		t.Fatalf("Failed to create mount path: %v", err)
+	}
+
+	var usages []mounts.Usage
+	executeJSON(t, &usages, "--host-root", hostRoot, "--db-path", dbPath, "devbox", "df")
+	if len(usages) != 3 {
+		t.Fatalf("Expected 3 usages, got %d", len(usages))
+	}
+
+	byID := make(map[string]mounts.Usage)
+	for _, usage := range usages {
+		byID[usage.ContentID] = usage
+	}
+	if usage := byID["content-1"]; usage.State != mounts.UsageNotMountpoint || usage.Total == 0 || usage.Path != "/var/lib/devbox/content-1" {
+		t.Errorf("Expected content-1 to report the filesystem it lives on at its host path, got %+v", usage)
+	}
+	if usage := byID["content-2"]; usage.State != mounts.UsageMissing || usage.Total != 0 {
+		t.Errorf("Expected content-2 to be %s, got %+v", mounts.UsageMissing, usage)
+	}
+	if usage := byID["content-3"]; usage.State != mounts.UsageNoPath {
+		t.Errorf("Expected content-3 to be %s, got %+v", mounts.UsageNoPath, usage)
+	}
+	if usages[0].ContentID != "content-1" {
+		t.Errorf("Expected the entry with a filesystem to sort first, got %+v", usages)
+	}
+
+	// The threshold drops entries without usage as well
+	usages = nil
+	executeJSON(t, &usages, "--host-root", hostRoot, "--db-path", dbPath, "devbox", "df", "--threshold", "101")
+	if len(usages) != 0 {
+		t.Errorf("Expected no entries above 101%%, got %+v", usages)
+	}
+}
+
+func TestDevboxDFCmd_Mountpoint(t *testing.T) {
+	dbPath := setupFixtureDB(t, "devbox")
+	addFixtureStorage(t, dbPath,
+		database.DevboxStorageInfo{ContentID: "content-3", LvName: "lv-content-3", Path: "/", Status: "active"},
+	)
+
+	var usages []mounts.Usage
+	executeJSON(t, &usages, "--db-path", dbPath, "devbox", "df")
+	for _, usage := range usages {
+		if usage.ContentID == "content-3" && (usage.State != mounts.UsageOK || usage.Total == 0) {
+			t.Errorf("Expected the root filesystem to be reported as mounted, got %+v", usage)
+		}
+	}
+}
+
+func TestDevboxUsersCmd(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"devbox", "users"})
+	if err != nil || cmd.Name() != "users" {
+		t.Fatalf("Expected devbox users command to exist, got error: %v", err)
+	}
+
+	if cmd.RunE == nil {
+		t.Error("Expected devbox users command to have RunE function")
+	}
+
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("Expected devbox users command to require a content ID")
+	}
+
+	flag := cmd.Flags().Lookup("proc-root")
+	if flag == nil {
+		t.Fatal("Expected devbox users command to have a proc-root flag")
+	}
+	if flag.DefValue != "" {
+		t.Errorf("Expected proc-root flag default to be empty, got %s", flag.DefValue)
+	}
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	devmapperPoolDBPath string
+)
+
+// devmapperCmd represents the devmapper command
+var devmapperCmd = &cobra.Command{
+	Use:   "devmapper",
+	Short: "Manage and inspect devmapper snapshotter thin devices",
+	Long: `View the thin device metadata of the io.containerd.snapshotter.v1.devmapper
+snapshotter. Device records (device ID, name, parent, size, state) are read
+from the devices bucket and can be cross-referenced with the snapshots
+stored in the snapshotter database.`,
+}
+
+// devmapperListCmd represents the devmapper list command
+var devmapperListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all thin devices",
+	Long: `List all thin devices from the devices bucket.
+This shows thin device IDs, device names, parents, sizes and states.`,
+	RunE: runDevmapperList,
+}
+
+// devmapperGetCmd represents the devmapper get command
+var devmapperGetCmd = &cobra.Command{
+	Use:   "get [device-name]",
+	Short: "Get detailed information about a specific thin device",
+	Long: `Get detailed information about a specific thin device by its name
+(as it appears under /dev/mapper).`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDevmapperGet,
+}
+
+// devmapperSnapshotsCmd represents the devmapper snapshots command
+var devmapperSnapshotsCmd = &cobra.Command{
+	Use:   "snapshots",
+	Short: "Cross-reference thin devices with snapshots",
+	Long: `Map every snapshot to the thin device that backs it, showing the
+device state and thin device ID. Devices without a snapshot and snapshots
+without a device are flagged.`,
+	RunE: runDevmapperSnapshots,
+}
+
+// devmapperPoolPath returns the database holding the devices bucket
+func devmapperPoolPath() string {
+	if devmapperPoolDBPath != "" {
+		return devmapperPoolDBPath
+	}
+	return dbPath
+}
+
+func runDevmapperList(cmd *cobra.Command, args []string) error {
+	reader, err := database.NewMetaReader(devmapperPoolPath())
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	devices, err := reader.ListDevmapperDevices()
+	if err != nil {
+		return fmt.Errorf("failed to list devmapper devices: %w", err)
+	}
+
+	return formatOutput(reader, devices)
+}
+
+func runDevmapperGet(cmd *cobra.Command, args []string) error {
+	deviceName := args[0]
+
+	reader, err := database.NewMetaReader(devmapperPoolPath())
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	device, err := reader.GetDevmapperDevice(deviceName)
+	if err != nil {
+		return fmt.Errorf("failed to get devmapper device %s: %w", deviceName, err)
+	}
+
+	return formatOutput(reader, device)
+}
+
+func runDevmapperSnapshots(cmd *cobra.Command, args []string) error {
+	reader, err := newReader()
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	if err := requireCommandGroup(reader, cmd); err != nil {
+		return err
+	}
+
+	snapshots, err := reader.ListSnapshots()
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	poolReader := reader
+	if poolPath := devmapperPoolPath(); poolPath != dbPath {
+		poolReader, err = database.NewMetaReader(poolPath)
+		if err != nil {
+			return fmt.Errorf("failed to create pool database reader: %w", err)
+		}
+		defer poolReader.Close()
+	}
+
+	devices, err := poolReader.ListDevmapperDevices()
+	if err != nil {
+		return fmt.Errorf("failed to list devmapper devices: %w", err)
+	}
+
+	mappings := database.MapDevmapperDevices(devices, snapshots)
+
+	return formatOutput(reader, mappings)
+}
+
+func init() {
+	rootCmd.AddCommand(devmapperCmd)
+	devmapperCmd.AddCommand(devmapperListCmd)
+	devmapperCmd.AddCommand(devmapperGetCmd)
+	devmapperCmd.AddCommand(devmapperSnapshotsCmd)
+
+	addFilterFlag(devmapperListCmd, devmapperSnapshotsCmd)
+
+	devmapperCmd.PersistentFlags().StringVar(&devmapperPoolDBPath, "pool-db", "", "Path to the pool metadata database holding the devices bucket (default: --db-path)")
+}
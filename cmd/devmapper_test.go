@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestDevmapperCmd(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"devmapper"})
+	if err != nil {
+		t.Fatalf("Expected devmapper command to exist, got error: %v", err)
+	}
+
+	if cmd.Short == "" || cmd.Long == "" {
+		t.Error("Expected devmapper command to have short and long descriptions")
+	}
+
+	if cmd.PersistentFlags().Lookup("pool-db") == nil {
+		t.Error("Expected devmapper command to have a pool-db flag")
+	}
+}
+
+func TestDevmapperSubcommands(t *testing.T) {
+	expectedSubcommands := []string{
+		"list",
+		"get",
+		"snapshots",
+	}
+
+	for _, expected := range expectedSubcommands {
+		cmd, _, err := rootCmd.Find([]string{"devmapper", expected})
+		if err != nil || cmd.Name() != expected {
+			t.Errorf("Expected devmapper subcommand %s to be registered", expected)
+			continue
+		}
+
+		if cmd.RunE == nil {
+			t.Errorf("Expected devmapper %s command to have RunE function", expected)
+		}
+	}
+}
+
+func TestDevmapperGetCmdArgs(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"devmapper", "get"})
+	if err != nil {
+		t.Fatalf("Failed to find devmapper get command: %v", err)
+	}
+
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("Expected get command to require an argument")
+	}
+
+	if err := cmd.Args(cmd, []string{"pool-snap-1"}); err != nil {
+		t.Errorf("Expected get command to accept single argument, got error: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containerd/meta-viewer/internal/discover"
+	"github.com/spf13/cobra"
+)
+
+// discoverCmd represents the discover command
+var discoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "Find snapshotter databases using containerd's configuration",
+	Long: `Read containerd's config.toml (including root, imports and the
+snapshotter plugin sections) and scan for
+<root>/io.containerd.snapshotter.v1.*/metadata.db.
+
+Each database is listed with its snapshotter, the schema detected from its
+contents, its size and whether it is locked by a running snapshotter. The
+snapshotter configured for the CRI plugin is marked as default.`,
+	RunE: runDiscover,
+}
+
+// discoverDatabases loads containerd's configuration and lists its snapshotter databases
+func discoverDatabases() ([]discover.Database, error) {
+	config, err := discover.LoadConfig(containerdConfig)
+	if err != nil {
+		return nil, err
+	}
+	return discover.Discover(config), nil
+}
+
+// autoDetectDBPath picks the database of the CRI default snapshotter, or the
+// only database found
+func autoDetectDBPath() (string, error) {
+	databases, err := discoverDatabases()
+	if err != nil {
+		return "", err
+	}
+
+	for _, db := range databases {
+		if db.Default {
+			return db.Path, nil
+		}
+	}
+
+	switch len(databases) {
+	case 0:
+		return "", fmt.Errorf("no snapshotter database found using %s", containerdConfig)
+	case 1:
+		return databases[0].Path, nil
+	}
+
+	paths := make([]string, len(databases))
+	for i, db := range databases {
+		paths[i] = db.Path
+	}
+	return "", fmt.Errorf("found %d snapshotter databases, use --db-path to pick one: %s",
+		len(databases), strings.Join(paths, ", "))
+}
+
+func runDiscover(cmd *cobra.Command, args []string) error {
+	databases, err := discoverDatabases()
+	if err != nil {
+		return fmt.Errorf("failed to discover snapshotter databases: %w", err)
+	}
+
+	return formatOutput(nil, databases)
+}
+
+func init() {
+	rootCmd.AddCommand(discoverCmd)
+
+	addFilterFlag(discoverCmd)
+}
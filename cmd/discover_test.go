@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/containerd/meta-viewer/internal/discover"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestDiscoverCmd(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"discover"})
+	if err != nil {
+		t.Fatalf("Expected discover command to exist, got error: %v", err)
+	}
+
+	if cmd.Short == "" || cmd.Long == "" {
+		t.Error("Expected discover command to have short and long descriptions")
+	}
+
+	if cmd.RunE == nil {
+		t.Error("Expected discover command to have RunE function")
+	}
+}
+
+// setupContainerdRoot creates a containerd config and snapshotter databases
+// for the given snapshotter names, returning the config path
+func setupContainerdRoot(t *testing.T, defaultSnapshotter string, names ...string) string {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "containerd")
+
+	for _, name := range names {
+		path := filepath.Join(root, discover.SnapshotterPluginPrefix+name, "metadata.db")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		db, err := bolt.Open(path, 0600, nil)
+		if err != nil {
+			t.Fatalf("Failed to create database: %v", err)
+		}
+		db.Close()
+	}
+
+	config := "version = 2\nroot = \"" + root + "\"\n"
+	if defaultSnapshotter != "" {
+		config += "[plugins.\"io.containerd.grpc.v1.cri\".containerd]\n  snapshotter = \"" + defaultSnapshotter + "\"\n"
+	}
+	configPath := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return configPath
+}
+
+func TestAutoDetectDBPath(t *testing.T) {
+	orig := containerdConfig
+	defer func() { containerdConfig = orig }()
+
+	tests := []struct {
+		name               string
+		defaultSnapshotter string
+		snapshotters       []string
+		expected           string
+		expectError        bool
+	}{
+		{"cri default", "devbox", []string{"devbox", "overlayfs"}, "io.containerd.snapshotter.v1.devbox", false},
+		{"single database", "", []string{"native"}, "io.containerd.snapshotter.v1.native", false},
+		{"ambiguous", "", []string{"native", "overlayfs"}, "", true},
+		{"none", "", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			containerdConfig = setupContainerdRoot(t, tt.defaultSnapshotter, tt.snapshotters...)
+
+			path, err := autoDetectDBPath()
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got path %s", path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(path, tt.expected) {
+				t.Errorf("Expected path under %s, got %s", tt.expected, path)
+			}
+		})
+	}
+}
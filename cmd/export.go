@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/export"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat     string
+	exportDropTables bool
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the database for analysis in other tools",
+	Long: `Export the buckets, snapshots, snapshot labels and devbox storage of the
+snapshotter database.
+
+The sql format writes CREATE TABLE and INSERT statements in one
+transaction, loadable into SQLite or PostgreSQL:
+
+  containerd-meta-viewer export --format sql > meta.sql
+  sqlite3 meta.db < meta.sql
+  psql -f meta.sql
+
+Snapshots reference their parent and devbox storage entry by foreign key and
+are inserted parents first. Parents and content IDs without a row of their
+own are exported as NULL, with a comment keeping the original value.`,
+	Args: cobra.NoArgs,
+	RunE: runExport,
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	if exportFormat != "sql" {
+		return fmt.Errorf("invalid export format '%s'. Use 'sql'", exportFormat)
+	}
+
+	reader, err := newReader()
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	dump := export.Dump{Source: dbPath}
+	dump.Buckets, err = reader.ListBuckets()
+	if err != nil {
+		return fmt.Errorf("failed to list buckets: %w", err)
+	}
+	dump.Snapshots, err = reader.ListSnapshots()
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	if _, ok := reader.Decoder().(database.StorageDecoder); ok {
+		dump.Storage, err = reader.ListDevboxStorage()
+		if err != nil {
+			return fmt.Errorf("failed to list devbox storage: %w", err)
+		}
+	}
+
+	return writeOutput(func(w io.Writer) error {
+		return export.WriteSQL(w, dump, exportDropTables)
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVar(&exportFormat, "format", "sql", "Export format (sql)")
+	exportCmd.Flags().BoolVar(&exportDropTables, "drop-tables", false, "Drop existing tables before creating them")
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportCmd(t *testing.T) {
+	if exportCmd.Use != "export" {
+		t.Errorf("Expected export command use = 'export', got %s", exportCmd.Use)
+	}
+	flag := exportCmd.Flags().Lookup("format")
+	if flag == nil || flag.DefValue != "sql" {
+		t.Error("Expected --format flag defaulting to sql")
+	}
+	if exportCmd.Flags().Lookup("drop-tables") == nil {
+		t.Error("Expected --drop-tables flag")
+	}
+}
+
+func TestExportCmd_Run(t *testing.T) {
+	dbPath := setupFixtureDB(t, "devbox")
+
+	out, err := executeCommand(t, "--db-path", dbPath, "export", "--format", "sql")
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	expected := []string{
+		"CREATE TABLE snapshots (",
+		"INSERT INTO buckets (name, key_count) VALUES ('v1', ",
+		"VALUES ('content-2', 'lv-content-2', '/var/lib/devbox/content-2', 'removed');",
+		"VALUES ('container-1', 3, 'active', 'layer-2', ",
+		"VALUES ('layer-1', 'containerd.io/gc.root', '2024-01-01T00:00:00Z');",
+	}
+	for _, s := range expected {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected export to contain %q\n%s", s, out)
+		}
+	}
+
+	if _, err := executeCommand(t, "--db-path", dbPath, "export", "--format", "csv"); err == nil {
+		t.Error("Expected an error for an unsupported export format")
+	}
+}
+
+func TestExportCmd_Overlayfs(t *testing.T) {
+	dbPath := setupFixtureDB(t, "overlayfs")
+
+	out, err := executeCommand(t, "--db-path", dbPath, "export")
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if strings.Contains(out, "INSERT INTO devbox_storage") {
+		t.Errorf("Expected no devbox storage rows for an overlayfs database\n%s", out)
+	}
+	if !strings.Contains(out, "CREATE TABLE devbox_storage") {
+		t.Error("Expected the devbox storage table to be created anyway")
+	}
+}
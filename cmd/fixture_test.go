@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/metadata/boltutil"
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	bolt "go.etcd.io/bbolt"
+)
+
+// fixtureSnapshot is a snapshot written to fixture databases
+type fixtureSnapshot struct {
+	key       string
+	id        uint64
+	kind      snapshots.Kind
+	parent    string
+	age       time.Duration
+	size      int64
+	labels    map[string]string
+	contentID string
+	path      string
+}
+
+// fixtureSnapshots are the snapshots of fixture databases: two committed
+// layers, a container on top of them and a view of the lower layer
+var fixtureSnapshots = []fixtureSnapshot{
+	{key: "layer-1", id: 1, kind: snapshots.KindCommitted, age: 10 * 24 * time.Hour, size: 1 << 20,
+		labels: map[string]string{"containerd.io/gc.root": "2024-01-01T00:00:00Z"}},
+	{key: "layer-2", id: 2, kind: snapshots.KindCommitted, parent: "layer-1", age: 8 * 24 * time.Hour, size: 20 << 30},
+	{key: "container-1", id: 3, kind: snapshots.KindActive, parent: "layer-2", age: time.Hour, size: 4096,
+		contentID: "content-1", path: "/var/lib/devbox/content-1"},
+	{key: "view-1", id: 4, kind: snapshots.KindView, parent: "layer-1", age: time.Minute},
+}
+
+// fixtureStorage are the storage entries of devbox fixture databases:
+// content-1 is active, content-2 removed
+var fixtureStorage = []database.DevboxStorageInfo{
+	{ContentID: "content-1", LvName: "lv-content-1", Path: "/var/lib/devbox/content-1", Status: "active"},
+	{ContentID: "content-2", LvName: "lv-content-2", Path: "/var/lib/devbox/content-2", Status: "removed"},
+}
+
+// setupFixtureDB creates a database in the root directory of the named
+// snapshotter plugin and returns its path. Devbox databases also get the
+// fixtureStorage entries.
+func setupFixtureDB(t *testing.T, plugin string) string {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), "io.containerd.snapshotter.v1."+plugin)
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatalf("Failed to create snapshotter root: %v", err)
+	}
+	path := filepath.Join(root, "metadata.db")
+
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		v1, err := tx.CreateBucket([]byte("v1"))
+		if err != nil {
+			return err
+		}
+		snapshotsBkt, err := v1.CreateBucket([]byte("snapshots"))
+		if err != nil {
+			return err
+		}
+		if _, err := v1.CreateBucket([]byte("parents")); err != nil {
+			return err
+		}
+
+		for _, s := range fixtureSnapshots {
+			if err := writeFixtureSnapshot(snapshotsBkt, s, plugin == "devbox"); err != nil {
+				return err
+			}
+		}
+
+		if plugin == "devbox" {
+			return writeFixtureStorage(v1, fixtureStorage)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to write fixture data: %v", err)
+	}
+	return path
+}
+
+// updateFixtureDB runs fn on the v1 bucket of the fixture database at path
+func updateFixtureDB(t *testing.T, path string, fn func(v1 *bolt.Bucket) error) {
+	t.Helper()
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return fn(tx.Bucket([]byte("v1")))
+	})
+	if err != nil {
+		t.Fatalf("Failed to update fixture data: %v", err)
+	}
+}
+
+// addFixtureStorage adds devbox storage entries to the fixture database at path
+func addFixtureStorage(t *testing.T, path string, entries ...database.DevboxStorageInfo) {
+	t.Helper()
+	updateFixtureDB(t, path, func(v1 *bolt.Bucket) error {
+		return writeFixtureStorage(v1, entries)
+	})
+}
+
+// writeFixtureStorage writes devbox storage entries, including their extra
+// keys, to the storage bucket under v1
+func writeFixtureStorage(v1 *bolt.Bucket, entries []database.DevboxStorageInfo) error {
+	storageBkt, err := v1.CreateBucketIfNotExists(database.DevboxStoragePathBucket)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		bkt, err := storageBkt.CreateBucket([]byte(entry.ContentID))
+		if err != nil {
+			return err
+		}
+		values := map[string]string{
+			string(database.DevboxKeyLvName): entry.LvName,
+			string(database.DevboxKeyPath):   entry.Path,
+			string(database.DevboxKeyStatus): entry.Status,
+		}
+		for key, value := range entry.Extra {
+			values[key] = value
+		}
+		for key, value := range values {
+			if value == "" {
+				continue
+			}
+			if err := bkt.Put([]byte(key), []byte(value)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeFixtureSnapshot(bkt *bolt.Bucket, s fixtureSnapshot, devbox bool) error {
+	snapBkt, err := bkt.CreateBucket([]byte(s.key))
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 16)
+	n := utils.EncodeID(buf, s.id)
+	if err := snapBkt.Put([]byte("id"), append([]byte(nil), buf[:n]...)); err != nil {
+		return err
+	}
+	if err := snapBkt.Put([]byte("kind"), []byte{byte(s.kind)}); err != nil {
+		return err
+	}
+	if s.parent != "" {
+		if err := snapBkt.Put([]byte("parent"), []byte(s.parent)); err != nil {
+			return err
+		}
+	}
+
+	created := time.Now().Add(-s.age)
+	if err := boltutil.WriteTimestamps(snapBkt, created, created); err != nil {
+		return err
+	}
+	if err := boltutil.WriteLabels(snapBkt, s.labels); err != nil {
+		return err
+	}
+
+	n = utils.EncodeSize(buf, 100)
+	if err := snapBkt.Put([]byte("inodes"), append([]byte(nil), buf[:n]...)); err != nil {
+		return err
+	}
+	n = utils.EncodeSize(buf, s.size)
+	if err := snapBkt.Put([]byte("size"), append([]byte(nil), buf[:n]...)); err != nil {
+		return err
+	}
+
+	if devbox && s.contentID != "" {
+		if err := snapBkt.Put(database.DevboxKeyContentID, []byte(s.contentID)); err != nil {
+			return err
+		}
+		if err := snapBkt.Put(database.DevboxKeyPath, []byte(s.path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setupFixtureHostRoot creates a devbox fixture database under a fake host
+// root and returns the root and the host path of the database
+func setupFixtureHostRoot(t *testing.T) (string, string) {
+	t.Helper()
+	dbPath := setupFixtureDB(t, "devbox")
+	hostRoot := filepath.Dir(filepath.Dir(dbPath))
+	return hostRoot, strings.TrimPrefix(dbPath, hostRoot)
+}
+
+// executeCommand runs the CLI with args and returns what it wrote to its
+// standard output. Flags are reset to their defaults afterwards.
+func executeCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	defer resetCommandState()
+
+	// Formatters print to os.Stdout directly
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	captured := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		captured <- buf.String()
+	}()
+
+	stdout := os.Stdout
+	os.Stdout = w
+	var stderr bytes.Buffer
+	rootCmd.SetErr(&stderr)
+	rootCmd.SetArgs(args)
+	err = rootCmd.Execute()
+	os.Stdout = stdout
+	w.Close()
+	return <-captured, err
+}
+
+// executeJSON runs the CLI with JSON output and decodes the items, or the
+// item of single objects, of the envelope into v
+func executeJSON(t *testing.T, v interface{}, args ...string) {
+	t.Helper()
+	out, err := executeCommand(t, append([]string{"-o", "json"}, args...)...)
+	if err != nil {
+		t.Fatalf("%s failed: %v", strings.Join(args, " "), err)
+	}
+	var envelope struct {
+		Items json.RawMessage `json:"items"`
+		Item  json.RawMessage `json:"item"`
+	}
+	if err := json.Unmarshal([]byte(out), &envelope); err != nil {
+		t.Fatalf("Failed to decode output: %v\n%s", err, out)
+	}
+	payload := envelope.Items
+	if payload == nil {
+		payload = envelope.Item
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		t.Fatalf("Failed to decode output: %v\n%s", err, out)
+	}
+}
+
+// writeFixtureFile writes content to path, creating its directory
+func writeFixtureFile(t *testing.T, path, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+// resetCommandState restores the flags of every command and the state the
+// root command derives from them
+func resetCommandState() {
+	var reset func(c *cobra.Command)
+	reset = func(c *cobra.Command) {
+		for _, flags := range []*pflag.FlagSet{c.PersistentFlags(), c.LocalNonPersistentFlags()} {
+			flags.VisitAll(func(f *pflag.Flag) {
+				if slice, ok := f.Value.(pflag.SliceValue); ok {
+					defaults := strings.Trim(f.DefValue, "[]")
+					values := []string{}
+					if defaults != "" {
+						values = strings.Split(defaults, ",")
+					}
+					_ = slice.Replace(values)
+				} else {
+					_ = f.Value.Set(f.DefValue)
+				}
+				f.Changed = false
+			})
+		}
+		for _, sub := range c.Commands() {
+			reset(sub)
+		}
+	}
+	reset(rootCmd)
+
+	rootCmd.SetErr(nil)
+	rootCmd.SetArgs(nil)
+	utils.HostRoot = ""
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/gc"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gcMetaDBPath      string
+	gcMetaSnapshotter string
+	gcOnlyReclaimed   bool
+)
+
+// gcPreviewCmd represents the gc-preview command
+var gcPreviewCmd = &cobra.Command{
+	Use:   "gc-preview",
+	Short: "Simulate containerd garbage collection for snapshots",
+	Long: `Build containerd's garbage collection reference graph from meta.db and
+cross it with the snapshotter database. Leases, containerd.io/gc.root labels,
+gc.ref.snapshot.* / gc.ref.content.* labels, containers and images are used
+to decide which snapshots are roots, which are referenced (and by what), and
+which are unreferenced and would be reclaimed by the next GC run.
+
+Snapshots are matched against the meta.db entries of the snapshotter named
+by --meta-snapshotter. By default this is the plugin directory holding the
+snapshotter database (io.containerd.snapshotter.v1.<name>), the plugin of
+the --profile in use, or else the detected schema.`,
+	RunE: runGCPreview,
+}
+
+func runGCPreview(cmd *cobra.Command, args []string) error {
+	metaReader, err := database.NewMetaReader(gcMetaDBPath)
+	if err != nil {
+		return fmt.Errorf("failed to create containerd metadata reader: %w", err)
+	}
+	defer metaReader.Close()
+
+	meta, err := metaReader.ReadContainerdMetadata()
+	if err != nil {
+		return fmt.Errorf("failed to read containerd metadata: %w", err)
+	}
+
+	reader, err := newReader()
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	snapshots, err := reader.ListSnapshots()
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	snapshotter := gcMetaSnapshotter
+	if snapshotter == "" {
+		snapshotter = reader.SnapshotterName()
+	}
+
+	report := gc.Preview(meta, snapshotter, snapshots, time.Now())
+	if gcOnlyReclaimed {
+		var reclaimable []gc.SnapshotResult
+		for _, snapshot := range report.Snapshots {
+			if snapshot.Status == gc.StatusUnreferenced {
+				reclaimable = append(reclaimable, snapshot)
+			}
+		}
+		report.Snapshots = reclaimable
+	}
+
+	return formatOutput(reader, report)
+}
+
+func init() {
+	rootCmd.AddCommand(gcPreviewCmd)
+
+	gcPreviewCmd.Flags().StringVar(&gcMetaDBPath, "meta-db", database.DefaultContainerdMetaDBPath, "Path to containerd's meta.db")
+	gcPreviewCmd.Flags().StringVar(&gcMetaSnapshotter, "meta-snapshotter", "", "Snapshotter name as recorded in meta.db (default: derived from the snapshotter database)")
+	gcPreviewCmd.Flags().BoolVar(&gcOnlyReclaimed, "unreferenced", false, "Only show snapshots that would be reclaimed")
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/gc"
+	bolt "go.etcd.io/bbolt"
+)
+
+// setupGCMetaDB creates a containerd meta.db whose default namespace has a
+// container using the snapshot container-1 of the named snapshotter
+func setupGCMetaDB(t *testing.T, snapshotter string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "meta.db")
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatalf("Failed to create meta.db: %v", err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		v1, err := tx.CreateBucket([]byte("v1"))
+		if err != nil {
+			return err
+		}
+		ns, err := v1.CreateBucket([]byte("default"))
+		if err != nil {
+			return err
+		}
+		container, err := ns.CreateBucket([]byte("containers"))
+		if err == nil {
+			container, err = container.CreateBucket([]byte("web"))
+		}
+		if err != nil {
+			return err
+		}
+		if err := container.Put([]byte("snapshotter"), []byte(snapshotter)); err != nil {
+			return err
+		}
+		if err := container.Put([]byte("snapshotKey"), []byte("web")); err != nil {
+			return err
+		}
+
+		snapshot, err := ns.CreateBucket([]byte("snapshots"))
+		if err == nil {
+			snapshot, err = snapshot.CreateBucket([]byte(snapshotter))
+		}
+		if err == nil {
+			snapshot, err = snapshot.CreateBucket([]byte("web"))
+		}
+		if err != nil {
+			return err
+		}
+		return snapshot.Put([]byte("name"), []byte("container-1"))
+	})
+	if err != nil {
+		t.Fatalf("Failed to write meta.db: %v", err)
+	}
+	return path
+}
+
+func TestGCPreviewCmd(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"gc-preview"})
+	if err != nil {
+		t.Fatalf("Expected gc-preview command to exist, got error: %v", err)
+	}
+
+	if cmd.Use != "gc-preview" {
+		t.Errorf("Expected gc-preview command use = 'gc-preview', got %s", cmd.Use)
+	}
+
+	if cmd.Short == "" || cmd.Long == "" {
+		t.Error("Expected gc-preview command to have short and long descriptions")
+	}
+
+	if cmd.RunE == nil {
+		t.Error("Expected gc-preview command to have RunE function")
+	}
+}
+
+func TestGCPreviewFlags(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"gc-preview"})
+	if err != nil {
+		t.Fatalf("Failed to find gc-preview command: %v", err)
+	}
+
+	tests := []struct {
+		flagName    string
+		flagDefault string
+	}{
+		{"meta-db", database.DefaultContainerdMetaDBPath},
+		{"meta-snapshotter", ""},
+		{"unreferenced", "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flagName, func(t *testing.T) {
+			flag := cmd.Flags().Lookup(tt.flagName)
+			if flag == nil {
+				t.Fatalf("Expected flag %s to exist", tt.flagName)
+			}
+			if flag.DefValue != tt.flagDefault {
+				t.Errorf("Expected flag %s default value = %s, got %s", tt.flagName, tt.flagDefault, flag.DefValue)
+			}
+		})
+	}
+}
+
+func TestGCPreview_Run(t *testing.T) {
+	// stargz databases are decoded as overlayfs, but meta.db records the
+	// snapshots under the plugin name
+	dbPath := setupFixtureDB(t, "stargz")
+	metaDB := setupGCMetaDB(t, "stargz")
+
+	status := func(report gc.Report, key string) gc.SnapshotStatus {
+		for _, snapshot := range report.Snapshots {
+			if snapshot.Key == key {
+				return snapshot.Status
+			}
+		}
+		return ""
+	}
+
+	var report gc.Report
+	executeJSON(t, &report, "--db-path", dbPath, "gc-preview", "--meta-db", metaDB)
+	if report.Snapshotter != "stargz" {
+		t.Errorf("Expected snapshotter stargz, got %s", report.Snapshotter)
+	}
+	if got := status(report, "container-1"); got != gc.StatusRoot {
+		t.Errorf("Expected container-1 to be a root, got %s", got)
+	}
+
+	report = gc.Report{}
+	executeJSON(t, &report, "--db-path", dbPath, "gc-preview", "--meta-db", metaDB, "--meta-snapshotter", "overlayfs")
+	if got := status(report, "container-1"); got != gc.StatusUnreferenced {
+		t.Errorf("Expected container-1 unreferenced in the overlayfs bucket, got %s", got)
+	}
+}
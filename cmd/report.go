@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportFormat string
+	reportTop    int
+)
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Render a Markdown or HTML summary of the database",
+	Long: `Render a summary of the snapshotter database for postmortems and capacity
+reviews: bucket key counts, snapshot counts and sizes by kind, the largest
+snapshots, the lineage below each root snapshot and, for devbox databases,
+the storage status breakdown and the LVM map.
+
+The html format is a single file with inline styles and sortable tables,
+and needs no network access to view. The markdown format can be pasted
+into tickets as is.`,
+	Args: cobra.NoArgs,
+	RunE: runReport,
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	if reportFormat != "markdown" && reportFormat != "html" {
+		return fmt.Errorf("invalid report format '%s'. Use 'markdown' or 'html'", reportFormat)
+	}
+
+	reader, err := newReader()
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	in := report.Input{Source: dbPath, Snapshotter: reader.Decoder().Name()}
+	in.Buckets, err = reader.ListBuckets()
+	if err != nil {
+		return fmt.Errorf("failed to list buckets: %w", err)
+	}
+	in.Snapshots, err = reader.ListSnapshots()
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	if _, ok := reader.Decoder().(database.StorageDecoder); ok {
+		in.Devbox = true
+		in.Storage, err = reader.ListDevboxStorage()
+		if err != nil {
+			return fmt.Errorf("failed to list devbox storage: %w", err)
+		}
+	}
+
+	r := report.Build(in, reportTop, time.Now())
+	opts := tableOptions()
+	return writeOutput(func(w io.Writer) error {
+		if reportFormat == "html" {
+			return report.WriteHTML(w, r, opts)
+		}
+		return report.WriteMarkdown(w, r, opts)
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	reportCmd.Flags().StringVar(&reportFormat, "format", "markdown", "Report format (markdown|html)")
+	reportCmd.Flags().IntVar(&reportTop, "top", report.DefaultTop, "Number of snapshots in the largest snapshots section")
+}
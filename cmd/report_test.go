@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReportCmd(t *testing.T) {
+	if reportCmd.Use != "report" {
+		t.Errorf("Expected report command use = 'report', got %s", reportCmd.Use)
+	}
+	for _, name := range []string{"format", "top"} {
+		if reportCmd.Flags().Lookup(name) == nil {
+			t.Errorf("Expected --%s flag", name)
+		}
+	}
+}
+
+func TestReportCmd_Run(t *testing.T) {
+	dbPath := setupFixtureDB(t, "devbox")
+
+	out, err := executeCommand(t, "--db-path", dbPath, "report", "--top", "1")
+	if err != nil {
+		t.Fatalf("report failed: %v", err)
+	}
+	for _, s := range []string{"# Snapshotter metadata report", "| layer-2 | committed |", "| lv-content-1 | /var/lib/devbox/content-1 |"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected Markdown report to contain %q\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "| layer-1 | committed |") {
+		t.Error("Expected --top 1 to keep only the largest snapshot")
+	}
+
+	out, err = executeCommand(t, "--db-path", dbPath, "report", "--format", "html")
+	if err != nil {
+		t.Fatalf("report failed: %v", err)
+	}
+	if !strings.HasPrefix(out, "<!DOCTYPE html>") || !strings.Contains(out, "Devbox storage by status") {
+		t.Errorf("Expected an HTML report with the devbox sections\n%s", out)
+	}
+
+	if _, err := executeCommand(t, "--db-path", dbPath, "report", "--format", "pdf"); err == nil {
+		t.Error("Expected an error for an unsupported report format")
+	}
+}
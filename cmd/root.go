@@ -2,8 +2,16 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/discover"
+	"github.com/containerd/meta-viewer/internal/filter"
+	"github.com/containerd/meta-viewer/internal/formatters"
+	"github.com/containerd/meta-viewer/internal/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -12,9 +20,28 @@ const (
 )
 
 var (
-	dbPath   string
-	output   string
-	verbose  bool
+	dbPath      string
+	output      string
+	outputFile  string
+	verbose     bool
+	snapshotter string
+	profile     string
+
+	autoDBPath       bool
+	containerdConfig string
+	hostRoot         string
+
+	noTrunc  bool
+	wide     bool
+	units    string
+	timezone string
+	utc      bool
+	location *time.Location
+
+	sortBy     string
+	reverse    bool
+	fields     []string
+	filterExpr string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -25,6 +52,28 @@ var rootCmd = &cobra.Command{
 stored by containerd snapshotters. It allows you to view snapshots,
 storage information, and LVM mappings stored in the bolt database.`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Resolve host paths under the mounted host filesystem
+		if hostRoot != "" {
+			if stat, err := os.Stat(hostRoot); err != nil || !stat.IsDir() {
+				fmt.Fprintf(os.Stderr, "Error: host root %s is not a directory\n", hostRoot)
+				os.Exit(1)
+			}
+			utils.HostRoot = hostRoot
+		}
+
+		// Locate the database from containerd's configuration
+		if autoDBPath && dbPath == "" && cmd != discoverCmd && cmd != schemaCmd {
+			path, err := autoDetectDBPath()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			dbPath = path
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Using discovered database path: %s\n", dbPath)
+			}
+		}
+
 		// Use default database path if not provided
 		if dbPath == "" {
 			dbPath = defaultDBPath
@@ -34,13 +83,194 @@ storage information, and LVM mappings stored in the bolt database.`,
 		}
 
 		// Validate output format
-		if output != "table" && output != "json" {
-			fmt.Fprintf(os.Stderr, "Error: invalid output format '%s'. Use 'table' or 'json'\n", output)
+		if _, err := formatters.New(output, formatters.Options{Writer: io.Discard}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Validate table options
+		if err := validateTableOptions(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Validate snapshotter type override
+		if snapshotter != "" {
+			if _, err := database.GetDecoder(snapshotter); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if snapshotter != "" && profile != "" {
+			fmt.Fprintf(os.Stderr, "Error: --snapshotter and --schema-profile cannot be used together\n")
 			os.Exit(1)
 		}
 	},
 }
 
+// newReader opens the snapshotter database and selects its schema decoder,
+// honouring the --snapshotter and --schema-profile overrides
+func newReader() (*database.MetaReader, error) {
+	reader, err := database.NewMetaReader(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshotter != "" {
+		if err := reader.SetDecoder(snapshotter); err != nil {
+			reader.Close()
+			return nil, err
+		}
+	}
+
+	if profile != "" {
+		schemaProfile, err := database.FindProfile(profile)
+		if err != nil {
+			reader.Close()
+			return nil, err
+		}
+		reader.UseProfile(schemaProfile)
+	}
+
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Using %s snapshotter schema\n", reader.Decoder().Name())
+	}
+	return reader, nil
+}
+
+// requireCommandGroup fails when the decoder of the database does not
+// contribute the top-level command group cmd belongs to, e.g. devbox
+// commands on an overlayfs database
+func requireCommandGroup(reader *database.MetaReader, cmd *cobra.Command) error {
+	group := cmd
+	for group.HasParent() && group.Parent() != rootCmd {
+		group = group.Parent()
+	}
+	name := group.Name()
+	if database.HasCommandGroup(reader.Decoder(), name) {
+		return nil
+	}
+
+	hint := "--snapshotter"
+	if snapshotters := database.CommandGroupDecoders(name); len(snapshotters) == 1 {
+		hint += " " + snapshotters[0]
+	}
+	if database.HasCommandGroup(database.NewProfileDecoder(database.DevboxProfile), name) {
+		// Every schema profile contributes the groups of the devbox profile
+		hint += " or --schema-profile"
+	}
+	return fmt.Errorf("%s commands need a %s snapshotter database, but %s looks like %s (use %s to override)",
+		name, name, dbPath, reader.Decoder().Name(), hint)
+}
+
+// validateTableOptions checks --units and resolves the time zone selected
+// by --tz or --utc
+func validateTableOptions() error {
+	valid := false
+	for _, name := range formatters.SizeUnits {
+		valid = valid || units == name
+	}
+	if !valid {
+		return fmt.Errorf("invalid units '%s'. Use one of: %s", units, strings.Join(formatters.SizeUnits, ", "))
+	}
+
+	switch {
+	case utc && timezone != "":
+		return fmt.Errorf("--tz and --utc cannot be used together")
+	case utc:
+		location = time.UTC
+	case timezone != "":
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return fmt.Errorf("invalid time zone '%s': %w", timezone, err)
+		}
+		location = loc
+	default:
+		location = time.Local
+	}
+	return nil
+}
+
+// tableOptions returns the table layout selected by the flags. Tables
+// written to stdout are fitted to the terminal and colored when stdout is a
+// terminal and NO_COLOR is not set; tables written to --output-file are not.
+func tableOptions() formatters.TableOptions {
+	opts := formatters.TableOptions{
+		NoTrunc:  noTrunc,
+		Wide:     wide,
+		Units:    units,
+		Location: location,
+	}
+	if outputFile == "" {
+		opts.Width = utils.TerminalWidth(os.Stdout)
+		opts.Color = utils.IsTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+	}
+	return opts
+}
+
+// addFilterFlag adds the flag filtering list output to commands listing records
+func addFilterFlag(commands ...*cobra.Command) {
+	for _, c := range commands {
+		c.Flags().StringVar(&filterExpr, "filter", "", `Only output records matching an expression, e.g. 'kind==active && size>10GiB && created_at<now-7d'`)
+	}
+}
+
+// addListFlags adds the flags filtering and sorting list output and
+// selecting its fields
+func addListFlags(commands ...*cobra.Command) {
+	addFilterFlag(commands...)
+	for _, c := range commands {
+		c.Flags().StringVar(&sortBy, "sort-by", "", "Sort by a field, e.g. size, created_at, id or label:<name> (default: database order)")
+		c.Flags().BoolVar(&reverse, "reverse", false, "Reverse the --sort-by order")
+		c.Flags().StringSliceVar(&fields, "fields", nil, "Fields to output, in order, e.g. key,size,label:<name> (default: all fields, or the usual table columns)")
+	}
+}
+
+// formatOutput writes data in the --output format, filtered by --filter,
+// sorted by --sort-by and reduced to --fields. reader is the database the
+// data was read from, recorded in JSON output, or nil.
+func formatOutput(reader *database.MetaReader, data interface{}) error {
+	if filterExpr != "" {
+		expr, err := filter.Parse(filterExpr, time.Now().In(location))
+		if err != nil {
+			return err
+		}
+		if data, err = formatters.FilterRecords(data, expr); err != nil {
+			return err
+		}
+	}
+	if sortBy != "" {
+		if err := formatters.SortRecords(data, sortBy, reverse); err != nil {
+			return err
+		}
+	}
+
+	opts := formatters.Options{Pretty: verbose, Table: tableOptions(), Fields: fields}
+	if reader != nil {
+		source := reader.Source()
+		opts.Source = &source
+	}
+
+	return writeOutput(func(w io.Writer) error {
+		opts.Writer = w
+		formatter, err := formatters.New(output, opts)
+		if err != nil {
+			return err
+		}
+		return formatter.Format(data)
+	})
+}
+
+// writeOutput calls write with stdout, or with a temporary file that
+// replaces --output-file once write succeeds, so a failed or interrupted run
+// never leaves a partially written file behind
+func writeOutput(write func(w io.Writer) error) error {
+	if outputFile == "" {
+		return write(os.Stdout)
+	}
+	return utils.WriteFileAtomic(outputFile, write)
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -52,6 +282,17 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&dbPath, "db-path", "p", "", "Path to the containerd metadata.db file (default: /var/lib/containerd/io.containerd.snapshotter.v1.devbox/metadata.db)")
-	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", "table", "Output format (table|json)")
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", "table", "Output format ("+strings.Join(formatters.Formats(), "|")+"|"+strings.Join(formatters.TemplateFormats(), "=...|")+"=...)")
+	rootCmd.PersistentFlags().StringVar(&outputFile, "output-file", "", "Write the output to this file, replacing it only once the output is complete")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().StringVar(&snapshotter, "snapshotter", "", "Snapshotter schema ("+strings.Join(database.DecoderNames(), "|")+"), auto-detected by default")
+	rootCmd.PersistentFlags().BoolVar(&autoDBPath, "auto", false, "Discover the database from containerd's config.toml when --db-path is not given")
+	rootCmd.PersistentFlags().StringVar(&containerdConfig, "containerd-config", discover.DefaultConfigPath, "Path to containerd's config.toml used by --auto and discover")
+	rootCmd.PersistentFlags().StringVar(&hostRoot, "host-root", "", "Directory the host filesystem is mounted at (e.g. /host); host paths are resolved under it")
+	rootCmd.PersistentFlags().StringVar(&profile, "schema-profile", "", "Schema profile name or JSON file describing a forked devbox style layout")
+	rootCmd.PersistentFlags().BoolVar(&noTrunc, "no-trunc", false, "Do not truncate table cells to fit the terminal width")
+	rootCmd.PersistentFlags().BoolVar(&wide, "wide", false, "Add less used columns to tables, such as snapshot labels")
+	rootCmd.PersistentFlags().StringVar(&units, "units", formatters.UnitsIEC, "Units of sizes in tables ("+strings.Join(formatters.SizeUnits, "|")+")")
+	rootCmd.PersistentFlags().StringVar(&timezone, "tz", "", "Time zone of times in tables and --filter, e.g. Asia/Shanghai (default: local time)")
+	rootCmd.PersistentFlags().BoolVar(&utc, "utc", false, "Print times in tables and read times in --filter in UTC")
 }
\ No newline at end of file
@@ -1,8 +1,14 @@
 package cmd
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/containerd/meta-viewer/internal/formatters"
 	"github.com/spf13/cobra"
 )
 
@@ -40,6 +46,36 @@ func TestRootFlags(t *testing.T) {
 			flagDefault: "false",
 			exists:      true,
 		},
+		{
+			name:        "snapshotter flag",
+			flagName:    "snapshotter",
+			flagDefault: "",
+			exists:      true,
+		},
+		{
+			name:        "schema profile flag",
+			flagName:    "schema-profile",
+			flagDefault: "",
+			exists:      true,
+		},
+		{
+			name:        "auto flag",
+			flagName:    "auto",
+			flagDefault: "false",
+			exists:      true,
+		},
+		{
+			name:        "containerd config flag",
+			flagName:    "containerd-config",
+			flagDefault: "/etc/containerd/config.toml",
+			exists:      true,
+		},
+		{
+			name:        "host root flag",
+			flagName:    "host-root",
+			flagDefault: "",
+			exists:      true,
+		},
 		{
 			name:        "non-existent flag",
 			flagName:    "non-existent",
@@ -103,4 +139,484 @@ func TestRootCmdRequiredFlags(t *testing.T) {
 
 	// Check if annotation indicates it's required (this depends on cobra version)
 	// For now, we'll just check that the flag exists
+}
+
+func TestOutputFormats_Run(t *testing.T) {
+	dbPath := setupFixtureDB(t, "devbox")
+
+	t.Run("name", func(t *testing.T) {
+		out, err := executeCommand(t, "--db-path", dbPath, "-o", "name", "snapshots", "list")
+		if err != nil {
+			t.Fatalf("snapshots list failed: %v", err)
+		}
+		expected := "container-1\nlayer-1\nlayer-2\nview-1\n"
+		if out != expected {
+			t.Errorf("Expected snapshot keys %q, got %q", expected, out)
+		}
+
+		out, err = executeCommand(t, "--db-path", dbPath, "-o", "name", "devbox", "list")
+		if err != nil {
+			t.Fatalf("devbox list failed: %v", err)
+		}
+		if out != "content-1\ncontent-2\n" {
+			t.Errorf("Expected content IDs, got %q", out)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		out, err := executeCommand(t, "--db-path", dbPath, "-o", "csv", "snapshots", "get", "layer-1")
+		if err != nil {
+			t.Fatalf("snapshots get failed: %v", err)
+		}
+		records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse CSV: %v\n%s", err, out)
+		}
+		if len(records) != 2 {
+			t.Fatalf("Expected a header and one row, got %v", records)
+		}
+		row := make(map[string]string)
+		for i, column := range records[0] {
+			row[column] = records[1][i]
+		}
+		if row["kind"] != "committed" || row["labels"] != `{"containerd.io/gc.root":"2024-01-01T00:00:00Z"}` {
+			t.Errorf("Expected the kind name and labels as a JSON object, got %v", row)
+		}
+	})
+
+	t.Run("tsv", func(t *testing.T) {
+		out, err := executeCommand(t, "--db-path", dbPath, "-o", "tsv", "devbox", "lvm-map")
+		if err != nil {
+			t.Fatalf("devbox lvm-map failed: %v", err)
+		}
+		expected := "lv_name\tpath\nlv-content-1\t/var/lib/devbox/content-1\nlv-content-2\t/var/lib/devbox/content-2\n"
+		if out != expected {
+			t.Errorf("Expected %q, got %q", expected, out)
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		out, err := executeCommand(t, "--db-path", dbPath, "-o", "ndjson", "buckets")
+		if err != nil {
+			t.Fatalf("buckets failed: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		if len(lines) != 1 {
+			t.Fatalf("Expected one line per bucket, got %q", out)
+		}
+		var bucket map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[0]), &bucket); err != nil {
+			t.Fatalf("Failed to decode line: %v", err)
+		}
+		if bucket["name"] != "v1" {
+			t.Errorf("Expected the v1 bucket, got %v", bucket)
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		out, err := executeCommand(t, "--db-path", dbPath, "-o", "yaml", "devbox", "get", "content-2")
+		if err != nil {
+			t.Fatalf("devbox get failed: %v", err)
+		}
+		expected := "content_id: content-2\nlv_name: lv-content-2\npath: /var/lib/devbox/content-2\nstatus: removed\n"
+		if out != expected {
+			t.Errorf("Expected %q, got %q", expected, out)
+		}
+	})
+
+	t.Run("formats listed in help", func(t *testing.T) {
+		flag := rootCmd.PersistentFlags().Lookup("output")
+		for _, format := range append(formatters.Formats(), formatters.TemplateFormats()...) {
+			if !strings.Contains(flag.Usage, format) {
+				t.Errorf("Expected --output usage to list %s", format)
+			}
+		}
+	})
+}
+
+func TestTemplateOutputFormats_Run(t *testing.T) {
+	dbPath := setupFixtureDB(t, "devbox")
+
+	t.Run("go-template", func(t *testing.T) {
+		out, err := executeCommand(t, "--db-path", dbPath, "-o", `go-template={{range .}}{{.key}}={{.size}}{{"\n"}}{{end}}`, "snapshots", "search", "--content-id", "content-1")
+		if err != nil {
+			t.Fatalf("snapshots search failed: %v", err)
+		}
+		if out != "container-1=4096\n" {
+			t.Errorf("Expected the key and size of container-1, got %q", out)
+		}
+	})
+
+	t.Run("jsonpath", func(t *testing.T) {
+		out, err := executeCommand(t, "--db-path", dbPath, "-o", "jsonpath={.lv_name}", "devbox", "get", "content-1")
+		if err != nil {
+			t.Fatalf("devbox get failed: %v", err)
+		}
+		if out != "lv-content-1" {
+			t.Errorf("Expected lv-content-1, got %q", out)
+		}
+	})
+
+	t.Run("jsonpath on the envelope", func(t *testing.T) {
+		// Templates see the items, not the JSON envelope
+		_, err := executeCommand(t, "--db-path", dbPath, "-o", "jsonpath={.items[*].key}", "snapshots", "list")
+		if err == nil || !strings.Contains(err.Error(), "not the JSON envelope") {
+			t.Errorf("Expected an error pointing at the envelope, got %v", err)
+		}
+	})
+
+	t.Run("custom-columns", func(t *testing.T) {
+		out, err := executeCommand(t, "--db-path", dbPath, "-o", "custom-columns=ID:.content_id,LV:.lv_name,SIZE:.size", "devbox", "list")
+		if err != nil {
+			t.Fatalf("devbox list failed: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		if len(lines) != 3 || strings.Join(strings.Fields(lines[1]), " ") != "content-1 lv-content-1 <none>" {
+			t.Errorf("Expected a header and two rows with <none> for missing fields, got %q", out)
+		}
+	})
+
+	t.Run("template files", func(t *testing.T) {
+		dir := t.TempDir()
+		templatePath := writeFixtureFile(t, filepath.Join(dir, "keys.tmpl"), `{{range .}}{{.name}}{{end}}`)
+		columnsPath := writeFixtureFile(t, filepath.Join(dir, "columns.txt"), "NAME KEYS\n.name .key_count\n")
+
+		out, err := executeCommand(t, "--db-path", dbPath, "-o", "go-template-file="+templatePath, "buckets")
+		if err != nil {
+			t.Fatalf("buckets failed: %v", err)
+		}
+		if out != "v1" {
+			t.Errorf("Expected v1, got %q", out)
+		}
+
+		out, err = executeCommand(t, "--db-path", dbPath, "-o", "custom-columns-file="+columnsPath, "buckets")
+		if err != nil {
+			t.Fatalf("buckets failed: %v", err)
+		}
+		if !strings.HasPrefix(out, "NAME") || !strings.Contains(out, "v1") {
+			t.Errorf("Expected a NAME column with v1, got %q", out)
+		}
+	})
+
+	t.Run("invalid templates", func(t *testing.T) {
+		for _, value := range []string{"jsonpath", "jsonpath={.key", "go-template={{.key", "custom-columns=KEY", "yaml=x", "jsonpath-file=/nonexistent"} {
+			if _, err := formatters.New(value, formatters.Options{}); err == nil {
+				t.Errorf("Expected an error for -o %s", value)
+			}
+		}
+	})
+}
+
+func TestJSONEnvelope_Run(t *testing.T) {
+	dbPath := setupFixtureDB(t, "devbox")
+
+	var envelope struct {
+		APIVersion string                   `json:"apiVersion"`
+		Kind       string                   `json:"kind"`
+		Source     string                   `json:"source"`
+		ReadAt     string                   `json:"readAt"`
+		TxID       int                      `json:"txid"`
+		Items      []map[string]interface{} `json:"items"`
+		Item       map[string]interface{}   `json:"item"`
+	}
+	decode := func(args ...string) {
+		t.Helper()
+		out, err := executeCommand(t, append([]string{"--db-path", dbPath, "-o", "json"}, args...)...)
+		if err != nil {
+			t.Fatalf("%s failed: %v", strings.Join(args, " "), err)
+		}
+		envelope.Items, envelope.Item = nil, nil
+		if err := json.Unmarshal([]byte(out), &envelope); err != nil {
+			t.Fatalf("Failed to decode output: %v\n%s", err, out)
+		}
+	}
+
+	decode("snapshots", "list")
+	if envelope.APIVersion != "meta-viewer/v1" || envelope.Kind != "SnapshotList" {
+		t.Errorf("Expected a meta-viewer/v1 SnapshotList, got %s %s", envelope.APIVersion, envelope.Kind)
+	}
+	if envelope.Source != dbPath || envelope.ReadAt == "" || envelope.TxID < 1 {
+		t.Errorf("Expected the source database, read time and transaction ID, got %q %q %d", envelope.Source, envelope.ReadAt, envelope.TxID)
+	}
+	if len(envelope.Items) != 4 || envelope.Items[0]["kind"] != "Active" {
+		t.Fatalf("Expected 4 snapshots with kind names, got %v", envelope.Items)
+	}
+	if _, ok := envelope.Items[0]["kind_value"]; ok {
+		t.Error("Expected no kind_value without --verbose")
+	}
+
+	decode("-v", "snapshots", "get", "layer-1")
+	if envelope.Kind != "Snapshot" || envelope.Item["kind"] != "Committed" || envelope.Item["kind_value"] != float64(3) {
+		t.Errorf("Expected a Snapshot item with the numeric kind, got %s %v", envelope.Kind, envelope.Item)
+	}
+
+	decode("snapshots", "search", "--content-id", "missing")
+	if envelope.Items == nil {
+		t.Error("Expected an empty list to be encoded as []")
+	}
+}
+
+func TestTableOptions_Run(t *testing.T) {
+	dbPath := setupFixtureDB(t, "devbox")
+	t.Setenv("COLUMNS", "")
+
+	out, err := executeCommand(t, "--db-path", dbPath, "snapshots", "list")
+	if err != nil {
+		t.Fatalf("snapshots list failed: %v", err)
+	}
+	for _, s := range []string{"AGE", "20.0GiB", "10d ago", "1h ago", "/var/lib/devbox/content-1"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected %q in:\n%s", s, out)
+		}
+	}
+
+	out, err = executeCommand(t, "--db-path", dbPath, "--wide", "--utc", "--units", "bytes", "snapshots", "list")
+	if err != nil {
+		t.Fatalf("snapshots list --wide failed: %v", err)
+	}
+	for _, s := range []string{"LABELS", "UTC", "21474836480", "containerd.io/gc.root=2024-01-01T00:00:00Z"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected %q in wide output:\n%s", s, out)
+		}
+	}
+
+	// COLUMNS sets the width tables are fitted to
+	t.Setenv("COLUMNS", "90")
+	out, err = executeCommand(t, "--db-path", dbPath, "snapshots", "list")
+	if err != nil {
+		t.Fatalf("snapshots list failed: %v", err)
+	}
+	if strings.Contains(out, "/var/lib/devbox/content-1") || !strings.Contains(out, "...") {
+		t.Errorf("Expected the path to be truncated, got:\n%s", out)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if len(line) > 90 {
+			t.Errorf("Expected lines of at most 90 columns, got %d: %q", len(line), line)
+		}
+	}
+
+	out, err = executeCommand(t, "--db-path", dbPath, "--no-trunc", "snapshots", "list")
+	if err != nil {
+		t.Fatalf("snapshots list --no-trunc failed: %v", err)
+	}
+	if !strings.Contains(out, "/var/lib/devbox/content-1") {
+		t.Errorf("Expected the full path with --no-trunc, got:\n%s", out)
+	}
+}
+
+func TestValidateTableOptions(t *testing.T) {
+	defer resetCommandState()
+
+	tests := []struct {
+		units    string
+		timezone string
+		utc      bool
+		valid    bool
+	}{
+		{units: "iec", valid: true},
+		{units: "si", timezone: "UTC", valid: true},
+		{units: "bytes", utc: true, valid: true},
+		{units: "kb"},
+		{units: "iec", timezone: "Nowhere/City"},
+		{units: "iec", timezone: "UTC", utc: true},
+	}
+
+	for _, tt := range tests {
+		units, timezone, utc = tt.units, tt.timezone, tt.utc
+		if err := validateTableOptions(); (err == nil) != tt.valid {
+			t.Errorf("validateTableOptions() with units=%q tz=%q utc=%t: err = %v, expected valid = %t",
+				tt.units, tt.timezone, tt.utc, err, tt.valid)
+		}
+	}
+}
+
+func TestRequireCommandGroup_Run(t *testing.T) {
+	dbPath := setupFixtureDB(t, "overlayfs")
+
+	tests := []struct {
+		args     []string
+		expected string // Expected error, empty when the command runs
+	}{
+		{args: []string{"devbox", "list"}, expected: "devbox commands need a devbox snapshotter database"},
+		{args: []string{"devbox", "list", "--snapshotter", "devbox"}},
+		{args: []string{"devmapper", "snapshots"}, expected: "devmapper commands need a devmapper snapshotter database"},
+		{args: []string{"devmapper", "snapshots", "--snapshotter", "devbox"}, expected: "looks like devbox (use --snapshotter devmapper to override)"},
+	}
+
+	for _, tt := range tests {
+		_, err := executeCommand(t, append([]string{"--db-path", dbPath, "-o", "json"}, tt.args...)...)
+		switch {
+		case tt.expected == "" && err != nil:
+			t.Errorf("%s failed: %v", strings.Join(tt.args, " "), err)
+		case tt.expected != "" && (err == nil || !strings.Contains(err.Error(), tt.expected)):
+			t.Errorf("%s: expected an error containing %q, got %v", strings.Join(tt.args, " "), tt.expected, err)
+		}
+	}
+}
+
+func TestOutputFile_Run(t *testing.T) {
+	dbPath := setupFixtureDB(t, "devbox")
+	dir := t.TempDir()
+	t.Setenv("COLUMNS", "60")
+
+	path := filepath.Join(dir, "buckets.json")
+	out, err := executeCommand(t, "--db-path", dbPath, "-o", "json", "--output-file", path, "buckets")
+	if err != nil {
+		t.Fatalf("buckets failed: %v", err)
+	}
+	if out != "" {
+		t.Errorf("Expected no output on stdout, got %q", out)
+	}
+	var envelope formatters.Envelope
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if err := json.Unmarshal(content, &envelope); err != nil || envelope.Kind != "BucketList" || envelope.Source != dbPath {
+		t.Errorf("Expected a BucketList envelope read from %s, got %s (%v)", dbPath, content, err)
+	}
+
+	// Tables written to a file are not fitted to the terminal
+	path = filepath.Join(dir, "snapshots.txt")
+	if _, err := executeCommand(t, "--db-path", dbPath, "--output-file", path, "snapshots", "list"); err != nil {
+		t.Fatalf("snapshots list failed: %v", err)
+	}
+	if content, _ := os.ReadFile(path); !strings.Contains(string(content), "/var/lib/devbox/content-1") {
+		t.Errorf("Expected the full path in the output file, got:\n%s", content)
+	}
+
+	path = filepath.Join(dir, "report.md")
+	if _, err := executeCommand(t, "--db-path", dbPath, "--output-file", path, "report"); err != nil {
+		t.Fatalf("report failed: %v", err)
+	}
+	if content, _ := os.ReadFile(path); !strings.HasPrefix(string(content), "# ") {
+		t.Errorf("Expected a Markdown report in the output file, got:\n%s", content)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 3 {
+		t.Errorf("Expected no temporary files left in %s, got %d entries", dir, len(entries))
+	}
+}
+
+func TestListFlags_Run(t *testing.T) {
+	dbPath := setupFixtureDB(t, "devbox")
+	t.Setenv("COLUMNS", "")
+
+	out, err := executeCommand(t, "--db-path", dbPath, "snapshots", "list", "--sort-by", "size", "--reverse", "--fields", "key,size,label:containerd.io/gc.root")
+	if err != nil {
+		t.Fatalf("snapshots list failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[0], "KEY") || !strings.HasSuffix(lines[0], "CONTAINERD.IO/GC.ROOT") {
+		t.Fatalf("Expected a KEY, SIZE and label table of 4 snapshots, got:\n%s", out)
+	}
+	for i, key := range []string{"layer-2", "layer-1", "container-1", "view-1"} {
+		if !strings.HasPrefix(lines[i+1], key+" ") {
+			t.Errorf("Expected %s in row %d, got:\n%s", key, i+1, out)
+		}
+	}
+	if !strings.HasSuffix(lines[2], "2024-01-01T00:00:00Z") || !strings.HasSuffix(lines[1], "-") {
+		t.Errorf("Expected the gc.root label of layer-1 only, got:\n%s", out)
+	}
+
+	// The same fields and order in the other formats
+	var snapshots []map[string]interface{}
+	executeJSON(t, &snapshots, "--db-path", dbPath, "snapshots", "list", "--sort-by", "created_at", "--fields", "key,kind")
+	if len(snapshots) != 4 || snapshots[0]["key"] != "layer-1" || snapshots[3]["key"] != "view-1" || len(snapshots[0]) != 2 || snapshots[0]["kind"] != "Committed" {
+		t.Errorf("Expected the key and kind of snapshots oldest first, got %v", snapshots)
+	}
+	out, err = executeCommand(t, "--db-path", dbPath, "-o", "csv", "snapshots", "list", "--sort-by", "id", "--reverse", "--fields", "id,key")
+	if err != nil {
+		t.Fatalf("snapshots list -o csv failed: %v", err)
+	}
+	if out != "id,key\n4,view-1\n3,container-1\n2,layer-2\n1,layer-1\n" {
+		t.Errorf("Unexpected csv output:\n%s", out)
+	}
+
+	out, err = executeCommand(t, "--db-path", dbPath, "devbox", "list", "--sort-by", "status", "--fields", "status,content_id")
+	if err != nil {
+		t.Fatalf("devbox list failed: %v", err)
+	}
+	if lines := strings.Split(out, "\n"); !strings.HasPrefix(lines[0], "STATUS") || !strings.Contains(lines[1], "content-1") || !strings.Contains(lines[2], "content-2") {
+		t.Errorf("Expected storage sorted by status, got:\n%s", out)
+	}
+
+	var buckets []map[string]interface{}
+	executeJSON(t, &buckets, "--db-path", dbPath, "buckets", "--sort-by", "key_count", "--reverse", "--fields", "name")
+	if len(buckets) == 0 || len(buckets[0]) != 1 {
+		t.Errorf("Expected bucket names only, got %v", buckets)
+	}
+
+	// age and kind_value are fields of snapshots in every format, not only tables
+	var computed []map[string]interface{}
+	executeJSON(t, &computed, "--db-path", dbPath, "snapshots", "list", "--sort-by", "age", "--fields", "key,age,kind_value")
+	if len(computed) != 4 || computed[0]["key"] != "view-1" || computed[3]["key"] != "layer-1" {
+		t.Fatalf("Expected snapshots youngest first, got %v", computed)
+	}
+	if age, ok := computed[3]["age"].(float64); !ok || age < 10*86400 || computed[3]["kind_value"] != float64(3) {
+		t.Errorf("Expected the age in seconds and the numeric kind of layer-1, got %v", computed[3])
+	}
+	out, err = executeCommand(t, "--db-path", dbPath, "snapshots", "list", "--fields", "key,kind_value", "--filter", "age<1h")
+	if err != nil {
+		t.Fatalf("snapshots list --fields kind_value failed: %v", err)
+	}
+	if lines := strings.Split(out, "\n"); !strings.HasSuffix(lines[0], "KIND_VALUE") || !strings.HasPrefix(lines[1], "view-1 ") || !strings.HasSuffix(lines[1], " 1") {
+		t.Errorf("Expected the numeric kind of view-1, got:\n%s", out)
+	}
+
+	// Filters apply before sorting, in every format
+	out, err = executeCommand(t, "--db-path", dbPath, "-o", "name", "snapshots", "list",
+		"--filter", `kind==committed && size>10GiB && created_at<now-7d || labels["containerd.io/gc.root"] exists`, "--sort-by", "key")
+	if err != nil {
+		t.Fatalf("snapshots list --filter failed: %v", err)
+	}
+	if out != "layer-1\nlayer-2\n" {
+		t.Errorf("Expected layer-1 and layer-2, got:\n%s", out)
+	}
+	var storage []map[string]interface{}
+	executeJSON(t, &storage, "--db-path", dbPath, "devbox", "list", "--filter", `status!=removed && lv_name=~"^lv-"`)
+	if len(storage) != 1 || storage[0]["content_id"] != "content-1" {
+		t.Errorf("Expected the active storage entry, got %v", storage)
+	}
+	executeJSON(t, &snapshots, "--db-path", dbPath, "snapshots", "search", "--content-id", "content-1", "--filter", "kind==view")
+	if len(snapshots) != 0 {
+		t.Errorf("Expected no matches, got %v", snapshots)
+	}
+
+	// Every command listing records accepts --filter
+	for _, path := range [][]string{
+		{"buckets"}, {"snapshots", "list"}, {"snapshots", "search"}, {"snapshots", "du"}, {"snapshots", "orphans"},
+		{"snapshots", "users"}, {"devbox", "list"}, {"devbox", "lvm-map"}, {"devbox", "verify"}, {"devbox", "lvm-reconcile"},
+		{"devbox", "devices"}, {"devbox", "df"}, {"devbox", "users"}, {"devmapper", "list"}, {"devmapper", "snapshots"}, {"discover"},
+	} {
+		if c, _, err := rootCmd.Find(path); err != nil || c.Flags().Lookup("filter") == nil {
+			t.Errorf("Expected %v to have a --filter flag", path)
+		}
+	}
+	out, err = executeCommand(t, "--db-path", dbPath, "-o", "csv", "devbox", "lvm-map", "--filter", `path=~"content-2$"`)
+	if err != nil {
+		t.Fatalf("devbox lvm-map --filter failed: %v", err)
+	}
+	if out != "lv_name,path\nlv-content-2,/var/lib/devbox/content-2\n" {
+		t.Errorf("Expected the mapping of content-2 only, got:\n%s", out)
+	}
+
+	_, err = executeCommand(t, "--db-path", dbPath, "snapshots", "list", "--filter", "kind==active & size>1")
+	if err == nil || !strings.Contains(err.Error(), "position 14") {
+		t.Errorf("Expected a parse error at position 14, got %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"snapshots", "list", "--sort-by", "nope"},
+		{"snapshots", "list", "--sort-by", "labels"},
+		{"snapshots", "list", "--fields", "key,nope"},
+		{"snapshots", "list", "--filter", "age>10GiB"},
+		{"devbox", "list", "--fields", "label:a"},
+		{"devbox", "list", "--filter", "size>1GiB"},
+		{"buckets", "--filter", "key_count>many"},
+	} {
+		if _, err := executeCommand(t, append([]string{"--db-path", dbPath}, args...)...); err == nil {
+			t.Errorf("Expected %v to fail", args)
+		}
+	}
 }
\ No newline at end of file
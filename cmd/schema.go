@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/containerd/meta-viewer/internal/formatters"
+	"github.com/spf13/cobra"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema [kind]",
+	Short: "Print the JSON Schema of the JSON output",
+	Long: `Print the JSON Schema (draft 2020-12) of the envelope wrapping -o json output
+of the given kind, e.g. SnapshotList for "snapshots list". Without a kind,
+list the kinds of JSON output.
+
+Every JSON output is an object with apiVersion, kind, the source database
+path, the time it was read, the ID of its last transaction, and the items
+of lists or the item of single objects.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSchema,
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return writeOutput(func(w io.Writer) error {
+			for _, kind := range formatters.OutputKinds() {
+				if _, err := fmt.Fprintln(w, kind); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	schema, err := formatters.JSONSchema(args[0])
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON Schema: %w", err)
+	}
+	return writeOutput(func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, string(data))
+		return err
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
\ No newline at end of file
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSchemaCmd(t *testing.T) {
+	if schemaCmd.Use != "schema [kind]" {
+		t.Errorf("Expected schema command use = 'schema [kind]', got %s", schemaCmd.Use)
+	}
+	if err := schemaCmd.Args(schemaCmd, []string{"a", "b"}); err == nil {
+		t.Error("Expected schema command to take at most one kind")
+	}
+}
+
+func TestSchemaCmd_Run(t *testing.T) {
+	out, err := executeCommand(t, "schema")
+	if err != nil {
+		t.Fatalf("schema failed: %v", err)
+	}
+	for _, kind := range []string{"BucketList\n", "Snapshot\n", "SnapshotList\n", "GCPreview\n"} {
+		if !strings.Contains(out, kind) {
+			t.Errorf("Expected kind %q to be listed\n%s", kind, out)
+		}
+	}
+
+	out, err = executeCommand(t, "schema", "DevboxStorageList")
+	if err != nil {
+		t.Fatalf("schema failed: %v", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &schema); err != nil {
+		t.Fatalf("Failed to decode schema: %v\n%s", err, out)
+	}
+	if schema["title"] != "DevboxStorageList" || !strings.Contains(out, `"lv_name"`) {
+		t.Errorf("Expected the DevboxStorageList schema\n%s", out)
+	}
+
+	if _, err := executeCommand(t, "schema", "Nothing"); err == nil {
+		t.Error("Expected an error for an unknown kind")
+	}
+}
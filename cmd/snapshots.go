@@ -1,16 +1,38 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
 
 	"github.com/containerd/meta-viewer/internal/database"
-	"github.com/containerd/meta-viewer/internal/formatters"
+	"github.com/containerd/meta-viewer/internal/du"
+	"github.com/containerd/meta-viewer/internal/graph"
+	"github.com/containerd/meta-viewer/internal/mounts"
+	"github.com/containerd/meta-viewer/internal/procs"
 	"github.com/spf13/cobra"
 )
 
 var (
 	searchContentID string
 	searchPath      string
+
+	snapshotRoot        string
+	snapshotConcurrency int
+
+	mountsOverlayOptions []string
+	mountsTarget         string
+	mountsCommand        bool
+	mountsVG             string
+
+	usersProcRoot string
+
+	graphFormat    string
+	graphRootKey   string
+	graphNamespace string
+	graphStorage   bool
 )
 
 // snapshotsCmd represents the snapshots command
@@ -51,8 +73,90 @@ You can specify one or both search criteria to filter snapshots.`,
 	RunE: runSnapshotsSearch,
 }
 
+// snapshotsDuCmd represents the snapshots du command
+var snapshotsDuCmd = &cobra.Command{
+	Use:   "du [snapshot-key...]",
+	Short: "Compare recorded snapshot usage with actual disk usage",
+	Long: `Walk the directory of each snapshot (<root>/snapshots/<ID>/fs) and compare
+its actual disk usage with the size and inode count recorded in the database.
+Containerd only records usage when a snapshot is committed, so active
+snapshots usually report zero or stale values. Hardlinked files are counted
+once, like containerd's own usage calculation.
+
+The snapshotter root defaults to the directory holding the database. Give
+snapshot keys to measure only those snapshots.`,
+	RunE: runSnapshotsDu,
+}
+
+// snapshotsOrphansCmd represents the snapshots orphans command
+var snapshotsOrphansCmd = &cobra.Command{
+	Use:   "orphans",
+	Short: "Find snapshot directories and database entries without a counterpart",
+	Long: `Compare the snapshot IDs in the database with the numeric directories under
+<root>/snapshots. Directories no entry references are left behind by crashes
+and can be reclaimed; entries whose directory is gone point at lost data.
+Orphan directories are measured on disk, entries report their recorded usage.
+
+The snapshotter root defaults to the directory holding the database.`,
+	Args: cobra.NoArgs,
+	RunE: runSnapshotsOrphans,
+}
+
+// snapshotsMountsCmd represents the snapshots mounts command
+var snapshotsMountsCmd = &cobra.Command{
+	Use:   "mounts [snapshot-key]",
+	Short: "Show the mounts the snapshotter would return for a snapshot",
+	Long: `Compute offline the mounts containerd would return for an active or view
+snapshot. Overlay style snapshotters produce an overlay with the lowerdir
+chain built from the parent IDs, plus upperdir and workdir for active
+snapshots, or a bind mount when there is a single layer to show. Native
+snapshots bind their own directory, or their parent's for views. Devbox
+snapshots show the mount of their logical volume /dev/<vg>/<lv> at the
+storage path; the volume group comes from the entry's vg_name field or a
+vg/lv lv_name, otherwise from --vg.
+
+The snapshotter root defaults to the directory holding the database. Use
+--command to print ready-to-run mount command lines, e.g. to recover a
+container's rootfs by hand.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotsMounts,
+}
+
+// snapshotsUsersCmd represents the snapshots users command
+var snapshotsUsersCmd = &cobra.Command{
+	Use:   "users [snapshot-key]",
+	Short: "Find processes using a snapshot",
+	Long: `Scan /proc/*/fd, /proc/*/cwd and per-process mountinfo for open files,
+working directories and mounts under the directory of a snapshot
+(<root>/snapshots/<ID>, plus the devbox path when recorded), and report
+each process with its command line and container cgroup. Mounts whose
+options refer to the snapshot, such as an overlay using it as a lower
+layer, count as uses. Check this before removing anything.
+
+Mounts are reported once per mount namespace. Processes that cannot be
+inspected are skipped, so run as root to see every process.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotsUsers,
+}
+
+// snapshotsGraphCmd represents the snapshots graph command
+var snapshotsGraphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Export the snapshot lineage as Graphviz DOT, Mermaid or GraphML",
+	Long: `Export the snapshot parent/child lineage as a graph. Each snapshot is a node
+labelled with its key, kind, size and content ID, styled by kind, with an
+edge from its parent. Render DOT with Graphviz (dot -Tsvg), paste Mermaid
+into Markdown, or load GraphML into tools like Gephi or yEd.
+
+Use --root-key to graph one snapshot and its descendants, --namespace to
+keep only keys starting with "<namespace>/", and --storage to add the
+devbox storage entries linked to snapshots by content ID.`,
+	Args: cobra.NoArgs,
+	RunE: runSnapshotsGraph,
+}
+
 func runSnapshotsList(cmd *cobra.Command, args []string) error {
-	reader, err := database.NewMetaReader(dbPath)
+	reader, err := newReader()
 	if err != nil {
 		return fmt.Errorf("failed to create database reader: %w", err)
 	}
@@ -63,19 +167,13 @@ func runSnapshotsList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to list snapshots: %w", err)
 	}
 
-	if output == "json" {
-		formatter := formatters.NewJSONFormatter(verbose)
-		return formatter.FormatSnapshots(snapshots)
-	} else {
-		formatter := formatters.NewTableFormatter()
-		return formatter.FormatSnapshots(snapshots)
-	}
+	return formatOutput(reader, snapshots)
 }
 
 func runSnapshotsGet(cmd *cobra.Command, args []string) error {
 	snapshotKey := args[0]
 
-	reader, err := database.NewMetaReader(dbPath)
+	reader, err := newReader()
 	if err != nil {
 		return fmt.Errorf("failed to create database reader: %w", err)
 	}
@@ -86,17 +184,11 @@ func runSnapshotsGet(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get snapshot %s: %w", snapshotKey, err)
 	}
 
-	if output == "json" {
-		formatter := formatters.NewJSONFormatter(verbose)
-		return formatter.FormatSnapshot(snapshot)
-	} else {
-		formatter := formatters.NewTableFormatter()
-		return formatter.FormatSnapshot(snapshot)
-	}
+	return formatOutput(reader, snapshot)
 }
 
 func runSnapshotsSearch(cmd *cobra.Command, args []string) error {
-	reader, err := database.NewMetaReader(dbPath)
+	reader, err := newReader()
 	if err != nil {
 		return fmt.Errorf("failed to create database reader: %w", err)
 	}
@@ -107,13 +199,200 @@ func runSnapshotsSearch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to search snapshots: %w", err)
 	}
 
-	if output == "json" {
-		formatter := formatters.NewJSONFormatter(verbose)
-		return formatter.FormatSnapshots(snapshots)
+	return formatOutput(reader, snapshots)
+}
+
+func runSnapshotsDu(cmd *cobra.Command, args []string) error {
+	reader, err := newReader()
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	var snapshots []database.SnapshotInfo
+	if len(args) == 0 {
+		snapshots, err = reader.ListSnapshots()
+		if err != nil {
+			return fmt.Errorf("failed to list snapshots: %w", err)
+		}
 	} else {
-		formatter := formatters.NewTableFormatter()
-		return formatter.FormatSnapshots(snapshots)
+		for _, key := range args {
+			snapshot, err := reader.GetSnapshot(key)
+			if err != nil {
+				return fmt.Errorf("failed to get snapshot %s: %w", key, err)
+			}
+			snapshots = append(snapshots, *snapshot)
+		}
+	}
+
+	usages := du.Measure(context.Background(), snapshotterRoot(), snapshots, snapshotConcurrency)
+
+	return formatOutput(reader, usages)
+}
+
+func runSnapshotsOrphans(cmd *cobra.Command, args []string) error {
+	reader, err := newReader()
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	snapshots, err := reader.ListSnapshots()
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	orphans, err := du.FindOrphans(context.Background(), snapshotterRoot(), snapshots, snapshotConcurrency)
+	if err != nil {
+		return fmt.Errorf("failed to find orphan snapshots: %w", err)
 	}
+
+	return formatOutput(reader, orphans)
+}
+
+func runSnapshotsMounts(cmd *cobra.Command, args []string) error {
+	snapshotKey := args[0]
+
+	reader, err := newReader()
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	snapshots, err := reader.ListSnapshots()
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	parentIDs, err := mounts.ParentIDs(snapshotKey, snapshots)
+	if err != nil {
+		return fmt.Errorf("failed to resolve parents: %w", err)
+	}
+
+	var snapshot database.SnapshotInfo
+	for _, info := range snapshots {
+		if info.Key == snapshotKey {
+			snapshot = info
+			break
+		}
+	}
+
+	result := &mounts.SnapshotMounts{
+		Key:         snapshot.Key,
+		ID:          snapshot.ID,
+		Kind:        database.SnapshotKindString(snapshot.Kind),
+		Snapshotter: reader.Decoder().Name(),
+		ParentIDs:   parentIDs,
+	}
+
+	_, devbox := reader.Decoder().(database.StorageDecoder)
+	switch {
+	case devbox && snapshot.ContentID != "":
+		var storage *database.DevboxStorageInfo
+		storage, err = reader.GetDevboxStorage(snapshot.ContentID)
+		if err != nil {
+			return fmt.Errorf("failed to get devbox storage %s: %w", snapshot.ContentID, err)
+		}
+		result.Mounts, err = mounts.DevboxMounts(snapshot, *storage, mountsVG)
+	case devbox || result.Snapshotter == "overlayfs":
+		// Devbox snapshots without a content ID are plain overlay layers
+		result.Mounts, err = mounts.OverlayMounts(snapshotterRoot(), snapshot, parentIDs, mountsOverlayOptions)
+	case result.Snapshotter == "native":
+		result.Mounts, err = mounts.NativeMounts(snapshotterRoot(), snapshot, parentIDs)
+	default:
+		return fmt.Errorf("computing mounts is not supported for %s snapshots", result.Snapshotter)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to compute mounts: %w", err)
+	}
+
+	if mountsCommand {
+		return writeOutput(func(w io.Writer) error {
+			for _, spec := range result.Mounts {
+				if _, err := fmt.Fprintln(w, spec.Command(mountsTarget)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	return formatOutput(reader, result)
+}
+
+func runSnapshotsUsers(cmd *cobra.Command, args []string) error {
+	snapshotKey := args[0]
+
+	reader, err := newReader()
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	snapshot, err := reader.GetSnapshot(snapshotKey)
+	if err != nil {
+		return fmt.Errorf("failed to get snapshot %s: %w", snapshotKey, err)
+	}
+
+	paths := []string{filepath.Join(snapshotterRoot(), "snapshots", strconv.FormatUint(snapshot.ID, 10))}
+	if snapshot.Path != "" {
+		paths = append(paths, snapshot.Path)
+	}
+
+	users, err := procs.FindUsers(usersProcRoot, paths)
+	if err != nil {
+		return fmt.Errorf("failed to find processes: %w", err)
+	}
+
+	return formatOutput(reader, users)
+}
+
+func runSnapshotsGraph(cmd *cobra.Command, args []string) error {
+	if graphFormat != "dot" && graphFormat != "mermaid" && graphFormat != "graphml" {
+		return fmt.Errorf("invalid graph format '%s'. Use 'dot', 'mermaid' or 'graphml'", graphFormat)
+	}
+
+	reader, err := newReader()
+	if err != nil {
+		return fmt.Errorf("failed to create database reader: %w", err)
+	}
+	defer reader.Close()
+
+	infos, err := reader.ListSnapshots()
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var storage []database.DevboxStorageInfo
+	if graphStorage {
+		if _, ok := reader.Decoder().(database.StorageDecoder); !ok {
+			return fmt.Errorf("--storage needs a devbox database, %s has no storage bucket", reader.Decoder().Name())
+		}
+		storage, err = reader.ListDevboxStorage()
+		if err != nil {
+			return fmt.Errorf("failed to list devbox storage: %w", err)
+		}
+	}
+
+	g, err := graph.Build(infos, storage, graph.Options{
+		RootKey:   graphRootKey,
+		Namespace: graphNamespace,
+		Storage:   graphStorage,
+	})
+	if err != nil {
+		return err
+	}
+	return writeOutput(func(w io.Writer) error {
+		return graph.Write(w, g, graphFormat, tableOptions())
+	})
+}
+
+// snapshotterRoot returns the --root flag, defaulting to the directory holding the database
+func snapshotterRoot() string {
+	if snapshotRoot != "" {
+		return snapshotRoot
+	}
+	return filepath.Dir(dbPath)
 }
 
 func init() {
@@ -121,8 +400,40 @@ func init() {
 	snapshotsCmd.AddCommand(snapshotsListCmd)
 	snapshotsCmd.AddCommand(snapshotsGetCmd)
 	snapshotsCmd.AddCommand(snapshotsSearchCmd)
+	snapshotsCmd.AddCommand(snapshotsDuCmd)
+	snapshotsCmd.AddCommand(snapshotsOrphansCmd)
+	snapshotsCmd.AddCommand(snapshotsMountsCmd)
+	snapshotsCmd.AddCommand(snapshotsUsersCmd)
+	snapshotsCmd.AddCommand(snapshotsGraphCmd)
 
 	// Add flags to search command
 	snapshotsSearchCmd.Flags().StringVar(&searchContentID, "content-id", "", "Search by content ID")
 	snapshotsSearchCmd.Flags().StringVar(&searchPath, "path", "", "Search by mount path")
+
+	// Add sorting and field selection to list commands
+	addListFlags(snapshotsListCmd, snapshotsSearchCmd)
+	addFilterFlag(snapshotsDuCmd, snapshotsOrphansCmd, snapshotsUsersCmd)
+
+	// Add flags to commands walking snapshot directories
+	for _, c := range []*cobra.Command{snapshotsDuCmd, snapshotsOrphansCmd} {
+		c.Flags().StringVar(&snapshotRoot, "root", "", "Snapshotter root directory (default: the directory holding the database)")
+		c.Flags().IntVar(&snapshotConcurrency, "concurrency", du.DefaultConcurrency, "Number of snapshots walked in parallel")
+	}
+
+	// Add flags to mounts command
+	snapshotsMountsCmd.Flags().StringVar(&snapshotRoot, "root", "", "Snapshotter root directory (default: the directory holding the database)")
+	snapshotsMountsCmd.Flags().StringSliceVar(&mountsOverlayOptions, "overlay-options", mounts.DefaultOverlayOptions, "Options the snapshotter adds to every overlay and bind mount")
+	snapshotsMountsCmd.Flags().BoolVar(&mountsCommand, "command", false, "Print mount command lines instead of the mount specification")
+	snapshotsMountsCmd.Flags().StringVar(&mountsTarget, "target", "/mnt/rootfs", "Mount point used in --command output for snapshot mounts")
+	snapshotsMountsCmd.Flags().StringVar(&mountsVG, "vg", "", "Volume group of devbox logical volumes whose storage entry does not record one, used to build /dev/<vg>/<lv> sources")
+
+	// Add flags to users command
+	snapshotsUsersCmd.Flags().StringVar(&snapshotRoot, "root", "", "Snapshotter root directory (default: the directory holding the database)")
+	snapshotsUsersCmd.Flags().StringVar(&usersProcRoot, "proc-root", "", "Process table to scan (default: /proc under --host-root)")
+
+	// Add flags to graph command
+	snapshotsGraphCmd.Flags().StringVar(&graphFormat, "format", "dot", "Graph format (dot|mermaid|graphml)")
+	snapshotsGraphCmd.Flags().StringVar(&graphRootKey, "root-key", "", "Only graph this snapshot and its descendants")
+	snapshotsGraphCmd.Flags().StringVar(&graphNamespace, "namespace", "", "Only graph snapshots whose key starts with <namespace>/")
+	snapshotsGraphCmd.Flags().BoolVar(&graphStorage, "storage", false, "Add devbox storage entries linked by content ID")
 }
\ No newline at end of file
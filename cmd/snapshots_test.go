@@ -1,9 +1,18 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/du"
+	"github.com/containerd/meta-viewer/internal/mounts"
 	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
 )
 
 func TestSnapshotsCmd(t *testing.T) {
@@ -53,6 +62,11 @@ func TestSnapshotsSubcommands(t *testing.T) {
 		"list",
 		"get",
 		"search",
+		"du",
+		"orphans",
+		"mounts",
+		"users",
+		"graph",
 	}
 
 	for _, expected := range expectedSubcommands {
@@ -209,4 +223,372 @@ func TestSnapshotsSearchCmd(t *testing.T) {
 	if searchCmd.RunE == nil {
 		t.Error("Expected snapshots search command to have RunE function")
 	}
+}
+
+func TestSnapshotsDuCmd(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"snapshots", "du"})
+	if err != nil || cmd.Name() != "du" {
+		t.Fatalf("Expected snapshots du command to exist, got error: %v", err)
+	}
+
+	if cmd.RunE == nil {
+		t.Error("Expected snapshots du command to have RunE function")
+	}
+
+	if flag := cmd.Flags().Lookup("root"); flag == nil {
+		t.Error("Expected snapshots du command to have a root flag")
+	}
+
+	flag := cmd.Flags().Lookup("concurrency")
+	if flag == nil {
+		t.Fatal("Expected snapshots du command to have a concurrency flag")
+	}
+	if flag.DefValue != "4" {
+		t.Errorf("Expected concurrency flag default to be 4, got %s", flag.DefValue)
+	}
+}
+
+// usagesByKey indexes snapshots du results by snapshot key
+func usagesByKey(items []du.SnapshotUsage) map[string]du.SnapshotUsage {
+	usages := make(map[string]du.SnapshotUsage, len(items))
+	for _, usage := range items {
+		usages[usage.Key] = usage
+	}
+	return usages
+}
+
+func TestSnapshotsDuCmd_Run(t *testing.T) {
+	dbPath := setupFixtureDB(t, "overlayfs")
+	root := filepath.Dir(dbPath)
+
+	// layer-1 holds a file and a hardlink to it, container-1 wrote more than
+	// its recorded size, layer-2 and view-1 have no directory
+	data := writeFixtureFile(t, filepath.Join(root, "snapshots/1/fs/data"), strings.Repeat("x", 8192))
+	if err := os.Link(data, filepath.Join(root, "snapshots/1/fs/data-link")); err != nil {
+		t.Fatalf("Failed to create hardlink: %v", err)
+	}
+	writeFixtureFile(t, filepath.Join(root, "snapshots/3/fs/app.log"), strings.Repeat("x", 65536))
+
+	var items []du.SnapshotUsage
+	executeJSON(t, &items, "--db-path", dbPath, "snapshots", "du")
+	usages := usagesByKey(items)
+	if len(usages) != len(fixtureSnapshots) {
+		t.Fatalf("Expected every snapshot to be measured, got %+v", items)
+	}
+
+	layer := usages["layer-1"]
+	if layer.State != du.StateOK || layer.Dir != filepath.Join(root, "snapshots/1/fs") {
+		t.Errorf("Expected layer-1 to be measured in its fs directory, got %+v", layer)
+	}
+	if layer.ActualInodes != 2 {
+		t.Errorf("Expected the hardlinked file to be counted once next to its directory, got %d inodes", layer.ActualInodes)
+	}
+	if container := usages["container-1"]; container.State != du.StateOK || container.SizeDrift != container.ActualSize-4096 || container.SizeDrift <= 0 {
+		t.Errorf("Expected container-1 to have grown past its recorded size, got %+v", container)
+	}
+	if missing := usages["layer-2"]; missing.State != du.StateMissing || missing.ActualSize != 0 || missing.RecordedSize != 20<<30 {
+		t.Errorf("Expected layer-2 to be missing with its recorded size, got %+v", missing)
+	}
+
+	// Arguments select snapshots, --root moves the snapshot directories
+	other := t.TempDir()
+	items = nil
+	executeJSON(t, &items, "--db-path", dbPath, "snapshots", "du", "layer-1", "--root", other)
+	if len(items) != 1 || items[0].Dir != filepath.Join(other, "snapshots/1/fs") || items[0].State != du.StateMissing {
+		t.Errorf("Expected only layer-1 to be looked for under --root, got %+v", items)
+	}
+
+	if _, err := executeCommand(t, "--db-path", dbPath, "snapshots", "du", "layer-9"); err == nil {
+		t.Error("Expected error for an unknown snapshot")
+	}
+}
+
+func TestSnapshotsOrphansCmd(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"snapshots", "orphans"})
+	if err != nil || cmd.Name() != "orphans" {
+		t.Fatalf("Expected snapshots orphans command to exist, got error: %v", err)
+	}
+
+	if cmd.RunE == nil {
+		t.Error("Expected snapshots orphans command to have RunE function")
+	}
+
+	for _, name := range []string{"root", "concurrency"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("Expected snapshots orphans command to have a %s flag", name)
+		}
+	}
+
+	if err := cmd.Args(cmd, []string{"key"}); err == nil {
+		t.Error("Expected snapshots orphans command to reject arguments")
+	}
+}
+
+func TestSnapshotterRoot(t *testing.T) {
+	oldRoot, oldDBPath := snapshotRoot, dbPath
+	defer func() { snapshotRoot, dbPath = oldRoot, oldDBPath }()
+
+	snapshotRoot = ""
+	dbPath = "/var/lib/containerd/io.containerd.snapshotter.v1.overlayfs/metadata.db"
+	if root := snapshotterRoot(); root != "/var/lib/containerd/io.containerd.snapshotter.v1.overlayfs" {
+		t.Errorf("Expected root to default to the database directory, got %s", root)
+	}
+
+	snapshotRoot = "/data/snapshotter"
+	if root := snapshotterRoot(); root != "/data/snapshotter" {
+		t.Errorf("Expected --root to take precedence, got %s", root)
+	}
+}
+
+func TestSnapshotsOrphansCmd_Run(t *testing.T) {
+	dbPath := setupFixtureDB(t, "overlayfs")
+	root := filepath.Dir(dbPath)
+
+	// snapshots/9 has no entry, container-1 and view-1 have no directory.
+	// new-123 is a snapshot being prepared and the file is not a snapshot.
+	for _, dir := range []string{"snapshots/1/fs", "snapshots/2/fs", "snapshots/new-123/fs"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	writeFixtureFile(t, filepath.Join(root, "snapshots/9/fs/data"), strings.Repeat("x", 8192))
+	writeFixtureFile(t, filepath.Join(root, "snapshots/10"), "")
+
+	var orphans []du.Orphan
+	executeJSON(t, &orphans, "--db-path", dbPath, "snapshots", "orphans")
+	if len(orphans) != 3 {
+		t.Fatalf("Expected the leftover directory and two entries without directories, got %+v", orphans)
+	}
+	if orphan := orphans[0]; orphan.Type != du.OrphanDirectory || orphan.ID != 9 || orphan.Size == 0 || orphan.Dir != filepath.Join(root, "snapshots/9") {
+		t.Errorf("Expected snapshots/9 to be a measured orphan directory listed first, got %+v", orphan)
+	}
+	for i, key := range []string{"container-1", "view-1"} {
+		orphan := orphans[i+1]
+		if orphan.Type != du.OrphanEntry || orphan.Key != key || orphan.Error != "" {
+			t.Errorf("Expected %s to have no directory, got %+v", key, orphan)
+		}
+	}
+	if orphans[1].Size != 4096 {
+		t.Errorf("Expected container-1 to report its recorded size, got %d", orphans[1].Size)
+	}
+
+	// Without a snapshots directory nothing can be compared
+	if _, err := executeCommand(t, "--db-path", dbPath, "snapshots", "orphans", "--root", t.TempDir()); err == nil {
+		t.Error("Expected error for a root without a snapshots directory")
+	}
+}
+
+func TestSnapshotsMountsCmd(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"snapshots", "mounts"})
+	if err != nil || cmd.Name() != "mounts" {
+		t.Fatalf("Expected snapshots mounts command to exist, got error: %v", err)
+	}
+
+	if cmd.RunE == nil {
+		t.Error("Expected snapshots mounts command to have RunE function")
+	}
+
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("Expected snapshots mounts command to require a snapshot key")
+	}
+
+	for _, name := range []string{"root", "overlay-options", "command", "target", "vg"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("Expected snapshots mounts command to have a %s flag", name)
+		}
+	}
+
+	if flag := cmd.Flags().Lookup("overlay-options"); flag != nil && flag.DefValue != "[index=off]" {
+		t.Errorf("Expected overlay-options default to be [index=off], got %s", flag.DefValue)
+	}
+}
+
+// executeMounts runs snapshots mounts with JSON output and returns the mounts
+func executeMounts(t *testing.T, args ...string) []mounts.Spec {
+	t.Helper()
+	var result mounts.SnapshotMounts
+	executeJSON(t, &result, append([]string{"snapshots", "mounts"}, args...)...)
+	return result.Mounts
+}
+
+func TestSnapshotsMountsCmd_Overlay(t *testing.T) {
+	dbPath := setupFixtureDB(t, "overlayfs")
+	root := filepath.Dir(dbPath)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []mounts.Spec
+	}{
+		{"active snapshot stacks its parents", []string{"container-1"}, []mounts.Spec{{
+			Type:   "overlay",
+			Source: "overlay",
+			Options: []string{"index=off", "workdir=" + root + "/snapshots/3/work", "upperdir=" + root + "/snapshots/3/fs",
+				"lowerdir=" + root + "/snapshots/2/fs:" + root + "/snapshots/1/fs"},
+		}}},
+		{"view of a single layer binds it read-only", []string{"view-1", "--overlay-options", ""}, []mounts.Spec{{
+			Type:    "bind",
+			Source:  root + "/snapshots/1/fs",
+			Options: []string{"ro", "rbind"},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if specs := executeMounts(t, append([]string{"--db-path", dbPath}, tt.args...)...); !reflect.DeepEqual(specs, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, specs)
+			}
+		})
+	}
+
+	stdout, err := executeCommand(t, "--db-path", dbPath, "snapshots", "mounts", "container-1", "--command", "--target", "/run/rootfs")
+	if err != nil {
+		t.Fatalf("snapshots mounts failed: %v", err)
+	}
+	expected := "mount -t overlay -o index=off,workdir=" + root + "/snapshots/3/work,upperdir=" + root + "/snapshots/3/fs,lowerdir=" +
+		root + "/snapshots/2/fs:" + root + "/snapshots/1/fs overlay /run/rootfs\n"
+	if stdout != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout)
+	}
+
+	// containerd never mounts committed snapshots
+	if _, err := executeCommand(t, "--db-path", dbPath, "snapshots", "mounts", "layer-2"); err == nil {
+		t.Error("Expected error for a committed snapshot")
+	}
+}
+
+func TestSnapshotsMountsCmd_Native(t *testing.T) {
+	dbPath := setupFixtureDB(t, "native")
+	root := filepath.Dir(dbPath)
+
+	expected := []mounts.Spec{{Type: "bind", Source: root + "/snapshots/3", Options: []string{"rw", "rbind"}}}
+	if specs := executeMounts(t, "--db-path", dbPath, "container-1"); !reflect.DeepEqual(specs, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, specs)
+	}
+
+	// view-1 binds its parent layer-1 read-only
+	expected = []mounts.Spec{{Type: "bind", Source: root + "/snapshots/1", Options: []string{"ro", "rbind"}}}
+	if specs := executeMounts(t, "--db-path", dbPath, "view-1"); !reflect.DeepEqual(specs, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, specs)
+	}
+}
+
+func TestSnapshotsMountsCmd_Devbox(t *testing.T) {
+	dbPath := setupFixtureDB(t, "devbox")
+	root := filepath.Dir(dbPath)
+
+	// container-2 is backed by an entry without an LV, container-3 by no entry
+	updateFixtureDB(t, dbPath, func(v1 *bolt.Bucket) error {
+		for _, s := range []fixtureSnapshot{
+			{key: "container-2", id: 5, kind: snapshots.KindActive, parent: "layer-2", contentID: "content-5", path: "/var/lib/devbox/content-5"},
+			{key: "container-3", id: 6, kind: snapshots.KindActive, parent: "layer-2", contentID: "content-6", path: "/var/lib/devbox/content-6"},
+		} {
+			if err := writeFixtureSnapshot(v1.Bucket([]byte("snapshots")), s, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	addFixtureStorage(t, dbPath, database.DevboxStorageInfo{ContentID: "content-5", Path: "/var/lib/devbox/content-5", Status: "active"})
+
+	// container-1 is backed by the LV of content-1
+	expected := []mounts.Spec{{Source: "/dev/devbox-vg/lv-content-1", Target: "/var/lib/devbox/content-1"}}
+	if specs := executeMounts(t, "--db-path", dbPath, "container-1", "--vg", "devbox-vg"); !reflect.DeepEqual(specs, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, specs)
+	}
+
+	// view-1 has no content ID and is a plain overlay layer
+	expected = []mounts.Spec{{Type: "bind", Source: root + "/snapshots/1/fs", Options: []string{"index=off", "ro", "rbind"}}}
+	if specs := executeMounts(t, "--db-path", dbPath, "view-1"); !reflect.DeepEqual(specs, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, specs)
+	}
+
+	// Without --vg the volume group of lv-content-1 is unknown
+	if _, err := executeCommand(t, "--db-path", dbPath, "snapshots", "mounts", "container-1", "--command"); err == nil || !strings.Contains(err.Error(), "volume group") {
+		t.Errorf("Expected an unknown volume group to fail, got %v", err)
+	}
+
+	for key, message := range map[string]string{
+		"container-2": "has no logical volume",
+		"container-3": "failed to get devbox storage content-6",
+	} {
+		_, err := executeCommand(t, "--db-path", dbPath, "snapshots", "mounts", key, "--vg", "devbox-vg")
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("Expected %s to fail with %q, got %v", key, message, err)
+		}
+	}
+}
+
+func TestSnapshotsMountsCmd_Unsupported(t *testing.T) {
+	dbPath := setupFixtureDB(t, "btrfs")
+
+	_, err := executeCommand(t, "--db-path", dbPath, "snapshots", "mounts", "container-1")
+	if err == nil || !strings.Contains(err.Error(), "not supported for btrfs") {
+		t.Errorf("Expected btrfs snapshots to be rejected, got %v", err)
+	}
+}
+
+func TestSnapshotsUsersCmd(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"snapshots", "users"})
+	if err != nil || cmd.Name() != "users" {
+		t.Fatalf("Expected snapshots users command to exist, got error: %v", err)
+	}
+
+	if cmd.RunE == nil {
+		t.Error("Expected snapshots users command to have RunE function")
+	}
+
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("Expected snapshots users command to require a snapshot key")
+	}
+
+	for _, name := range []string{"root", "proc-root"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("Expected snapshots users command to have a %s flag", name)
+		}
+	}
+}
+
+func TestSnapshotsGraphCmd(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"snapshots", "graph"})
+	if err != nil || cmd.Name() != "graph" {
+		t.Fatalf("Expected snapshots graph command to exist, got error: %v", err)
+	}
+
+	for _, name := range []string{"format", "root-key", "namespace", "storage"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("Expected snapshots graph command to have a %s flag", name)
+		}
+	}
+}
+
+func TestSnapshotsGraphCmd_Run(t *testing.T) {
+	dbPath := setupFixtureDB(t, "devbox")
+
+	out, err := executeCommand(t, "--db-path", dbPath, "snapshots", "graph", "--storage")
+	if err != nil {
+		t.Fatalf("snapshots graph failed: %v", err)
+	}
+	for _, s := range []string{"digraph snapshots {", "s1 -> s2;", "s2 -> s3;", "s1 -> s4;", "s3 -> c1 [style=dashed", `label="content-2\nlv-content-2\nremoved"`} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected DOT output to contain %q\n%s", s, out)
+		}
+	}
+
+	out, err = executeCommand(t, "--db-path", dbPath, "snapshots", "graph", "--format", "mermaid", "--root-key", "layer-2")
+	if err != nil {
+		t.Fatalf("snapshots graph failed: %v", err)
+	}
+	if !strings.Contains(out, "s2 --> s3") || strings.Contains(out, "s1[") || strings.Contains(out, "s4[") {
+		t.Errorf("Expected only layer-2 and its descendants\n%s", out)
+	}
+
+	if _, err := executeCommand(t, "--db-path", dbPath, "snapshots", "graph", "--root-key", "missing"); err == nil {
+		t.Error("Expected an error for an unknown root key")
+	}
+	if _, err := executeCommand(t, "--db-path", setupFixtureDB(t, "overlayfs"), "snapshots", "graph", "--storage"); err == nil {
+		t.Error("Expected --storage to fail without a storage bucket")
+	}
+	if _, err := executeCommand(t, "--db-path", dbPath, "snapshots", "graph", "--format", "svg"); err == nil {
+		t.Error("Expected an error for an unsupported graph format")
+	}
 }
\ No newline at end of file
@@ -0,0 +1,85 @@
+package blockdev
+
+import (
+	"sort"
+
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+// DevboxDevice is a devbox storage entry resolved to its block device
+type DevboxDevice struct {
+	ContentID  string    `json:"content_id"`
+	LvName     string    `json:"lv_name"`
+	Status     string    `json:"status"`
+	Found      bool      `json:"found"`
+	Ambiguous  bool      `json:"ambiguous,omitempty"`
+	Candidates []string  `json:"candidates,omitempty"` // Device-mapper names of same-named LVs when ambiguous
+	Device     *DMDevice `json:"device,omitempty"`
+}
+
+// ResolveDevbox matches each devbox entry's LV to a device-mapper device by
+// volume group and LV name. The volume group comes from the entry (see
+// DevboxStorageInfo.Volume) or, when it does not record one, from vgs; with
+// no vgs every volume group is searched. An entry matching same-named LVs
+// in several volume groups is marked ambiguous and left unresolved.
+func ResolveDevbox(storage []database.DevboxStorageInfo, devices []DMDevice, vgs []string) []DevboxDevice {
+	byKey := make(map[string]*DMDevice, len(devices))
+	byName := make(map[string][]*DMDevice, len(devices))
+	for i := range devices {
+		device := &devices[i]
+		if device.LVName == "" {
+			continue
+		}
+		byKey[device.VGName+"/"+device.LVName] = device
+		byName[device.LVName] = append(byName[device.LVName], device)
+	}
+
+	results := make([]DevboxDevice, 0, len(storage))
+	for _, item := range storage {
+		result := DevboxDevice{
+			ContentID: item.ContentID,
+			LvName:    item.LvName,
+			Status:    item.Status,
+		}
+
+		var matches []*DMDevice
+		vg, name := item.Volume()
+		if vg != "" {
+			if device, ok := byKey[vg+"/"+name]; ok {
+				matches = append(matches, device)
+			}
+		} else if name != "" {
+			for _, device := range byName[name] {
+				if len(vgs) == 0 || containsString(vgs, device.VGName) {
+					matches = append(matches, device)
+				}
+			}
+		}
+
+		switch {
+		case len(matches) == 1:
+			result.Found = true
+			result.Device = matches[0]
+		case len(matches) > 1:
+			result.Ambiguous = true
+			for _, device := range matches {
+				result.Candidates = append(result.Candidates, device.Name)
+			}
+		}
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ContentID < results[j].ContentID
+	})
+	return results
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
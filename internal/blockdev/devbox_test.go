@@ -0,0 +1,80 @@
+package blockdev
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+func TestResolveDevbox(t *testing.T) {
+	devices, err := ListDMDevices(setupFakeTree(t, testDevices...))
+	if err != nil {
+		t.Fatalf("Failed to list devices: %v", err)
+	}
+
+	storage := []database.DevboxStorageInfo{
+		{ContentID: "c2", LvName: "lv-content-2", Status: "active"},
+		{ContentID: "c1", LvName: "lv-content-1", Status: "active"},
+		{ContentID: "c3", LvName: "lv-content-3", Status: "removed"},
+		{ContentID: "c4", Status: "active"},
+	}
+
+	results := ResolveDevbox(storage, devices, nil)
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+
+	if results[0].ContentID != "c1" || !results[0].Found || results[0].Device.Kernel != "dm-1" {
+		t.Errorf("Expected c1 to resolve to dm-1, got %+v", results[0])
+	}
+	if !results[1].Found || results[1].Device.MajorMinor != "253:2" {
+		t.Errorf("Expected c2 to resolve to 253:2, got %+v", results[1])
+	}
+	for _, result := range results[2:] {
+		if result.Found || result.Device != nil {
+			t.Errorf("Expected %s not to resolve, got %+v", result.ContentID, result.Device)
+		}
+	}
+}
+
+func TestResolveDevbox_SameNameInSeveralVGs(t *testing.T) {
+	devices, err := ListDMDevices(setupFakeTree(t,
+		fakeDevice{kernel: "dm-1", name: "vg--a-lv--1", dev: "253:1", sectors: "2048"},
+		fakeDevice{kernel: "dm-2", name: "vg--b-lv--1", dev: "253:2", sectors: "4096"},
+		fakeDevice{kernel: "dm-3", name: "vg--b-lv--2", dev: "253:3", sectors: "8192"},
+	))
+	if err != nil {
+		t.Fatalf("Failed to list devices: %v", err)
+	}
+
+	storage := []database.DevboxStorageInfo{
+		{ContentID: "c1", LvName: "lv-1", Extra: map[string]string{"vg_name": "vg-b"}},
+		{ContentID: "c2", LvName: "/dev/vg-b/lv-2"},
+		{ContentID: "c3", LvName: "vg-a/lv-2"},
+		{ContentID: "c4", LvName: "lv-1"},
+	}
+
+	results := ResolveDevbox(storage, devices, nil)
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+	if !results[0].Found || results[0].Device.Kernel != "dm-2" {
+		t.Errorf("Expected c1 to resolve to dm-2 from vg_name, got %+v", results[0])
+	}
+	if !results[1].Found || results[1].Device.Kernel != "dm-3" {
+		t.Errorf("Expected c2 to resolve to dm-3 from its path, got %+v", results[1])
+	}
+	if results[2].Found || results[2].Ambiguous {
+		t.Errorf("Expected c3 not to resolve to vg-b's lv-2, got %+v", results[2])
+	}
+	if c4 := results[3]; c4.Found || !c4.Ambiguous || !reflect.DeepEqual(c4.Candidates, []string{"vg--a-lv--1", "vg--b-lv--1"}) {
+		t.Errorf("Expected c4 to be ambiguous, got %+v", c4)
+	}
+
+	// --vg selects one of the same-named LVs
+	results = ResolveDevbox(storage[3:], devices, []string{"vg-a"})
+	if !results[0].Found || results[0].Device.Kernel != "dm-1" {
+		t.Errorf("Expected c4 to resolve to dm-1 in vg-a, got %+v", results[0])
+	}
+}
@@ -0,0 +1,120 @@
+package blockdev
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/meta-viewer/internal/utils"
+)
+
+// sectorSize is the unit of /sys/block/<dev>/size
+const sectorSize = 512
+
+// DMDevice is a device-mapper block device as seen in sysfs
+type DMDevice struct {
+	Name       string   `json:"name"`   // Device-mapper name, as under /dev/mapper
+	Kernel     string   `json:"kernel"` // Kernel name, e.g. dm-3
+	VGName     string   `json:"vg_name,omitempty"`
+	LVName     string   `json:"lv_name,omitempty"`
+	MajorMinor string   `json:"major_minor"`
+	Size       int64    `json:"size"`
+	UUID       string   `json:"uuid,omitempty"`
+	MapperPath string   `json:"mapper_path,omitempty"` // Empty when the /dev/mapper node is missing
+	Holders    []string `json:"holders,omitempty"`
+	Slaves     []string `json:"slaves,omitempty"`
+}
+
+// Roots locates sysfs and /dev. Paths are host paths resolved under utils.HostRoot.
+type Roots struct {
+	Sys string
+	Dev string
+}
+
+// DefaultRoots are the host's /sys and /dev
+var DefaultRoots = Roots{Sys: "/sys", Dev: "/dev"}
+
+// ListDMDevices reads every device-mapper device from <sys>/block/dm-*
+func ListDMDevices(roots Roots) ([]DMDevice, error) {
+	blockDir := utils.HostPath(filepath.Join(roots.Sys, "block"))
+	matches, err := filepath.Glob(filepath.Join(blockDir, "dm-*"))
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]DMDevice, 0, len(matches))
+	for _, dir := range matches {
+		name := readAttr(filepath.Join(dir, "dm", "name"))
+		if name == "" {
+			continue
+		}
+
+		device := DMDevice{
+			Name:       name,
+			Kernel:     filepath.Base(dir),
+			MajorMinor: readAttr(filepath.Join(dir, "dev")),
+			UUID:       readAttr(filepath.Join(dir, "dm", "uuid")),
+			Holders:    listDir(filepath.Join(dir, "holders")),
+			Slaves:     listDir(filepath.Join(dir, "slaves")),
+		}
+		if sectors, err := strconv.ParseInt(readAttr(filepath.Join(dir, "size")), 10, 64); err == nil {
+			device.Size = sectors * sectorSize
+		}
+		if vg, lv, ok := SplitDMName(name); ok {
+			device.VGName, device.LVName = vg, lv
+		}
+
+		mapperPath := filepath.Join(roots.Dev, "mapper", name)
+		if _, err := os.Stat(utils.HostPath(mapperPath)); err == nil {
+			device.MapperPath = mapperPath
+		}
+		devices = append(devices, device)
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Name < devices[j].Name
+	})
+	return devices, nil
+}
+
+// SplitDMName splits a device-mapper name into volume group and logical
+// volume, undoing the doubling of dashes done by LVM
+func SplitDMName(name string) (vg, lv string, ok bool) {
+	for i := 0; i < len(name); i++ {
+		if name[i] != '-' {
+			continue
+		}
+		if i+1 < len(name) && name[i+1] == '-' {
+			i++
+			continue
+		}
+		vg = strings.ReplaceAll(name[:i], "--", "-")
+		lv = strings.ReplaceAll(name[i+1:], "--", "-")
+		return vg, lv, vg != "" && lv != ""
+	}
+	return "", "", false
+}
+
+// readAttr reads a single-line sysfs attribute, returning "" when unreadable
+func readAttr(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// listDir returns the sorted entry names of a sysfs directory such as holders/
+func listDir(path string) []string {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
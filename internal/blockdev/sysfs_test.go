@@ -0,0 +1,155 @@
+package blockdev
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/meta-viewer/internal/utils"
+)
+
+// fakeDevice describes a dm device to create in a fake sysfs tree
+type fakeDevice struct {
+	kernel  string
+	name    string
+	dev     string
+	sectors string
+	holders []string
+	slaves  []string
+	node    bool
+}
+
+func writeAttr(t *testing.T, path, value string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(value+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+// setupFakeTree builds <root>/sys/block/dm-* and <root>/dev/mapper
+func setupFakeTree(t *testing.T, devices ...fakeDevice) Roots {
+	root := t.TempDir()
+	roots := Roots{Sys: filepath.Join(root, "sys"), Dev: filepath.Join(root, "dev")}
+
+	for _, d := range devices {
+		dir := filepath.Join(roots.Sys, "block", d.kernel)
+		writeAttr(t, filepath.Join(dir, "dm", "name"), d.name)
+		writeAttr(t, filepath.Join(dir, "dm", "uuid"), "LVM-"+d.kernel)
+		writeAttr(t, filepath.Join(dir, "dev"), d.dev)
+		writeAttr(t, filepath.Join(dir, "size"), d.sectors)
+		for _, holder := range d.holders {
+			writeAttr(t, filepath.Join(dir, "holders", holder), "")
+		}
+		for _, slave := range d.slaves {
+			writeAttr(t, filepath.Join(dir, "slaves", slave), "")
+		}
+		if d.node {
+			writeAttr(t, filepath.Join(roots.Dev, "mapper", d.name), "")
+		}
+	}
+
+	// Non device-mapper block devices are ignored
+	writeAttr(t, filepath.Join(roots.Sys, "block", "sda", "dev"), "8:0")
+	return roots
+}
+
+var testDevices = []fakeDevice{
+	{kernel: "dm-0", name: "devbox--vg-thinpool-tpool", dev: "253:0", sectors: "209715200", holders: []string{"dm-1", "dm-2"}, slaves: []string{"sdb"}, node: true},
+	{kernel: "dm-1", name: "devbox--vg-lv--content--1", dev: "253:1", sectors: "20971520", slaves: []string{"dm-0"}, node: true},
+	{kernel: "dm-2", name: "devbox--vg-lv--content--2", dev: "253:2", sectors: "20971520", slaves: []string{"dm-0"}},
+}
+
+func TestListDMDevices(t *testing.T) {
+	roots := setupFakeTree(t, testDevices...)
+
+	devices, err := ListDMDevices(roots)
+	if err != nil {
+		t.Fatalf("Failed to list devices: %v", err)
+	}
+	if len(devices) != 3 {
+		t.Fatalf("Expected 3 devices, got %d", len(devices))
+	}
+
+	device := devices[0]
+	if device.Name != "devbox--vg-lv--content--1" || device.Kernel != "dm-1" || device.MajorMinor != "253:1" {
+		t.Errorf("Unexpected device: %+v", device)
+	}
+	if device.VGName != "devbox-vg" || device.LVName != "lv-content-1" {
+		t.Errorf("Expected devbox-vg/lv-content-1, got %s/%s", device.VGName, device.LVName)
+	}
+	if device.Size != 20971520*512 {
+		t.Errorf("Expected size %d, got %d", 20971520*512, device.Size)
+	}
+	if device.MapperPath != filepath.Join(roots.Dev, "mapper", device.Name) {
+		t.Errorf("Unexpected mapper path %s", device.MapperPath)
+	}
+	if len(device.Slaves) != 1 || device.Slaves[0] != "dm-0" {
+		t.Errorf("Expected slave dm-0, got %v", device.Slaves)
+	}
+
+	if devices[1].MapperPath != "" {
+		t.Errorf("Expected missing /dev/mapper node for %s", devices[1].Name)
+	}
+
+	pool := devices[2]
+	if len(pool.Holders) != 2 || pool.Holders[0] != "dm-1" || pool.Slaves[0] != "sdb" {
+		t.Errorf("Unexpected pool holders/slaves: %v %v", pool.Holders, pool.Slaves)
+	}
+}
+
+func TestListDMDevices_HostRoot(t *testing.T) {
+	roots := setupFakeTree(t, testDevices...)
+	hostRoot := filepath.Dir(roots.Sys)
+
+	orig := utils.HostRoot
+	utils.HostRoot = hostRoot
+	defer func() { utils.HostRoot = orig }()
+
+	devices, err := ListDMDevices(DefaultRoots)
+	if err != nil {
+		t.Fatalf("Failed to list devices: %v", err)
+	}
+	if len(devices) != 3 {
+		t.Fatalf("Expected 3 devices, got %d", len(devices))
+	}
+	// Mapper paths are reported as host paths
+	if devices[0].MapperPath != "/dev/mapper/devbox--vg-lv--content--1" {
+		t.Errorf("Expected host mapper path, got %s", devices[0].MapperPath)
+	}
+}
+
+func TestListDMDevices_Empty(t *testing.T) {
+	devices, err := ListDMDevices(Roots{Sys: t.TempDir(), Dev: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(devices) != 0 {
+		t.Errorf("Expected no devices, got %d", len(devices))
+	}
+}
+
+func TestSplitDMName(t *testing.T) {
+	tests := []struct {
+		name string
+		vg   string
+		lv   string
+		ok   bool
+	}{
+		{"vg0-lv0", "vg0", "lv0", true},
+		{"devbox--vg-lv--content--1", "devbox-vg", "lv-content-1", true},
+		{"vg0-pool-tpool", "vg0", "pool-tpool", true},
+		{"nodash", "", "", false},
+		{"-lv", "", "lv", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vg, lv, ok := SplitDMName(tt.name)
+			if ok != tt.ok || (ok && (vg != tt.vg || lv != tt.lv)) {
+				t.Errorf("SplitDMName(%s) = %s, %s, %t; expected %s, %s, %t", tt.name, vg, lv, ok, tt.vg, tt.lv, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/containerd/containerd/snapshots"
+	bolt "go.etcd.io/bbolt"
+)
+
+const snapshotterPluginPrefix = "io.containerd.snapshotter.v1."
+
+func init() {
+	RegisterDecoder(NewProfileDecoder(DevboxProfile))
+	RegisterDecoder(devmapperDecoder{})
+	RegisterDecoder(btrfsDecoder{})
+	RegisterDecoder(nativeDecoder{})
+	RegisterDecoder(overlayDecoder{})
+}
+
+// inPluginDir reports whether dbPath lives in the root directory of the named snapshotter plugin
+func inPluginDir(dbPath, name string) bool {
+	return filepath.Base(filepath.Dir(dbPath)) == snapshotterPluginPrefix+name
+}
+
+// snapshotDir returns <root>/snapshots/<id>, the directory most snapshotters keep a snapshot in
+func snapshotDir(root string, info *SnapshotInfo) string {
+	return filepath.Join(root, "snapshots", strconv.FormatUint(info.ID, 10))
+}
+
+func setExtra(info *SnapshotInfo, key, value string) {
+	if info.Extra == nil {
+		info.Extra = make(map[string]string)
+	}
+	info.Extra[key] = value
+}
+
+// overlayDecoder decodes io.containerd.snapshotter.v1.overlayfs databases
+type overlayDecoder struct{}
+
+func (overlayDecoder) Name() string { return "overlayfs" }
+
+func (overlayDecoder) Detect(tx *bolt.Tx, dbPath string) bool {
+	return inPluginDir(dbPath, "overlayfs")
+}
+
+func (overlayDecoder) DecodeSnapshot(root string, bkt *bolt.Bucket, info *SnapshotInfo) error {
+	dir := snapshotDir(root, info)
+	setExtra(info, "upperdir", filepath.Join(dir, "fs"))
+	if info.Kind == snapshots.KindActive {
+		setExtra(info, "workdir", filepath.Join(dir, "work"))
+	}
+	return nil
+}
+
+// nativeDecoder decodes io.containerd.snapshotter.v1.native databases
+type nativeDecoder struct{}
+
+func (nativeDecoder) Name() string { return "native" }
+
+func (nativeDecoder) Detect(tx *bolt.Tx, dbPath string) bool {
+	return inPluginDir(dbPath, "native")
+}
+
+func (nativeDecoder) DecodeSnapshot(root string, bkt *bolt.Bucket, info *SnapshotInfo) error {
+	setExtra(info, "dir", snapshotDir(root, info))
+	return nil
+}
+
+// btrfsDecoder decodes io.containerd.snapshotter.v1.btrfs databases
+type btrfsDecoder struct{}
+
+func (btrfsDecoder) Name() string { return "btrfs" }
+
+func (btrfsDecoder) Detect(tx *bolt.Tx, dbPath string) bool {
+	return inPluginDir(dbPath, "btrfs")
+}
+
+func (btrfsDecoder) DecodeSnapshot(root string, bkt *bolt.Bucket, info *SnapshotInfo) error {
+	// Committed snapshots are read-only subvolumes under snapshots/, the rest live under active/
+	dir := "active"
+	if info.Kind == snapshots.KindCommitted {
+		dir = "snapshots"
+	}
+	setExtra(info, "subvolume", filepath.Join(root, dir, strconv.FormatUint(info.ID, 10)))
+	return nil
+}
+
+// devmapperDecoder decodes io.containerd.snapshotter.v1.devmapper databases
+type devmapperDecoder struct{}
+
+func (devmapperDecoder) Name() string { return "devmapper" }
+
+func (devmapperDecoder) CommandGroups() []string { return []string{"devmapper"} }
+
+func (devmapperDecoder) Detect(tx *bolt.Tx, dbPath string) bool {
+	return inPluginDir(dbPath, "devmapper") || devmapperDevicesBucket(tx) != nil
+}
+
+func (d devmapperDecoder) DecodeSnapshot(root string, bkt *bolt.Bucket, info *SnapshotInfo) error {
+	decoder, err := d.ForTx(bkt.Tx())
+	if err != nil {
+		return err
+	}
+	return decoder.DecodeSnapshot(root, bkt, info)
+}
+
+// ForTx indexes the thin devices of the transaction by snapshot ID
+func (devmapperDecoder) ForTx(tx *bolt.Tx) (SchemaDecoder, error) {
+	devices := make(map[string]DevmapperDeviceInfo)
+	devicesBkt := devmapperDevicesBucket(tx)
+	if devicesBkt == nil {
+		// Devices are kept in a separate pool database
+		return devmapperTxDecoder{devices: devices}, nil
+	}
+
+	err := devicesBkt.ForEach(func(k, v []byte) error {
+		id, ok := DevmapperSnapshotID(string(k))
+		if v == nil || !ok {
+			return nil
+		}
+
+		var device DevmapperDeviceInfo
+		if err := json.Unmarshal(v, &device); err != nil {
+			return fmt.Errorf("failed to decode device %s: %w", string(k), err)
+		}
+		devices[id] = device
+		return nil
+	})
+	return devmapperTxDecoder{devices: devices}, err
+}
+
+// devmapperTxDecoder decodes devmapper snapshots of one transaction using
+// its thin devices indexed by snapshot ID
+type devmapperTxDecoder struct {
+	devmapperDecoder
+	devices map[string]DevmapperDeviceInfo
+}
+
+func (d devmapperTxDecoder) DecodeSnapshot(root string, bkt *bolt.Bucket, info *SnapshotInfo) error {
+	device, ok := d.devices[strconv.FormatUint(info.ID, 10)]
+	if !ok {
+		return nil
+	}
+	setExtra(info, "device", device.Name)
+	setExtra(info, "device_id", strconv.FormatUint(uint64(device.DeviceID), 10))
+	setExtra(info, "device_state", DevmapperStateString(device.State))
+	return nil
+}
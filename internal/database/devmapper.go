@@ -0,0 +1,162 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// DevmapperDevicesBucket holds <device_name>=<JSON DeviceInfo> records
+	DevmapperDevicesBucket = []byte("devices")
+)
+
+// Devmapper cross-reference statuses
+const (
+	DevmapperMappingOK            = "ok"
+	DevmapperMappingOrphanDevice  = "orphan-device"
+	DevmapperMappingMissingDevice = "missing-device"
+)
+
+// devmapperSnapSeparator separates the pool name from the snapshot ID in
+// device names created by the devmapper snapshotter (<pool>-snap-<id>)
+const devmapperSnapSeparator = "-snap-"
+
+// devmapperDevicesBucket locates the devices bucket, which lives at the top
+// level of the pool database but may also be nested under v1
+func devmapperDevicesBucket(tx *bolt.Tx) *bolt.Bucket {
+	if bkt := tx.Bucket(DevmapperDevicesBucket); bkt != nil {
+		return bkt
+	}
+	if v1Bkt := tx.Bucket(bucketKeyStorageVersion); v1Bkt != nil {
+		return v1Bkt.Bucket(DevmapperDevicesBucket)
+	}
+	return nil
+}
+
+// ListDevmapperDevices returns all thin devices recorded by the devmapper snapshotter
+func (r *MetaReader) ListDevmapperDevices() ([]DevmapperDeviceInfo, error) {
+	var devices []DevmapperDeviceInfo
+
+	err := r.db.View(func(tx *bolt.Tx) error {
+		devicesBkt := devmapperDevicesBucket(tx)
+		if devicesBkt == nil {
+			return fmt.Errorf("devices bucket not found")
+		}
+
+		return devicesBkt.ForEach(func(k, v []byte) error {
+			if v == nil { // skip nested buckets
+				return nil
+			}
+
+			var info DevmapperDeviceInfo
+			if err := json.Unmarshal(v, &info); err != nil {
+				return fmt.Errorf("failed to decode device %s: %w", string(k), err)
+			}
+
+			devices = append(devices, info)
+			return nil
+		})
+	})
+
+	return devices, err
+}
+
+// GetDevmapperDevice returns a specific thin device by name
+func (r *MetaReader) GetDevmapperDevice(name string) (*DevmapperDeviceInfo, error) {
+	var info *DevmapperDeviceInfo
+
+	err := r.db.View(func(tx *bolt.Tx) error {
+		devicesBkt := devmapperDevicesBucket(tx)
+		if devicesBkt == nil {
+			return fmt.Errorf("devices bucket not found")
+		}
+
+		data := devicesBkt.Get([]byte(name))
+		if data == nil {
+			return fmt.Errorf("device %s not found", name)
+		}
+
+		var device DevmapperDeviceInfo
+		if err := json.Unmarshal(data, &device); err != nil {
+			return fmt.Errorf("failed to decode device %s: %w", name, err)
+		}
+
+		info = &device
+		return nil
+	})
+
+	return info, err
+}
+
+// DevmapperSnapshotID extracts the snapshot ID from a devmapper device name,
+// returning false for devices not created for a snapshot (e.g. the base device)
+func DevmapperSnapshotID(deviceName string) (string, bool) {
+	i := strings.LastIndex(deviceName, devmapperSnapSeparator)
+	if i < 0 {
+		return "", false
+	}
+	id := deviceName[i+len(devmapperSnapSeparator):]
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		return "", false
+	}
+	return id, true
+}
+
+// MapDevmapperDevices cross-references thin devices with snapshots by snapshot ID.
+// Devices without a snapshot are reported as orphans and snapshots without a
+// device as missing.
+func MapDevmapperDevices(devices []DevmapperDeviceInfo, snapshots []SnapshotInfo) []DevmapperMapping {
+	byID := make(map[string]SnapshotInfo, len(snapshots))
+	for _, snapshot := range snapshots {
+		byID[strconv.FormatUint(snapshot.ID, 10)] = snapshot
+	}
+
+	var mappings []DevmapperMapping
+	seen := make(map[string]bool)
+	for _, device := range devices {
+		id, ok := DevmapperSnapshotID(device.Name)
+		if !ok {
+			continue
+		}
+
+		mapping := DevmapperMapping{
+			DeviceName: device.Name,
+			DeviceID:   device.DeviceID,
+			State:      DevmapperStateString(device.State),
+			SnapshotID: id,
+			Status:     DevmapperMappingOrphanDevice,
+		}
+		if snapshot, ok := byID[id]; ok {
+			mapping.SnapshotKey = snapshot.Key
+			mapping.Kind = SnapshotKindString(snapshot.Kind)
+			mapping.Status = DevmapperMappingOK
+			seen[id] = true
+		}
+		mappings = append(mappings, mapping)
+	}
+
+	for id, snapshot := range byID {
+		if seen[id] {
+			continue
+		}
+		mappings = append(mappings, DevmapperMapping{
+			SnapshotKey: snapshot.Key,
+			SnapshotID:  id,
+			Kind:        SnapshotKindString(snapshot.Kind),
+			Status:      DevmapperMappingMissingDevice,
+		})
+	}
+
+	sort.SliceStable(mappings, func(i, j int) bool {
+		a, _ := strconv.ParseUint(mappings[i].SnapshotID, 10, 64)
+		b, _ := strconv.ParseUint(mappings[j].SnapshotID, 10, 64)
+		return a < b
+	})
+
+	return mappings
+}
@@ -0,0 +1,241 @@
+package database
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/snapshots"
+	bolt "go.etcd.io/bbolt"
+)
+
+// setupDevmapperDB creates a pool database with a base device and two snapshot devices
+func setupDevmapperDB(t *testing.T) string {
+	dbPath := filepath.Join(t.TempDir(), "pool.db")
+
+	db, err := bolt.Open(dbPath, 0600, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	devices := []DevmapperDeviceInfo{
+		{DeviceID: 1, Size: 10 << 30, Name: "base", State: 4},
+		{DeviceID: 2, Size: 10 << 30, Name: "pool-snap-1", ParentName: "base", State: 4},
+		{DeviceID: 3, Size: 10 << 30, Name: "pool-snap-7", ParentName: "pool-snap-1", State: 13, Error: "device busy"},
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		devicesBkt, err := tx.CreateBucket(DevmapperDevicesBucket)
+		if err != nil {
+			return err
+		}
+		for _, device := range devices {
+			data, err := json.Marshal(device)
+			if err != nil {
+				return err
+			}
+			if err := devicesBkt.Put([]byte(device.Name), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+
+	return dbPath
+}
+
+func TestMetaReader_ListDevmapperDevices(t *testing.T) {
+	reader, err := NewMetaReader(setupDevmapperDB(t))
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	devices, err := reader.ListDevmapperDevices()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(devices) != 3 {
+		t.Fatalf("Expected 3 devices, got %d", len(devices))
+	}
+
+	if devices[0].Name != "base" || devices[0].DeviceID != 1 {
+		t.Errorf("Expected first device to be base with ID 1, got %+v", devices[0])
+	}
+}
+
+func TestMetaReader_GetDevmapperDevice(t *testing.T) {
+	reader, err := NewMetaReader(setupDevmapperDB(t))
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	t.Run("existing device", func(t *testing.T) {
+		device, err := reader.GetDevmapperDevice("pool-snap-7")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if device.ParentName != "pool-snap-1" {
+			t.Errorf("Expected parent = 'pool-snap-1', got %s", device.ParentName)
+		}
+		if DevmapperStateString(device.State) != "Faulty" {
+			t.Errorf("Expected state = Faulty, got %s", DevmapperStateString(device.State))
+		}
+	})
+
+	t.Run("non-existent device", func(t *testing.T) {
+		if _, err := reader.GetDevmapperDevice("missing"); err == nil {
+			t.Error("Expected error for non-existent device")
+		}
+	})
+}
+
+func TestMetaReader_ListDevmapperDevicesNoBucket(t *testing.T) {
+	reader, err := NewMetaReader(setupTestDB(t))
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	if _, err := reader.ListDevmapperDevices(); err == nil {
+		t.Error("Expected error when devices bucket is missing")
+	}
+}
+
+func TestDevmapperSnapshotID(t *testing.T) {
+	tests := []struct {
+		name   string
+		wantID string
+		wantOK bool
+	}{
+		{"containerd-pool-snap-42", "42", true},
+		{"pool-snap-1", "1", true},
+		{"base", "", false},
+		{"pool-snap-abc", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := DevmapperSnapshotID(tt.name)
+			if id != tt.wantID || ok != tt.wantOK {
+				t.Errorf("DevmapperSnapshotID(%s) = (%s, %v), want (%s, %v)", tt.name, id, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMapDevmapperDevices(t *testing.T) {
+	devices := []DevmapperDeviceInfo{
+		{DeviceID: 1, Name: "base"},
+		{DeviceID: 2, Name: "pool-snap-1", State: 4},
+		{DeviceID: 3, Name: "pool-snap-7", State: 12},
+	}
+	snapshotList := []SnapshotInfo{
+		{Key: "default/1/layer", ID: 1, Kind: snapshots.KindCommitted},
+		{Key: "default/2/rootfs", ID: 2, Kind: snapshots.KindActive},
+	}
+
+	mappings := MapDevmapperDevices(devices, snapshotList)
+	if len(mappings) != 3 {
+		t.Fatalf("Expected 3 mappings, got %d", len(mappings))
+	}
+
+	expected := []struct {
+		snapshotID string
+		status     string
+	}{
+		{"1", DevmapperMappingOK},
+		{"2", DevmapperMappingMissingDevice},
+		{"7", DevmapperMappingOrphanDevice},
+	}
+	for i, want := range expected {
+		if mappings[i].SnapshotID != want.snapshotID || mappings[i].Status != want.status {
+			t.Errorf("Expected mapping %d = (%s, %s), got (%s, %s)", i, want.snapshotID, want.status, mappings[i].SnapshotID, mappings[i].Status)
+		}
+	}
+
+	if mappings[0].DeviceID != 2 || mappings[0].State != "Activated" {
+		t.Errorf("Expected snapshot 1 to map to device 2 in state Activated, got %+v", mappings[0])
+	}
+}
+
+// addDevmapperDevices writes devices, as raw JSON by name, to the devices
+// bucket under v1 of the database at dbPath
+func addDevmapperDevices(t *testing.T, dbPath string, devices map[string]string) {
+	db, err := bolt.Open(dbPath, 0600, nil)
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		devicesBkt, err := tx.Bucket(bucketKeyStorageVersion).CreateBucketIfNotExists(DevmapperDevicesBucket)
+		if err != nil {
+			return err
+		}
+		for name, data := range devices {
+			if err := devicesBkt.Put([]byte(name), []byte(data)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to add devices: %v", err)
+	}
+}
+
+func TestDevmapperDecoder(t *testing.T) {
+	t.Run("device by snapshot ID", func(t *testing.T) {
+		dbPath := setupPlainSnapshotDB(t, filepath.Join(t.TempDir(), "io.containerd.snapshotter.v1.devmapper"))
+		addDevmapperDevices(t, dbPath, map[string]string{
+			"base":        `{"device_id":1,"name":"base","state":4}`,
+			"pool-snap-1": `{"device_id":2,"name":"pool-snap-1","state":4}`,
+		})
+		reader, err := NewMetaReader(dbPath)
+		if err != nil {
+			t.Fatalf("Failed to create reader: %v", err)
+		}
+		defer reader.Close()
+
+		list, err := reader.ListSnapshots()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for _, snapshot := range list {
+			device := ""
+			if snapshot.ID == 1 {
+				device = "pool-snap-1"
+			}
+			if snapshot.Extra["device"] != device {
+				t.Errorf("Expected snapshot %d device %q, got %q", snapshot.ID, device, snapshot.Extra["device"])
+			}
+		}
+		if list[0].Extra["device_id"] != "2" {
+			t.Errorf("Expected device_id 2, got %s", list[0].Extra["device_id"])
+		}
+	})
+
+	t.Run("invalid device record", func(t *testing.T) {
+		dbPath := setupPlainSnapshotDB(t, filepath.Join(t.TempDir(), "io.containerd.snapshotter.v1.devmapper"))
+		addDevmapperDevices(t, dbPath, map[string]string{"pool-snap-1": `{"device_id":`})
+		reader, err := NewMetaReader(dbPath)
+		if err != nil {
+			t.Fatalf("Failed to create reader: %v", err)
+		}
+		defer reader.Close()
+
+		if _, err := reader.ListSnapshots(); err == nil {
+			t.Error("Expected error for an undecodable device record")
+		}
+		if _, err := reader.GetSnapshot("default/1/layer"); err == nil {
+			t.Error("Expected error for an undecodable device record")
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/containerd/containerd/metadata/boltutil"
+	"github.com/containerd/meta-viewer/internal/utils"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// DefaultContainerdMetaDBPath is the default location of containerd's metadata database
+	DefaultContainerdMetaDBPath = "/var/lib/containerd/io.containerd.metadata.v1.bolt/meta.db"
+)
+
+var (
+	metaKeyImages      = []byte("images")
+	metaKeyContainers  = []byte("containers")
+	metaKeySnapshots   = []byte("snapshots")
+	metaKeyContent     = []byte("content")
+	metaKeyBlob        = []byte("blob")
+	metaKeyLeases      = []byte("leases")
+	metaKeyTarget      = []byte("target")
+	metaKeyDigest      = []byte("digest")
+	metaKeyName        = []byte("name")
+	metaKeyParent      = []byte("parent")
+	metaKeySize        = []byte("size")
+	metaKeyImage       = []byte("image")
+	metaKeySnapshotter = []byte("snapshotter")
+	metaKeySnapshotKey = []byte("snapshotKey")
+)
+
+// ReadContainerdMetadata reads images, containers, content, snapshots and
+// leases from every namespace of a containerd meta.db
+func (r *MetaReader) ReadContainerdMetadata() (*ContainerdMetadata, error) {
+	meta := &ContainerdMetadata{}
+
+	err := r.db.View(func(tx *bolt.Tx) error {
+		v1Bkt := tx.Bucket(bucketKeyStorageVersion)
+		if v1Bkt == nil {
+			return fmt.Errorf("v1 bucket not found")
+		}
+
+		return v1Bkt.ForEach(func(k, v []byte) error {
+			if v != nil { // skip non-buckets
+				return nil
+			}
+
+			ns := string(k)
+			if err := readNamespaceMetadata(ns, v1Bkt.Bucket(k), meta); err != nil {
+				return fmt.Errorf("failed to read namespace %s: %w", ns, err)
+			}
+			return nil
+		})
+	})
+
+	return meta, err
+}
+
+// readNamespaceMetadata reads all GC relevant resources of a single namespace
+func readNamespaceMetadata(ns string, nsBkt *bolt.Bucket, meta *ContainerdMetadata) error {
+	if imagesBkt := nsBkt.Bucket(metaKeyImages); imagesBkt != nil {
+		if err := forEachBucket(imagesBkt, func(name string, bkt *bolt.Bucket) error {
+			image := MetaImage{Namespace: ns, Name: name}
+			if target := bkt.Bucket(metaKeyTarget); target != nil {
+				image.Target = string(target.Get(metaKeyDigest))
+			}
+
+			labels, err := boltutil.ReadLabels(bkt)
+			if err != nil {
+				return fmt.Errorf("failed to read labels of image %s: %w", name, err)
+			}
+			image.Labels = labels
+
+			meta.Images = append(meta.Images, image)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if containersBkt := nsBkt.Bucket(metaKeyContainers); containersBkt != nil {
+		if err := forEachBucket(containersBkt, func(id string, bkt *bolt.Bucket) error {
+			container := MetaContainer{
+				Namespace:   ns,
+				ID:          id,
+				Snapshotter: string(bkt.Get(metaKeySnapshotter)),
+				SnapshotKey: string(bkt.Get(metaKeySnapshotKey)),
+				Image:       string(bkt.Get(metaKeyImage)),
+			}
+
+			labels, err := boltutil.ReadLabels(bkt)
+			if err != nil {
+				return fmt.Errorf("failed to read labels of container %s: %w", id, err)
+			}
+			container.Labels = labels
+
+			meta.Containers = append(meta.Containers, container)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if contentBkt := nsBkt.Bucket(metaKeyContent); contentBkt != nil {
+		if blobBkt := contentBkt.Bucket(metaKeyBlob); blobBkt != nil {
+			if err := forEachBucket(blobBkt, func(dgst string, bkt *bolt.Bucket) error {
+				content := MetaContent{Namespace: ns, Digest: dgst}
+				if sizeData := bkt.Get(metaKeySize); sizeData != nil {
+					content.Size = utils.ReadSize(sizeData)
+				}
+
+				labels, err := boltutil.ReadLabels(bkt)
+				if err != nil {
+					return fmt.Errorf("failed to read labels of content %s: %w", dgst, err)
+				}
+				content.Labels = labels
+
+				meta.Content = append(meta.Content, content)
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if snapshotsBkt := nsBkt.Bucket(metaKeySnapshots); snapshotsBkt != nil {
+		if err := forEachBucket(snapshotsBkt, func(snapshotter string, ssBkt *bolt.Bucket) error {
+			return forEachBucket(ssBkt, func(key string, bkt *bolt.Bucket) error {
+				snapshot := MetaSnapshot{
+					Namespace:   ns,
+					Snapshotter: snapshotter,
+					Key:         key,
+					Name:        string(bkt.Get(metaKeyName)),
+					Parent:      string(bkt.Get(metaKeyParent)),
+				}
+
+				labels, err := boltutil.ReadLabels(bkt)
+				if err != nil {
+					return fmt.Errorf("failed to read labels of snapshot %s/%s: %w", snapshotter, key, err)
+				}
+				snapshot.Labels = labels
+
+				meta.Snapshots = append(meta.Snapshots, snapshot)
+				return nil
+			})
+		}); err != nil {
+			return err
+		}
+	}
+
+	if leasesBkt := nsBkt.Bucket(metaKeyLeases); leasesBkt != nil {
+		if err := forEachBucket(leasesBkt, func(id string, bkt *bolt.Bucket) error {
+			lease := MetaLease{Namespace: ns, ID: id}
+
+			labels, err := boltutil.ReadLabels(bkt)
+			if err != nil {
+				return fmt.Errorf("failed to read labels of lease %s: %w", id, err)
+			}
+			lease.Labels = labels
+
+			if contentBkt := bkt.Bucket(metaKeyContent); contentBkt != nil {
+				if err := contentBkt.ForEach(func(k, v []byte) error {
+					lease.Content = append(lease.Content, string(k))
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+
+			if snapshotsBkt := bkt.Bucket(metaKeySnapshots); snapshotsBkt != nil {
+				if err := forEachBucket(snapshotsBkt, func(snapshotter string, ssBkt *bolt.Bucket) error {
+					return ssBkt.ForEach(func(k, v []byte) error {
+						lease.Snapshots = append(lease.Snapshots, snapshotter+"/"+string(k))
+						return nil
+					})
+				}); err != nil {
+					return err
+				}
+			}
+
+			meta.Leases = append(meta.Leases, lease)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// forEachBucket calls fn for every nested bucket of bkt, skipping plain keys
+func forEachBucket(bkt *bolt.Bucket, fn func(name string, bkt *bolt.Bucket) error) error {
+	return bkt.ForEach(func(k, v []byte) error {
+		if v != nil { // skip non-buckets
+			return nil
+		}
+		return fn(string(k), bkt.Bucket(k))
+	})
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/metadata/boltutil"
+	bolt "go.etcd.io/bbolt"
+)
+
+// setupContainerdMetaDB creates a minimal containerd meta.db with one of each GC resource
+func setupContainerdMetaDB(t *testing.T) string {
+	dbPath := filepath.Join(t.TempDir(), "meta.db")
+
+	db, err := bolt.Open(dbPath, 0600, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		v1Bkt, err := tx.CreateBucket(bucketKeyStorageVersion)
+		if err != nil {
+			return err
+		}
+		nsBkt, err := v1Bkt.CreateBucket([]byte("k8s.io"))
+		if err != nil {
+			return err
+		}
+
+		// Image pointing at a config blob
+		imageBkt, err := nsBkt.CreateBucket(metaKeyImages)
+		if err != nil {
+			return err
+		}
+		img, err := imageBkt.CreateBucket([]byte("docker.io/library/busybox:latest"))
+		if err != nil {
+			return err
+		}
+		target, err := img.CreateBucket(metaKeyTarget)
+		if err != nil {
+			return err
+		}
+		if err := target.Put(metaKeyDigest, []byte("sha256:config")); err != nil {
+			return err
+		}
+
+		// Content blob with a snapshot reference
+		contentBkt, err := nsBkt.CreateBucket(metaKeyContent)
+		if err != nil {
+			return err
+		}
+		blobBkt, err := contentBkt.CreateBucket(metaKeyBlob)
+		if err != nil {
+			return err
+		}
+		blob, err := blobBkt.CreateBucket([]byte("sha256:config"))
+		if err != nil {
+			return err
+		}
+		if err := boltutil.WriteLabels(blob, map[string]string{
+			"containerd.io/gc.ref.snapshot.devbox": "layer-1",
+		}); err != nil {
+			return err
+		}
+
+		// Snapshot reference
+		snapshotsBkt, err := nsBkt.CreateBucket(metaKeySnapshots)
+		if err != nil {
+			return err
+		}
+		devboxBkt, err := snapshotsBkt.CreateBucket([]byte("devbox"))
+		if err != nil {
+			return err
+		}
+		snap, err := devboxBkt.CreateBucket([]byte("rootfs"))
+		if err != nil {
+			return err
+		}
+		if err := snap.Put(metaKeyName, []byte("k8s.io/2/rootfs")); err != nil {
+			return err
+		}
+		if err := snap.Put(metaKeyParent, []byte("layer-1")); err != nil {
+			return err
+		}
+
+		// Container using the snapshot
+		containersBkt, err := nsBkt.CreateBucket(metaKeyContainers)
+		if err != nil {
+			return err
+		}
+		container, err := containersBkt.CreateBucket([]byte("web"))
+		if err != nil {
+			return err
+		}
+		if err := container.Put(metaKeySnapshotter, []byte("devbox")); err != nil {
+			return err
+		}
+		if err := container.Put(metaKeySnapshotKey, []byte("rootfs")); err != nil {
+			return err
+		}
+
+		// Lease holding a snapshot
+		leasesBkt, err := nsBkt.CreateBucket(metaKeyLeases)
+		if err != nil {
+			return err
+		}
+		lease, err := leasesBkt.CreateBucket([]byte("pull"))
+		if err != nil {
+			return err
+		}
+		leaseSnaps, err := lease.CreateBucket(metaKeySnapshots)
+		if err != nil {
+			return err
+		}
+		leaseDevbox, err := leaseSnaps.CreateBucket([]byte("devbox"))
+		if err != nil {
+			return err
+		}
+		return leaseDevbox.Put([]byte("extract-1"), nil)
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+
+	return dbPath
+}
+
+func TestMetaReader_ReadContainerdMetadata(t *testing.T) {
+	reader, err := NewMetaReader(setupContainerdMetaDB(t))
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	meta, err := reader.ReadContainerdMetadata()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(meta.Images) != 1 || meta.Images[0].Target != "sha256:config" {
+		t.Errorf("Expected one image targeting sha256:config, got %+v", meta.Images)
+	}
+
+	if len(meta.Content) != 1 || meta.Content[0].Labels["containerd.io/gc.ref.snapshot.devbox"] != "layer-1" {
+		t.Errorf("Expected one content blob with a snapshot ref, got %+v", meta.Content)
+	}
+
+	if len(meta.Snapshots) != 1 {
+		t.Fatalf("Expected 1 snapshot, got %d", len(meta.Snapshots))
+	}
+	snap := meta.Snapshots[0]
+	if snap.Namespace != "k8s.io" || snap.Snapshotter != "devbox" || snap.Key != "rootfs" {
+		t.Errorf("Unexpected snapshot identity: %+v", snap)
+	}
+	if snap.Name != "k8s.io/2/rootfs" || snap.Parent != "layer-1" {
+		t.Errorf("Unexpected snapshot name/parent: %+v", snap)
+	}
+
+	if len(meta.Containers) != 1 || meta.Containers[0].SnapshotKey != "rootfs" {
+		t.Errorf("Expected one container using rootfs, got %+v", meta.Containers)
+	}
+
+	if len(meta.Leases) != 1 || len(meta.Leases[0].Snapshots) != 1 || meta.Leases[0].Snapshots[0] != "devbox/extract-1" {
+		t.Errorf("Expected one lease holding devbox/extract-1, got %+v", meta.Leases)
+	}
+}
+
+func TestMetaReader_ReadContainerdMetadataEmpty(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "empty.db")
+	db, err := bolt.Open(dbPath, 0600, nil)
+	if err != nil {
+		t.Fatalf("Failed to create empty database: %v", err)
+	}
+	db.Close()
+
+	reader, err := NewMetaReader(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	if _, err := reader.ReadContainerdMetadata(); err == nil {
+		t.Error("Expected error when v1 bucket is missing")
+	}
+}
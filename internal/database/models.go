@@ -1,6 +1,8 @@
 package database
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/containerd/containerd/snapshots"
@@ -23,14 +25,78 @@ type SnapshotInfo struct {
 	// Devbox specific fields
 	ContentID string `json:"content_id,omitempty"`
 	Path      string `json:"path,omitempty"`
+
+	// Snapshotter specific fields contributed by the schema decoder
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+// SnapshotJSON is the JSON encoding of SnapshotInfo. Kind is containerd's
+// name of the kind, e.g. "Committed", KindValue the number stored in the
+// database.
+// The enum tag lists the values of Kind in the published JSON Schema.
+type SnapshotJSON struct {
+	Key       string            `json:"key"`
+	ID        uint64            `json:"id"`
+	Kind      string            `json:"kind" enum:"Active,Committed,View,Unknown"`
+	KindValue *uint8            `json:"kind_value,omitempty"`
+	Parent    string            `json:"parent"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Inodes    int64             `json:"inodes"`
+	Size      int64             `json:"size"`
+	ContentID string            `json:"content_id,omitempty"`
+	Path      string            `json:"path,omitempty"`
+	Extra     map[string]string `json:"extra,omitempty"`
+}
+
+// JSON returns the JSON encoding of the snapshot, with the numeric kind
+// when withKindValue is set
+func (s SnapshotInfo) JSON(withKindValue bool) SnapshotJSON {
+	encoded := SnapshotJSON{
+		Key:       s.Key,
+		ID:        s.ID,
+		Kind:      s.Kind.String(),
+		Parent:    s.Parent,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+		Labels:    s.Labels,
+		Inodes:    s.Inodes,
+		Size:      s.Size,
+		ContentID: s.ContentID,
+		Path:      s.Path,
+		Extra:     s.Extra,
+	}
+	if withKindValue {
+		value := uint8(s.Kind)
+		encoded.KindValue = &value
+	}
+	return encoded
+}
+
+// MarshalJSON encodes the snapshot as SnapshotJSON, without the numeric kind
+func (s SnapshotInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.JSON(false))
 }
 
 // DevboxStorageInfo represents devbox-specific storage metadata
 type DevboxStorageInfo struct {
-	ContentID string `json:"content_id"`
-	LvName    string `json:"lv_name"`
-	Path      string `json:"path"`
-	Status    string `json:"status"`
+	ContentID string            `json:"content_id"`
+	LvName    string            `json:"lv_name"`
+	Path      string            `json:"path"`
+	Status    string            `json:"status"`
+	Extra     map[string]string `json:"extra,omitempty"`
+}
+
+// Volume returns the volume group and LV name the entry refers to. The
+// volume group is known when the entry records it in a vg_name extra
+// field, or names the LV as vg/lv or by its /dev/<vg>/<lv> path.
+func (s DevboxStorageInfo) Volume() (vg, name string) {
+	name = strings.TrimPrefix(s.LvName, "/dev/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return s.Extra["vg_name"], name
 }
 
 // BucketInfo represents basic information about a bolt bucket
@@ -51,4 +117,94 @@ func SnapshotKindString(kind snapshots.Kind) string {
 	default:
 		return "unknown"
 	}
-}
\ No newline at end of file
+}
+
+// ContainerdMetadata holds the resources read from containerd's meta.db that
+// take part in garbage collection
+type ContainerdMetadata struct {
+	Snapshots  []MetaSnapshot  `json:"snapshots"`
+	Content    []MetaContent   `json:"content"`
+	Images     []MetaImage     `json:"images"`
+	Containers []MetaContainer `json:"containers"`
+	Leases     []MetaLease     `json:"leases"`
+}
+
+// MetaSnapshot represents a snapshot reference in containerd's meta.db
+type MetaSnapshot struct {
+	Namespace   string            `json:"namespace"`
+	Snapshotter string            `json:"snapshotter"`
+	Key         string            `json:"key"`
+	Name        string            `json:"name"`
+	Parent      string            `json:"parent,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// MetaContent represents a content blob reference in containerd's meta.db
+type MetaContent struct {
+	Namespace string            `json:"namespace"`
+	Digest    string            `json:"digest"`
+	Size      int64             `json:"size"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// MetaImage represents an image record in containerd's meta.db
+type MetaImage struct {
+	Namespace string            `json:"namespace"`
+	Name      string            `json:"name"`
+	Target    string            `json:"target"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// MetaContainer represents a container record in containerd's meta.db
+type MetaContainer struct {
+	Namespace   string            `json:"namespace"`
+	ID          string            `json:"id"`
+	Snapshotter string            `json:"snapshotter,omitempty"`
+	SnapshotKey string            `json:"snapshot_key,omitempty"`
+	Image       string            `json:"image,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// MetaLease represents a lease and the resources it holds in containerd's meta.db
+type MetaLease struct {
+	Namespace string            `json:"namespace"`
+	ID        string            `json:"id"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Snapshots []string          `json:"snapshots,omitempty"` // <snapshotter>/<key>
+	Content   []string          `json:"content,omitempty"`
+}
+
+// DevmapperDeviceInfo represents a thin device record of the devmapper snapshotter
+// pool metadata (containerd's devmapper.DeviceInfo)
+type DevmapperDeviceInfo struct {
+	DeviceID   uint32 `json:"device_id"`
+	Size       uint64 `json:"size"`
+	Name       string `json:"name"`
+	ParentName string `json:"parent_name"`
+	State      int    `json:"state"`
+	Error      string `json:"error"`
+}
+
+// DevmapperMapping cross-references a thin device with the snapshot it backs
+type DevmapperMapping struct {
+	DeviceName  string `json:"device_name,omitempty"`
+	DeviceID    uint32 `json:"device_id"`
+	State       string `json:"state,omitempty"`
+	SnapshotKey string `json:"snapshot_key,omitempty"`
+	SnapshotID  string `json:"snapshot_id"`
+	Kind        string `json:"kind,omitempty"`
+	Status      string `json:"status"`
+}
+
+// DevmapperStateString converts a devmapper device state to a human readable string
+func DevmapperStateString(state int) string {
+	states := []string{
+		"Unknown", "Creating", "Created", "Activating", "Activated",
+		"Suspending", "Suspended", "Resuming", "Resumed",
+		"Deactivating", "Deactivated", "Removing", "Removed", "Faulty",
+	}
+	if state < 0 || state >= len(states) {
+		return "unknown"
+	}
+	return states[state]
+}
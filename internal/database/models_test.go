@@ -1,6 +1,8 @@
 package database
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -110,6 +112,37 @@ func TestSnapshotInfo(t *testing.T) {
 	})
 }
 
+func TestSnapshotInfo_MarshalJSON(t *testing.T) {
+	info := SnapshotInfo{Key: "layer-1", ID: 1, Kind: snapshots.KindCommitted}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(string(data), `"kind":"Committed"`) || strings.Contains(string(data), "kind_value") {
+		t.Errorf("Expected the kind name without kind_value, got %s", data)
+	}
+	if !strings.HasPrefix(string(data), `{"key":"layer-1","id":1,"kind":"Committed",`) {
+		t.Errorf("Expected fields in declaration order, got %s", data)
+	}
+
+	data, err = json.Marshal(info.JSON(true))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(string(data), `"kind":"Committed","kind_value":3`) {
+		t.Errorf("Expected the numeric kind after the kind name, got %s", data)
+	}
+
+	var decoded SnapshotInfo
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if decoded.Kind != snapshots.KindCommitted {
+		t.Errorf("Expected the kind name to decode to KindCommitted, got %v", decoded.Kind)
+	}
+}
+
 func TestDevboxStorageInfo(t *testing.T) {
 	t.Run("create devbox storage info", func(t *testing.T) {
 		info := DevboxStorageInfo{
@@ -0,0 +1,326 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containerd/meta-viewer/internal/utils"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Field value types supported by schema profiles
+const (
+	FieldTypeString    = "string"
+	FieldTypeVarint    = "varint"
+	FieldTypeUvarint   = "uvarint"
+	FieldTypeTimestamp = "timestamp"
+)
+
+// ProfileDirs lists the directories searched for profiles selected by name
+var ProfileDirs = []string{
+	"/etc/containerd-meta-viewer/profiles",
+}
+
+// FieldSpec maps a bolt key to a model field
+type FieldSpec struct {
+	Key  string `json:"key"`
+	Type string `json:"type,omitempty"` // string (default), varint, uvarint or timestamp
+}
+
+// BucketSpec describes a bucket of per-entry sub-buckets and the keys read from each entry
+type BucketSpec struct {
+	Bucket []string             `json:"bucket,omitempty"`
+	Fields map[string]FieldSpec `json:"fields"`
+}
+
+// SchemaProfile describes a devbox style snapshotter layout: extra keys stored
+// in each snapshot bucket and a storage bucket keyed by content ID. Fields
+// named content_id, path, lv_name and status fill the model fields of the same
+// name; any other field is exposed as an extra field.
+type SchemaProfile struct {
+	Name     string     `json:"name"`
+	Plugin   string     `json:"plugin,omitempty"`
+	Snapshot BucketSpec `json:"snapshot"`
+	Storage  BucketSpec `json:"storage"`
+}
+
+// DevboxProfile is the layout written by the upstream devbox snapshotter
+var DevboxProfile = &SchemaProfile{
+	Name:   "devbox",
+	Plugin: snapshotterPluginPrefix + "devbox",
+	Snapshot: BucketSpec{
+		Fields: map[string]FieldSpec{
+			"content_id": {Key: string(DevboxKeyContentID)},
+			"path":       {Key: string(DevboxKeyPath)},
+		},
+	},
+	Storage: BucketSpec{
+		Bucket: []string{string(bucketKeyStorageVersion), string(DevboxStoragePathBucket)},
+		Fields: map[string]FieldSpec{
+			"lv_name": {Key: string(DevboxKeyLvName)},
+			"path":    {Key: string(DevboxKeyPath)},
+			"status":  {Key: string(DevboxKeyStatus)},
+		},
+	},
+}
+
+// StorageDecoder is implemented by decoders whose snapshotter keeps a devbox
+// style storage bucket
+type StorageDecoder interface {
+	// StorageBucket returns the bucket path holding one sub-bucket per content ID
+	StorageBucket() [][]byte
+
+	// DecodeStorage reads a storage entry bucket into info
+	DecodeStorage(bkt *bolt.Bucket, info *DevboxStorageInfo) error
+}
+
+// LoadProfile reads a schema profile from a JSON file
+func LoadProfile(path string) (*SchemaProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+
+	var profile SchemaProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+	}
+
+	if profile.Name == "" {
+		profile.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid profile %s: %w", path, err)
+	}
+
+	return &profile, nil
+}
+
+// FindProfile resolves a profile by file path or by name. Names are looked up
+// as <name>.json in ProfileDirs, then among the built-in profiles.
+func FindProfile(nameOrPath string) (*SchemaProfile, error) {
+	if strings.ContainsRune(nameOrPath, os.PathSeparator) || filepath.Ext(nameOrPath) == ".json" {
+		return LoadProfile(nameOrPath)
+	}
+
+	for _, dir := range ProfileDirs {
+		path := filepath.Join(dir, nameOrPath+".json")
+		if _, err := os.Stat(path); err == nil {
+			return LoadProfile(path)
+		}
+	}
+
+	if nameOrPath == DevboxProfile.Name {
+		return DevboxProfile, nil
+	}
+
+	return nil, fmt.Errorf("schema profile %q not found in %v", nameOrPath, ProfileDirs)
+}
+
+// Validate checks that the profile describes something readable
+func (p *SchemaProfile) Validate() error {
+	if len(p.Storage.Fields) > 0 && len(p.Storage.Bucket) == 0 {
+		return fmt.Errorf("storage fields given without a storage bucket")
+	}
+
+	for _, spec := range []BucketSpec{p.Snapshot, p.Storage} {
+		for field, fs := range spec.Fields {
+			if fs.Key == "" {
+				return fmt.Errorf("field %s has no key", field)
+			}
+			switch fs.Type {
+			case "", FieldTypeString, FieldTypeVarint, FieldTypeUvarint, FieldTypeTimestamp:
+			default:
+				return fmt.Errorf("field %s has unknown type %q", field, fs.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// readFields decodes every field of spec from bkt, in a stable order
+func readFields(bkt *bolt.Bucket, spec BucketSpec, fn func(field, value string)) error {
+	fields := make([]string, 0, len(spec.Fields))
+	for field := range spec.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		fs := spec.Fields[field]
+		data := bkt.Get([]byte(fs.Key))
+		if data == nil {
+			continue
+		}
+
+		value, err := DecodeFieldValue(data, fs.Type)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %w", field, err)
+		}
+		fn(field, value)
+	}
+	return nil
+}
+
+// DecodeFieldValue converts a raw bolt value of the given profile type to a string
+func DecodeFieldValue(data []byte, typ string) (string, error) {
+	switch typ {
+	case "", FieldTypeString:
+		return string(data), nil
+	case FieldTypeVarint:
+		return strconv.FormatInt(utils.ReadSize(data), 10), nil
+	case FieldTypeUvarint:
+		return strconv.FormatUint(utils.ReadID(data), 10), nil
+	case FieldTypeTimestamp:
+		var t time.Time
+		if err := t.UnmarshalBinary(data); err != nil {
+			return "", err
+		}
+		return t.Format(time.RFC3339), nil
+	default:
+		return "", fmt.Errorf("unknown field type %q", typ)
+	}
+}
+
+// profileDecoder is a SchemaDecoder and StorageDecoder driven by a SchemaProfile.
+// Every profile has a devbox style storage bucket, so it contributes the
+// devbox command group.
+type profileDecoder struct {
+	profile *SchemaProfile
+}
+
+// NewProfileDecoder returns a schema decoder for the given profile
+func NewProfileDecoder(profile *SchemaProfile) SchemaDecoder {
+	return profileDecoder{profile: profile}
+}
+
+func (d profileDecoder) Name() string { return d.profile.Name }
+
+func (profileDecoder) CommandGroups() []string { return []string{"devbox"} }
+
+func (d profileDecoder) StorageBucket() [][]byte {
+	path := make([][]byte, len(d.profile.Storage.Bucket))
+	for i, name := range d.profile.Storage.Bucket {
+		path[i] = []byte(name)
+	}
+	return path
+}
+
+// storageBucket resolves the profile's storage bucket within tx
+func (d profileDecoder) storageBucket(tx *bolt.Tx) *bolt.Bucket {
+	return nestedBucket(tx, d.StorageBucket())
+}
+
+func (d profileDecoder) Detect(tx *bolt.Tx, dbPath string) bool {
+	if d.profile.Plugin != "" && filepath.Base(filepath.Dir(dbPath)) == d.profile.Plugin {
+		return true
+	}
+
+	if len(d.profile.Storage.Bucket) > 0 && d.storageBucket(tx) != nil {
+		return true
+	}
+
+	// Older databases may lack the storage bucket but still carry the content ID key
+	contentID, ok := d.profile.Snapshot.Fields["content_id"]
+	if !ok {
+		return false
+	}
+	v1Bkt := tx.Bucket(bucketKeyStorageVersion)
+	if v1Bkt == nil {
+		return false
+	}
+	snapshotsBkt := v1Bkt.Bucket(bucketKeySnapshot)
+	if snapshotsBkt == nil {
+		return false
+	}
+	c := snapshotsBkt.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v != nil {
+			continue
+		}
+		if snapshotsBkt.Bucket(k).Get([]byte(contentID.Key)) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (d profileDecoder) DecodeSnapshot(root string, bkt *bolt.Bucket, info *SnapshotInfo) error {
+	err := readFields(bkt, d.profile.Snapshot, func(field, value string) {
+		switch field {
+		case "content_id":
+			info.ContentID = value
+		case "path":
+			info.Path = value
+		default:
+			setExtra(info, field, value)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// Join the storage entry to expose the backing logical volume
+	if info.ContentID == "" || len(d.profile.Storage.Bucket) == 0 {
+		return nil
+	}
+	storageBkt := d.storageBucket(bkt.Tx())
+	if storageBkt == nil {
+		return nil
+	}
+	contentBkt := storageBkt.Bucket([]byte(info.ContentID))
+	if contentBkt == nil {
+		return nil
+	}
+
+	var storage DevboxStorageInfo
+	if err := d.DecodeStorage(contentBkt, &storage); err != nil {
+		return err
+	}
+	if storage.LvName != "" {
+		setExtra(info, "lv_name", storage.LvName)
+	}
+	if storage.Status != "" {
+		setExtra(info, "storage_status", storage.Status)
+	}
+	return nil
+}
+
+func (d profileDecoder) DecodeStorage(bkt *bolt.Bucket, info *DevboxStorageInfo) error {
+	return readFields(bkt, d.profile.Storage, func(field, value string) {
+		switch field {
+		case "lv_name":
+			info.LvName = value
+		case "path":
+			info.Path = value
+		case "status":
+			info.Status = value
+		default:
+			if info.Extra == nil {
+				info.Extra = make(map[string]string)
+			}
+			info.Extra[field] = value
+		}
+	})
+}
+
+// nestedBucket walks a bucket path from the top level of tx
+func nestedBucket(tx *bolt.Tx, path [][]byte) *bolt.Bucket {
+	if len(path) == 0 {
+		return nil
+	}
+	bkt := tx.Bucket(path[0])
+	for _, name := range path[1:] {
+		if bkt == nil {
+			return nil
+		}
+		bkt = bkt.Bucket(name)
+	}
+	return bkt
+}
@@ -0,0 +1,267 @@
+package database
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/snapshots"
+	bolt "go.etcd.io/bbolt"
+)
+
+const forkProfileJSON = `{
+  "name": "devbox-fork",
+  "snapshot": {
+    "fields": {
+      "content_id": {"key": "cid"},
+      "path": {"key": "mount_path"},
+      "quota": {"key": "quota", "type": "uvarint"}
+    }
+  },
+  "storage": {
+    "bucket": ["v1", "lvm_storage"],
+    "fields": {
+      "lv_name": {"key": "lv"},
+      "status": {"key": "state"},
+      "vg_name": {"key": "vg_name"},
+      "created": {"key": "created", "type": "timestamp"}
+    }
+  }
+}`
+
+// setupForkDB creates a database with a renamed devbox storage bucket and keys
+func setupForkDB(t *testing.T, created time.Time) string {
+	dbPath := filepath.Join(t.TempDir(), "metadata.db")
+
+	db, err := bolt.Open(dbPath, 0600, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		v1Bkt, err := tx.CreateBucket(bucketKeyStorageVersion)
+		if err != nil {
+			return err
+		}
+		snapshotsBkt, err := v1Bkt.CreateBucket(bucketKeySnapshot)
+		if err != nil {
+			return err
+		}
+		if err := createTestSnapshot(snapshotsBkt, "snapshot-1", 1, snapshots.KindActive, "", "", ""); err != nil {
+			return err
+		}
+		snapBkt := snapshotsBkt.Bucket([]byte("snapshot-1"))
+		quota := binary.AppendUvarint(nil, 1024)
+		for k, v := range map[string][]byte{"cid": []byte("content-1"), "mount_path": []byte("/mnt/1"), "quota": quota} {
+			if err := snapBkt.Put([]byte(k), v); err != nil {
+				return err
+			}
+		}
+
+		storageBkt, err := v1Bkt.CreateBucket([]byte("lvm_storage"))
+		if err != nil {
+			return err
+		}
+		contentBkt, err := storageBkt.CreateBucket([]byte("content-1"))
+		if err != nil {
+			return err
+		}
+		ts, err := created.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		for k, v := range map[string][]byte{"lv": []byte("lv-1"), "state": []byte("active"), "vg_name": []byte("vg0"), "created": ts} {
+			if err := contentBkt.Put([]byte(k), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+
+	return dbPath
+}
+
+func writeProfile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "devbox-fork.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write profile: %v", err)
+	}
+	return path
+}
+
+func TestLoadProfile(t *testing.T) {
+	profile, err := LoadProfile(writeProfile(t, forkProfileJSON))
+	if err != nil {
+		t.Fatalf("Failed to load profile: %v", err)
+	}
+
+	if profile.Name != "devbox-fork" {
+		t.Errorf("Expected name devbox-fork, got %s", profile.Name)
+	}
+	if len(profile.Storage.Bucket) != 2 || profile.Storage.Bucket[1] != "lvm_storage" {
+		t.Errorf("Unexpected storage bucket %v", profile.Storage.Bucket)
+	}
+}
+
+func TestLoadProfile_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"malformed json", `{"name":`},
+		{"unknown type", `{"snapshot": {"fields": {"x": {"key": "x", "type": "float"}}}}`},
+		{"missing key", `{"snapshot": {"fields": {"x": {"type": "string"}}}}`},
+		{"storage without bucket", `{"storage": {"fields": {"lv_name": {"key": "lv"}}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadProfile(writeProfile(t, tt.content)); err == nil {
+				t.Error("Expected error loading invalid profile")
+			}
+		})
+	}
+}
+
+func TestFindProfile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "devbox-fork.json"), []byte(forkProfileJSON), 0644); err != nil {
+		t.Fatalf("Failed to write profile: %v", err)
+	}
+
+	orig := ProfileDirs
+	ProfileDirs = []string{dir}
+	defer func() { ProfileDirs = orig }()
+
+	profile, err := FindProfile("devbox-fork")
+	if err != nil {
+		t.Fatalf("Failed to find profile by name: %v", err)
+	}
+	if profile.Name != "devbox-fork" {
+		t.Errorf("Expected devbox-fork, got %s", profile.Name)
+	}
+
+	if _, err := FindProfile(filepath.Join(dir, "devbox-fork.json")); err != nil {
+		t.Errorf("Failed to find profile by path: %v", err)
+	}
+
+	if profile, err := FindProfile("devbox"); err != nil || profile != DevboxProfile {
+		t.Errorf("Expected built-in devbox profile, got %v, %v", profile, err)
+	}
+
+	if _, err := FindProfile("missing"); err == nil {
+		t.Error("Expected error for unknown profile")
+	}
+}
+
+func TestDecodeFieldValue(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tsData, _ := ts.MarshalBinary()
+
+	tests := []struct {
+		name     string
+		data     []byte
+		typ      string
+		expected string
+	}{
+		{"string", []byte("abc"), FieldTypeString, "abc"},
+		{"default type", []byte("abc"), "", "abc"},
+		{"varint", binary.AppendVarint(nil, -5), FieldTypeVarint, "-5"},
+		{"uvarint", binary.AppendUvarint(nil, 300), FieldTypeUvarint, "300"},
+		{"timestamp", tsData, FieldTypeTimestamp, "2026-01-02T03:04:05Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := DecodeFieldValue(tt.data, tt.typ)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if value != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, value)
+			}
+		})
+	}
+
+	if _, err := DecodeFieldValue([]byte("x"), FieldTypeTimestamp); err == nil {
+		t.Error("Expected error decoding invalid timestamp")
+	}
+}
+
+func TestMetaReader_UseProfile(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	reader, err := NewMetaReader(setupForkDB(t, created))
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	profile, err := LoadProfile(writeProfile(t, forkProfileJSON))
+	if err != nil {
+		t.Fatalf("Failed to load profile: %v", err)
+	}
+	reader.UseProfile(profile)
+
+	if name := reader.Decoder().Name(); name != "devbox-fork" {
+		t.Errorf("Expected devbox-fork decoder, got %s", name)
+	}
+
+	snapshot, err := reader.GetSnapshot("snapshot-1")
+	if err != nil {
+		t.Fatalf("Failed to get snapshot: %v", err)
+	}
+	if snapshot.ContentID != "content-1" || snapshot.Path != "/mnt/1" {
+		t.Errorf("Unexpected content ID/path: %s %s", snapshot.ContentID, snapshot.Path)
+	}
+	expectedExtra := map[string]string{"quota": "1024", "lv_name": "lv-1", "storage_status": "active"}
+	for k, v := range expectedExtra {
+		if snapshot.Extra[k] != v {
+			t.Errorf("Expected extra %s = %s, got %s", k, v, snapshot.Extra[k])
+		}
+	}
+
+	storage, err := reader.ListDevboxStorage()
+	if err != nil {
+		t.Fatalf("Failed to list storage: %v", err)
+	}
+	if len(storage) != 1 {
+		t.Fatalf("Expected 1 storage entry, got %d", len(storage))
+	}
+	item := storage[0]
+	if item.ContentID != "content-1" || item.LvName != "lv-1" || item.Status != "active" {
+		t.Errorf("Unexpected storage entry: %+v", item)
+	}
+	if item.Extra["vg_name"] != "vg0" {
+		t.Errorf("Expected vg_name vg0, got %s", item.Extra["vg_name"])
+	}
+	if item.Extra["created"] != "2026-01-02T03:04:05Z" {
+		t.Errorf("Expected created timestamp, got %s", item.Extra["created"])
+	}
+
+	if _, err := reader.GetDevboxStorage("content-1"); err != nil {
+		t.Errorf("Failed to get storage entry: %v", err)
+	}
+}
+
+func TestMetaReader_DefaultProfileIgnoresFork(t *testing.T) {
+	reader, err := NewMetaReader(setupForkDB(t, time.Now()))
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	// Without the profile the renamed bucket and keys are invisible
+	storage, err := reader.ListDevboxStorage()
+	if err != nil {
+		t.Fatalf("Failed to list storage: %v", err)
+	}
+	if len(storage) != 0 {
+		t.Errorf("Expected no storage entries with the default layout, got %d", len(storage))
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/containerd/containerd/metadata/boltutil"
@@ -33,19 +35,32 @@ var (
 // MetaReader handles reading metadata from devbox snapshotter bolt database
 type MetaReader struct {
 	db       *bolt.DB
-	tempPath string // Path to temporary copy if database was copied
+	dbPath   string        // Host path of the original database file
+	tempPath string        // Path to temporary copy if database was copied
+	decoder  SchemaDecoder // Snapshotter schema used to decode snapshots
+	openedAt time.Time
+}
+
+// Source identifies the database state a reader returns
+type Source struct {
+	Path   string    // Host path of the database file
+	ReadAt time.Time // When the database was opened
+	TxID   int       // ID of the last transaction committed to the database
 }
 
 // NewMetaReader creates a new MetaReader instance
 // If the database is locked by another process, it will automatically copy
 // the database file to a temporary location and read from the copy.
+// dbPath is a host path and is resolved under utils.HostRoot.
 func NewMetaReader(dbPath string) (*MetaReader, error) {
+	openPath := utils.HostPath(dbPath)
+
 	// First, try to open in ReadOnly mode with a short timeout
 	opts := &bolt.Options{
 		ReadOnly: true,
 		Timeout:  1 * time.Second, // Short timeout to quickly detect lock
 	}
-	db, err := bolt.Open(dbPath, 0400, opts)
+	db, err := bolt.Open(openPath, 0400, opts)
 
 	var tempPath string
 
@@ -60,7 +75,7 @@ func NewMetaReader(dbPath string) (*MetaReader, error) {
 		tempFile.Close()
 
 		// Copy the database file
-		if err := copyFile(dbPath, tempPath); err != nil {
+		if err := copyFile(openPath, tempPath); err != nil {
 			os.Remove(tempPath)
 			return nil, fmt.Errorf("failed to copy database file for reading: %w", err)
 		}
@@ -79,7 +94,65 @@ func NewMetaReader(dbPath string) (*MetaReader, error) {
 		return nil, fmt.Errorf("failed to open bolt database: %w", err)
 	}
 
-	return &MetaReader{db: db, tempPath: tempPath}, nil
+	return &MetaReader{
+		db:       db,
+		dbPath:   dbPath,
+		tempPath: tempPath,
+		decoder:  DetectDecoder(db, dbPath),
+		openedAt: time.Now(),
+	}, nil
+}
+
+// Source returns the path, open time and transaction ID of the database.
+// The database is opened read-only, so every read sees this transaction.
+func (r *MetaReader) Source() Source {
+	source := Source{Path: r.dbPath, ReadAt: r.openedAt}
+	_ = r.db.View(func(tx *bolt.Tx) error {
+		source.TxID = tx.ID()
+		return nil
+	})
+	return source
+}
+
+// Locked reports whether the database was locked by another process and is
+// being read from a temporary copy
+func (r *MetaReader) Locked() bool {
+	return r.tempPath != ""
+}
+
+// Decoder returns the snapshotter schema decoder in use
+func (r *MetaReader) Decoder() SchemaDecoder {
+	return r.decoder
+}
+
+// SetDecoder overrides the auto-detected snapshotter schema decoder
+func (r *MetaReader) SetDecoder(name string) error {
+	decoder, err := GetDecoder(name)
+	if err != nil {
+		return err
+	}
+	r.decoder = decoder
+	return nil
+}
+
+// UseProfile decodes the database with the given schema profile
+func (r *MetaReader) UseProfile(profile *SchemaProfile) {
+	r.decoder = NewProfileDecoder(profile)
+}
+
+// SnapshotterName returns the name containerd registers the snapshotter
+// under in meta.db: the plugin directory holding the database
+// (io.containerd.snapshotter.v1.<name>), the plugin of a schema profile, or
+// else the name of the decoder. Several snapshotters share a decoder, e.g.
+// native and stargz databases are decoded as overlayfs.
+func (r *MetaReader) SnapshotterName() string {
+	if name, ok := strings.CutPrefix(filepath.Base(filepath.Dir(r.dbPath)), snapshotterPluginPrefix); ok && name != "" {
+		return name
+	}
+	if d, ok := r.decoder.(profileDecoder); ok && d.profile.Plugin != "" {
+		return strings.TrimPrefix(d.profile.Plugin, snapshotterPluginPrefix)
+	}
+	return r.decoder.Name()
 }
 
 // Close closes the database connection and cleans up temporary files
@@ -155,13 +228,18 @@ func (r *MetaReader) ListSnapshots() ([]SnapshotInfo, error) {
 			return fmt.Errorf("snapshots bucket not found")
 		}
 
+		decoder, err := r.txDecoder(tx)
+		if err != nil {
+			return err
+		}
+
 		return snapshotsBkt.ForEach(func(k, v []byte) error {
 			if v != nil { // skip non-buckets
 				return nil
 			}
 
 			sbkt := snapshotsBkt.Bucket(k)
-			info, err := r.readSnapshotInfo(string(k), sbkt)
+			info, err := r.readSnapshotInfo(decoder, string(k), sbkt)
 			if err != nil {
 				return fmt.Errorf("failed to read snapshot %s: %w", string(k), err)
 			}
@@ -194,7 +272,12 @@ func (r *MetaReader) GetSnapshot(key string) (*SnapshotInfo, error) {
 			return fmt.Errorf("snapshot %s not found", key)
 		}
 
-		snapshotInfo, err := r.readSnapshotInfo(key, sbkt)
+		decoder, err := r.txDecoder(tx)
+		if err != nil {
+			return err
+		}
+
+		snapshotInfo, err := r.readSnapshotInfo(decoder, key, sbkt)
 		if err != nil {
 			return err
 		}
@@ -209,14 +292,13 @@ func (r *MetaReader) GetSnapshot(key string) (*SnapshotInfo, error) {
 // ListDevboxStorage returns all devbox storage entries
 func (r *MetaReader) ListDevboxStorage() ([]DevboxStorageInfo, error) {
 	var storage []DevboxStorageInfo
+	decoder := r.storageDecoder()
 
 	err := r.db.View(func(tx *bolt.Tx) error {
-		v1Bkt := tx.Bucket(bucketKeyStorageVersion)
-		if v1Bkt == nil {
-			return fmt.Errorf("v1 bucket not found")
+		devboxBkt, err := storageBucket(tx, decoder)
+		if err != nil {
+			return err
 		}
-
-		devboxBkt := v1Bkt.Bucket(DevboxStoragePathBucket)
 		if devboxBkt == nil {
 			// Devbox bucket might not exist, return empty list
 			return nil
@@ -227,9 +309,8 @@ func (r *MetaReader) ListDevboxStorage() ([]DevboxStorageInfo, error) {
 				return nil
 			}
 
-			contentBkt := devboxBkt.Bucket(k)
-			info, err := r.readDevboxStorageInfo(string(k), contentBkt)
-			if err != nil {
+			info := DevboxStorageInfo{ContentID: string(k)}
+			if err := decoder.DecodeStorage(devboxBkt.Bucket(k), &info); err != nil {
 				return fmt.Errorf("failed to read devbox storage %s: %w", string(k), err)
 			}
 
@@ -244,14 +325,13 @@ func (r *MetaReader) ListDevboxStorage() ([]DevboxStorageInfo, error) {
 // GetDevboxStorage returns a specific devbox storage entry by content ID
 func (r *MetaReader) GetDevboxStorage(contentID string) (*DevboxStorageInfo, error) {
 	var info *DevboxStorageInfo
+	decoder := r.storageDecoder()
 
 	err := r.db.View(func(tx *bolt.Tx) error {
-		v1Bkt := tx.Bucket(bucketKeyStorageVersion)
-		if v1Bkt == nil {
-			return fmt.Errorf("v1 bucket not found")
+		devboxBkt, err := storageBucket(tx, decoder)
+		if err != nil {
+			return err
 		}
-
-		devboxBkt := v1Bkt.Bucket(DevboxStoragePathBucket)
 		if devboxBkt == nil {
 			return fmt.Errorf("devbox storage bucket not found")
 		}
@@ -261,8 +341,8 @@ func (r *MetaReader) GetDevboxStorage(contentID string) (*DevboxStorageInfo, err
 			return fmt.Errorf("devbox storage %s not found", contentID)
 		}
 
-		storageInfo, err := r.readDevboxStorageInfo(contentID, contentBkt)
-		if err != nil {
+		storageInfo := DevboxStorageInfo{ContentID: contentID}
+		if err := decoder.DecodeStorage(contentBkt, &storageInfo); err != nil {
 			return err
 		}
 
@@ -273,6 +353,28 @@ func (r *MetaReader) GetDevboxStorage(contentID string) (*DevboxStorageInfo, err
 	return info, err
 }
 
+// storageDecoder returns the decoder used for the devbox storage bucket,
+// falling back to the upstream devbox layout
+func (r *MetaReader) storageDecoder() StorageDecoder {
+	if decoder, ok := r.decoder.(StorageDecoder); ok {
+		return decoder
+	}
+	return NewProfileDecoder(DevboxProfile).(StorageDecoder)
+}
+
+// storageBucket resolves the decoder's storage bucket. A missing top-level
+// bucket is an error, a missing nested bucket is reported as nil.
+func storageBucket(tx *bolt.Tx, decoder StorageDecoder) (*bolt.Bucket, error) {
+	path := decoder.StorageBucket()
+	if len(path) == 0 {
+		return nil, fmt.Errorf("schema has no storage bucket")
+	}
+	if tx.Bucket(path[0]) == nil {
+		return nil, fmt.Errorf("%s bucket not found", string(path[0]))
+	}
+	return nestedBucket(tx, path), nil
+}
+
 // SearchSnapshots searches snapshots by content ID or path
 func (r *MetaReader) SearchSnapshots(contentID, path string) ([]SnapshotInfo, error) {
 	var results []SnapshotInfo
@@ -301,8 +403,22 @@ func (r *MetaReader) SearchSnapshots(contentID, path string) ([]SnapshotInfo, er
 	return results, nil
 }
 
-// readSnapshotInfo reads snapshot information from a bucket
-func (r *MetaReader) readSnapshotInfo(key string, bkt *bolt.Bucket) (SnapshotInfo, error) {
+// txDecoder returns the decoder to use for the snapshots of a transaction
+func (r *MetaReader) txDecoder(tx *bolt.Tx) (SchemaDecoder, error) {
+	decoder, ok := r.decoder.(TxDecoder)
+	if !ok {
+		return r.decoder, nil
+	}
+	txDecoder, err := decoder.ForTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s metadata: %w", r.decoder.Name(), err)
+	}
+	return txDecoder, nil
+}
+
+// readSnapshotInfo reads snapshot information from a bucket, decoding
+// snapshotter specific fields with decoder
+func (r *MetaReader) readSnapshotInfo(decoder SchemaDecoder, key string, bkt *bolt.Bucket) (SnapshotInfo, error) {
 	var info SnapshotInfo
 	info.Key = key
 
@@ -340,33 +456,9 @@ func (r *MetaReader) readSnapshotInfo(key string, bkt *bolt.Bucket) (SnapshotInf
 		info.Size = utils.ReadSize(sizeData)
 	}
 
-	// Read devbox specific fields
-	if contentIDData := bkt.Get(DevboxKeyContentID); contentIDData != nil {
-		info.ContentID = string(contentIDData)
-	}
-
-	if pathData := bkt.Get(DevboxKeyPath); pathData != nil {
-		info.Path = string(pathData)
-	}
-
-	return info, nil
-}
-
-// readDevboxStorageInfo reads devbox storage information from a bucket
-func (r *MetaReader) readDevboxStorageInfo(contentID string, bkt *bolt.Bucket) (DevboxStorageInfo, error) {
-	var info DevboxStorageInfo
-	info.ContentID = contentID
-
-	if lvNameData := bkt.Get(DevboxKeyLvName); lvNameData != nil {
-		info.LvName = string(lvNameData)
-	}
-
-	if pathData := bkt.Get(DevboxKeyPath); pathData != nil {
-		info.Path = string(pathData)
-	}
-
-	if statusData := bkt.Get(DevboxKeyStatus); statusData != nil {
-		info.Status = string(statusData)
+	// Read snapshotter specific fields
+	if err := decoder.DecodeSnapshot(filepath.Dir(r.dbPath), bkt, &info); err != nil {
+		return info, fmt.Errorf("failed to decode %s fields: %w", decoder.Name(), err)
 	}
 
 	return info, nil
@@ -199,6 +199,28 @@ func TestNewMetaReader(t *testing.T) {
 	})
 }
 
+func TestMetaReader_Source(t *testing.T) {
+	dbPath := setupTestDB(t)
+	before := time.Now()
+	reader, err := NewMetaReader(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	source := reader.Source()
+	if source.Path != dbPath {
+		t.Errorf("Expected Path = %s, got %s", dbPath, source.Path)
+	}
+	if source.ReadAt.Before(before) || source.ReadAt.After(time.Now()) {
+		t.Errorf("Expected ReadAt to be the time the reader was created, got %v", source.ReadAt)
+	}
+	// setupTestDB commits one update transaction
+	if source.TxID < 1 {
+		t.Errorf("Expected the ID of the committed transaction, got %d", source.TxID)
+	}
+}
+
 func TestMetaReader_ListBuckets(t *testing.T) {
 	dbPath := setupTestDB(t)
 	reader, err := NewMetaReader(dbPath)
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// SchemaDecoder decodes the snapshotter specific parts of a snapshotter
+// metadata database. Every snapshotter built on containerd's storage.MetaStore
+// shares the v1/snapshots layout; decoders add what differs on top of it.
+type SchemaDecoder interface {
+	// Name returns the snapshotter type, e.g. "overlayfs"
+	Name() string
+
+	// Detect reports whether the database at dbPath was written by this snapshotter
+	Detect(tx *bolt.Tx, dbPath string) bool
+
+	// DecodeSnapshot fills snapshotter specific fields of info from the
+	// snapshot bucket. root is the snapshotter root directory.
+	DecodeSnapshot(root string, bkt *bolt.Bucket, info *SnapshotInfo) error
+}
+
+// TxDecoder is implemented by decoders that read other buckets of the
+// database to decode snapshots. ForTx returns the decoder to use for every
+// snapshot of a transaction, so those buckets are read only once.
+type TxDecoder interface {
+	ForTx(tx *bolt.Tx) (SchemaDecoder, error)
+}
+
+// CommandDecoder is implemented by decoders whose snapshotter has command
+// groups of its own, such as devbox. Those commands refuse databases whose
+// decoder does not contribute their group.
+type CommandDecoder interface {
+	// CommandGroups returns the names of the top-level commands for this
+	// snapshotter, e.g. "devbox"
+	CommandGroups() []string
+}
+
+var (
+	decoders = make(map[string]SchemaDecoder)
+
+	// detectOrder lists decoders from the most to the least specific check
+	detectOrder []string
+
+	// fallbackDecoder is used when no decoder recognizes the database
+	fallbackDecoder = "overlayfs"
+)
+
+// RegisterDecoder adds a schema decoder to the registry. Decoders registered
+// earlier take precedence during auto-detection.
+func RegisterDecoder(decoder SchemaDecoder) {
+	name := decoder.Name()
+	if _, exists := decoders[name]; !exists {
+		detectOrder = append(detectOrder, name)
+	}
+	decoders[name] = decoder
+}
+
+// GetDecoder returns the schema decoder registered under name
+func GetDecoder(name string) (SchemaDecoder, error) {
+	decoder, ok := decoders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown snapshotter type %q (available: %v)", name, DecoderNames())
+	}
+	return decoder, nil
+}
+
+// DecoderNames returns the names of all registered schema decoders, sorted
+func DecoderNames() []string {
+	names := make([]string, 0, len(decoders))
+	for name := range decoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DetectDecoder inspects the database buckets, keys and path to pick a
+// schema decoder, falling back to overlayfs for plain MetaStore layouts
+func DetectDecoder(db *bolt.DB, dbPath string) SchemaDecoder {
+	var detected SchemaDecoder
+
+	_ = db.View(func(tx *bolt.Tx) error {
+		for _, name := range detectOrder {
+			if decoders[name].Detect(tx, dbPath) {
+				detected = decoders[name]
+				return nil
+			}
+		}
+		return nil
+	})
+
+	if detected == nil {
+		detected = decoders[fallbackDecoder]
+	}
+	return detected
+}
+
+// HasCommandGroup reports whether decoder contributes the named command group
+func HasCommandGroup(decoder SchemaDecoder, group string) bool {
+	commands, ok := decoder.(CommandDecoder)
+	if !ok {
+		return false
+	}
+	for _, name := range commands.CommandGroups() {
+		if name == group {
+			return true
+		}
+	}
+	return false
+}
+
+// CommandGroupDecoders returns the names of the registered decoders that
+// contribute the named command group, sorted
+func CommandGroupDecoders(group string) []string {
+	var names []string
+	for _, name := range DecoderNames() {
+		if HasCommandGroup(decoders[name], group) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
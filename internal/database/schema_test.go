@@ -0,0 +1,293 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/utils"
+	bolt "go.etcd.io/bbolt"
+)
+
+// setupPlainSnapshotDB creates a storage.MetaStore database without any
+// snapshotter specific buckets at dir/metadata.db
+func setupPlainSnapshotDB(t *testing.T, dir string) string {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	dbPath := filepath.Join(dir, "metadata.db")
+
+	db, err := bolt.Open(dbPath, 0600, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		v1Bkt, err := tx.CreateBucket(bucketKeyStorageVersion)
+		if err != nil {
+			return err
+		}
+		snapshotsBkt, err := v1Bkt.CreateBucket(bucketKeySnapshot)
+		if err != nil {
+			return err
+		}
+		if err := createTestSnapshot(snapshotsBkt, "default/1/layer", 1, snapshots.KindCommitted, "", "", ""); err != nil {
+			return err
+		}
+		return createTestSnapshot(snapshotsBkt, "default/2/rootfs", 2, snapshots.KindActive, "default/1/layer", "", "")
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup test data: %v", err)
+	}
+
+	return dbPath
+}
+
+func TestDetectDecoder(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T) string
+		expected string
+	}{
+		{
+			name:     "devbox storage bucket",
+			setup:    setupTestDB,
+			expected: "devbox",
+		},
+		{
+			name:     "devmapper devices bucket",
+			setup:    setupDevmapperDB,
+			expected: "devmapper",
+		},
+		{
+			name: "overlayfs plugin directory",
+			setup: func(t *testing.T) string {
+				return setupPlainSnapshotDB(t, filepath.Join(t.TempDir(), "io.containerd.snapshotter.v1.overlayfs"))
+			},
+			expected: "overlayfs",
+		},
+		{
+			name: "btrfs plugin directory",
+			setup: func(t *testing.T) string {
+				return setupPlainSnapshotDB(t, filepath.Join(t.TempDir(), "io.containerd.snapshotter.v1.btrfs"))
+			},
+			expected: "btrfs",
+		},
+		{
+			name: "native plugin directory",
+			setup: func(t *testing.T) string {
+				return setupPlainSnapshotDB(t, filepath.Join(t.TempDir(), "io.containerd.snapshotter.v1.native"))
+			},
+			expected: "native",
+		},
+		{
+			name: "unknown layout falls back to overlayfs",
+			setup: func(t *testing.T) string {
+				return setupPlainSnapshotDB(t, t.TempDir())
+			},
+			expected: "overlayfs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewMetaReader(tt.setup(t))
+			if err != nil {
+				t.Fatalf("Failed to create reader: %v", err)
+			}
+			defer reader.Close()
+
+			if got := reader.Decoder().Name(); got != tt.expected {
+				t.Errorf("Expected decoder %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMetaReader_SetDecoder(t *testing.T) {
+	reader, err := NewMetaReader(setupTestDB(t))
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	if err := reader.SetDecoder("unknown"); err == nil {
+		t.Error("Expected error for unknown snapshotter type")
+	}
+
+	if err := reader.SetDecoder("overlayfs"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// overlayfs does not know devbox keys
+	snapshot, err := reader.GetSnapshot("snapshot-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if snapshot.ContentID != "" {
+		t.Errorf("Expected no content ID with overlayfs decoder, got %s", snapshot.ContentID)
+	}
+	if snapshot.Extra["upperdir"] == "" {
+		t.Error("Expected overlayfs decoder to contribute upperdir")
+	}
+}
+
+func TestDecoderExtraFields(t *testing.T) {
+	t.Run("devbox joins storage entry", func(t *testing.T) {
+		reader, err := NewMetaReader(setupTestDB(t))
+		if err != nil {
+			t.Fatalf("Failed to create reader: %v", err)
+		}
+		defer reader.Close()
+
+		snapshot, err := reader.GetSnapshot("snapshot-1")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if snapshot.Extra["lv_name"] != "lv-volume-1" {
+			t.Errorf("Expected lv_name = lv-volume-1, got %s", snapshot.Extra["lv_name"])
+		}
+	})
+
+	t.Run("btrfs subvolume by kind", func(t *testing.T) {
+		root := filepath.Join(t.TempDir(), "io.containerd.snapshotter.v1.btrfs")
+		reader, err := NewMetaReader(setupPlainSnapshotDB(t, root))
+		if err != nil {
+			t.Fatalf("Failed to create reader: %v", err)
+		}
+		defer reader.Close()
+
+		committed, err := reader.GetSnapshot("default/1/layer")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if want := filepath.Join(root, "snapshots", "1"); committed.Extra["subvolume"] != want {
+			t.Errorf("Expected subvolume = %s, got %s", want, committed.Extra["subvolume"])
+		}
+
+		active, err := reader.GetSnapshot("default/2/rootfs")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if want := filepath.Join(root, "active", "2"); active.Extra["subvolume"] != want {
+			t.Errorf("Expected subvolume = %s, got %s", want, active.Extra["subvolume"])
+		}
+	})
+}
+
+func TestDecoderNames(t *testing.T) {
+	names := DecoderNames()
+	for _, expected := range []string{"btrfs", "devbox", "devmapper", "native", "overlayfs"} {
+		found := false
+		for _, name := range names {
+			if name == expected {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected decoder %s to be registered", expected)
+		}
+	}
+}
+
+func TestCommandGroups(t *testing.T) {
+	tests := []struct {
+		group    string
+		expected []string
+	}{
+		{group: "devbox", expected: []string{"devbox"}},
+		{group: "devmapper", expected: []string{"devmapper"}},
+		{group: "snapshots"},
+	}
+
+	for _, tt := range tests {
+		if names := CommandGroupDecoders(tt.group); !reflect.DeepEqual(names, tt.expected) {
+			t.Errorf("Expected %s commands from %v, got %v", tt.group, tt.expected, names)
+		}
+	}
+
+	custom := NewProfileDecoder(&SchemaProfile{Name: "fork"})
+	if !HasCommandGroup(custom, "devbox") {
+		t.Error("Expected a schema profile to contribute the devbox commands")
+	}
+	if HasCommandGroup(overlayDecoder{}, "devbox") {
+		t.Error("Expected overlayfs to contribute no commands")
+	}
+}
+
+func TestNewMetaReader_HostRoot(t *testing.T) {
+	hostRoot := t.TempDir()
+	setupPlainSnapshotDB(t, filepath.Join(hostRoot, "var/lib/containerd/io.containerd.snapshotter.v1.native"))
+
+	orig := utils.HostRoot
+	utils.HostRoot = hostRoot
+	defer func() { utils.HostRoot = orig }()
+
+	reader, err := NewMetaReader("/var/lib/containerd/io.containerd.snapshotter.v1.native/metadata.db")
+	if err != nil {
+		t.Fatalf("Failed to open database under host root: %v", err)
+	}
+	defer reader.Close()
+
+	if name := reader.Decoder().Name(); name != "native" {
+		t.Errorf("Expected native decoder from the host path, got %s", name)
+	}
+
+	// Paths derived from the database location stay host paths
+	snapshot, err := reader.GetSnapshot("default/1/layer")
+	if err != nil {
+		t.Fatalf("Failed to get snapshot: %v", err)
+	}
+	expected := "/var/lib/containerd/io.containerd.snapshotter.v1.native/snapshots/1"
+	if snapshot.Extra["dir"] != expected {
+		t.Errorf("Expected dir %s, got %s", expected, snapshot.Extra["dir"])
+	}
+}
+
+func TestMetaReader_SnapshotterName(t *testing.T) {
+	t.Run("plugin directory", func(t *testing.T) {
+		// stargz has no decoder of its own, its database is decoded as overlayfs
+		root := filepath.Join(t.TempDir(), "io.containerd.snapshotter.v1.stargz")
+		reader, err := NewMetaReader(setupPlainSnapshotDB(t, root))
+		if err != nil {
+			t.Fatalf("Failed to create reader: %v", err)
+		}
+		defer reader.Close()
+
+		if name := reader.Decoder().Name(); name != "overlayfs" {
+			t.Fatalf("Expected overlayfs decoder, got %s", name)
+		}
+		if name := reader.SnapshotterName(); name != "stargz" {
+			t.Errorf("Expected snapshotter stargz, got %s", name)
+		}
+	})
+
+	t.Run("profile plugin", func(t *testing.T) {
+		reader, err := NewMetaReader(setupForkDB(t, time.Now()))
+		if err != nil {
+			t.Fatalf("Failed to create reader: %v", err)
+		}
+		defer reader.Close()
+
+		reader.UseProfile(&SchemaProfile{Name: "devbox-fork", Plugin: snapshotterPluginPrefix + "devbox"})
+		if name := reader.SnapshotterName(); name != "devbox" {
+			t.Errorf("Expected snapshotter devbox, got %s", name)
+		}
+	})
+
+	t.Run("decoder name", func(t *testing.T) {
+		reader, err := NewMetaReader(setupTestDB(t))
+		if err != nil {
+			t.Fatalf("Failed to create reader: %v", err)
+		}
+		defer reader.Close()
+
+		if name := reader.SnapshotterName(); name != "devbox" {
+			t.Errorf("Expected snapshotter devbox, got %s", name)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package discover
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/containerd/meta-viewer/internal/utils"
+	"github.com/pelletier/go-toml"
+)
+
+const (
+	// DefaultConfigPath is where containerd reads its configuration from by default
+	DefaultConfigPath = "/etc/containerd/config.toml"
+
+	// DefaultRoot is containerd's default persistent data directory
+	DefaultRoot = "/var/lib/containerd"
+
+	// SnapshotterPluginPrefix is the plugin ID prefix of snapshotter plugins
+	SnapshotterPluginPrefix = "io.containerd.snapshotter.v1."
+)
+
+// criPluginPaths lists where the CRI plugin keeps its default snapshotter,
+// for config version 2 and version 3
+var criPluginPaths = [][]string{
+	{"io.containerd.grpc.v1.cri", "containerd", "snapshotter"},
+	{"io.containerd.cri.v1.images", "snapshotter"},
+}
+
+// Config is the subset of containerd's config.toml needed to locate snapshotter databases
+type Config struct {
+	Version int                               `toml:"version"`
+	Root    string                            `toml:"root"`
+	Imports []string                          `toml:"imports"`
+	Plugins map[string]map[string]interface{} `toml:"plugins"`
+
+	// Files lists the configuration files read, main file first
+	Files []string `toml:"-"`
+}
+
+// LoadConfig reads a containerd config.toml and the files it imports. A
+// missing main file yields containerd's defaults. Paths are host paths and
+// are resolved under utils.HostRoot.
+func LoadConfig(path string) (*Config, error) {
+	config := &Config{Plugins: make(map[string]map[string]interface{})}
+
+	if _, err := os.Stat(utils.HostPath(path)); os.IsNotExist(err) {
+		config.Root = DefaultRoot
+		return config, nil
+	}
+
+	if err := config.load(path, make(map[string]bool)); err != nil {
+		return nil, err
+	}
+
+	if config.Root == "" {
+		config.Root = DefaultRoot
+	}
+	return config, nil
+}
+
+// load parses path into c, then merges its imports on top. Imports are
+// resolved relative to the importing file and may be glob patterns.
+func (c *Config) load(path string, loaded map[string]bool) error {
+	if loaded[path] {
+		return nil
+	}
+	loaded[path] = true
+
+	data, err := os.ReadFile(utils.HostPath(path))
+	if err != nil {
+		return fmt.Errorf("failed to read containerd config: %w", err)
+	}
+
+	var file Config
+	if err := toml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse containerd config %s: %w", path, err)
+	}
+
+	c.merge(&file)
+	c.Files = append(c.Files, path)
+
+	for _, imp := range file.Imports {
+		if !filepath.IsAbs(imp) {
+			imp = filepath.Join(filepath.Dir(path), imp)
+		}
+
+		matches, err := filepath.Glob(utils.HostPath(imp))
+		if err != nil {
+			return fmt.Errorf("invalid import %s: %w", imp, err)
+		}
+		sort.Strings(matches)
+
+		for _, match := range matches {
+			if err := c.load(utils.DisplayPath(match), loaded); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// merge applies the settings of an imported file, which take precedence
+func (c *Config) merge(from *Config) {
+	if from.Version != 0 {
+		c.Version = from.Version
+	}
+	if from.Root != "" {
+		c.Root = from.Root
+	}
+	for id, section := range from.Plugins {
+		if c.Plugins[id] == nil {
+			c.Plugins[id] = make(map[string]interface{})
+		}
+		mergeMap(c.Plugins[id], section)
+	}
+}
+
+func mergeMap(to, from map[string]interface{}) {
+	for k, v := range from {
+		fromSub, ok := v.(map[string]interface{})
+		toSub, exists := to[k].(map[string]interface{})
+		if ok && exists {
+			mergeMap(toSub, fromSub)
+			continue
+		}
+		to[k] = v
+	}
+}
+
+// DefaultSnapshotter returns the snapshotter configured for the CRI plugin, if any
+func (c *Config) DefaultSnapshotter() string {
+	for _, path := range criPluginPaths {
+		var value interface{} = c.Plugins
+		for _, key := range path {
+			section, ok := asMap(value)
+			if !ok {
+				value = nil
+				break
+			}
+			value = section[key]
+		}
+		if name, ok := value.(string); ok && name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// SnapshotterRoots returns the root directory of every snapshotter plugin
+// configured with a plugin section, keyed by snapshotter name. Plugins
+// without a root_path live under <root>/<plugin ID>.
+func (c *Config) SnapshotterRoots() map[string]string {
+	roots := make(map[string]string)
+	for id, section := range c.Plugins {
+		if !strings.HasPrefix(id, SnapshotterPluginPrefix) {
+			continue
+		}
+
+		name := strings.TrimPrefix(id, SnapshotterPluginPrefix)
+		if rootPath, ok := section["root_path"].(string); ok && rootPath != "" {
+			roots[name] = rootPath
+		} else {
+			roots[name] = filepath.Join(c.Root, id)
+		}
+	}
+	return roots
+}
+
+// asMap accepts both plain maps and the map[string]map[string]interface{}
+// used for the top-level plugins table
+func asMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[string]map[string]interface{}:
+		out := make(map[string]interface{}, len(m))
+		for k, sub := range m {
+			out[k] = sub
+		}
+		return out, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,110 @@
+package discover
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestLoadConfig_Missing(t *testing.T) {
+	config, err := LoadConfig(filepath.Join(t.TempDir(), "config.toml"))
+	if err != nil {
+		t.Fatalf("Expected defaults for missing config, got error: %v", err)
+	}
+	if config.Root != DefaultRoot {
+		t.Errorf("Expected root %s, got %s", DefaultRoot, config.Root)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.toml")
+	writeFile(t, configPath, `
+version = 2
+root = "/data/containerd"
+imports = ["conf.d/*.toml"]
+
+[plugins."io.containerd.grpc.v1.cri".containerd]
+  snapshotter = "overlayfs"
+
+[plugins."io.containerd.snapshotter.v1.devmapper"]
+  root_path = "/data/devmapper"
+  pool_name = "containerd-pool"
+`)
+	writeFile(t, filepath.Join(dir, "conf.d", "10-devbox.toml"), `
+version = 2
+
+[plugins."io.containerd.grpc.v1.cri".containerd]
+  snapshotter = "devbox"
+
+[plugins."io.containerd.snapshotter.v1.devbox"]
+  enabled = true
+`)
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if config.Root != "/data/containerd" {
+		t.Errorf("Expected root /data/containerd, got %s", config.Root)
+	}
+	if len(config.Files) != 2 {
+		t.Errorf("Expected main file and one import, got %v", config.Files)
+	}
+
+	// Imports override the main file
+	if snapshotter := config.DefaultSnapshotter(); snapshotter != "devbox" {
+		t.Errorf("Expected default snapshotter devbox, got %s", snapshotter)
+	}
+
+	roots := config.SnapshotterRoots()
+	expected := map[string]string{
+		"devmapper": "/data/devmapper",
+		"devbox":    "/data/containerd/io.containerd.snapshotter.v1.devbox",
+	}
+	for name, root := range expected {
+		if roots[name] != root {
+			t.Errorf("Expected %s root %s, got %s", name, root, roots[name])
+		}
+	}
+}
+
+func TestLoadConfig_Version3(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	writeFile(t, configPath, `
+version = 3
+
+[plugins."io.containerd.cri.v1.images"]
+  snapshotter = "native"
+`)
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if snapshotter := config.DefaultSnapshotter(); snapshotter != "native" {
+		t.Errorf("Expected default snapshotter native, got %s", snapshotter)
+	}
+	if config.Root != DefaultRoot {
+		t.Errorf("Expected default root, got %s", config.Root)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	writeFile(t, configPath, `root = [`)
+
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Error("Expected error for malformed config")
+	}
+}
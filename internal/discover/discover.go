@@ -0,0 +1,82 @@
+package discover
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/utils"
+)
+
+// metadataDBName is the file name of a snapshotter's metadata database
+const metadataDBName = "metadata.db"
+
+// Database describes a snapshotter metadata database found on the node
+type Database struct {
+	Path        string `json:"path"`
+	Snapshotter string `json:"snapshotter"`      // Plugin name taken from the directory or config
+	Schema      string `json:"schema,omitempty"` // Snapshotter type detected from the database contents
+	Size        int64  `json:"size"`
+	Locked      bool   `json:"locked"`
+	Default     bool   `json:"default"` // Snapshotter used by the CRI plugin
+	Error       string `json:"error,omitempty"`
+}
+
+// Discover lists the snapshotter databases of the configured plugins and of
+// every io.containerd.snapshotter.v1.* directory under the containerd root.
+// Returned paths are host paths, even when utils.HostRoot is set.
+func Discover(config *Config) []Database {
+	candidates := make(map[string]string) // path -> snapshotter name
+
+	for name, root := range config.SnapshotterRoots() {
+		candidates[filepath.Join(root, metadataDBName)] = name
+	}
+
+	matches, _ := filepath.Glob(utils.HostPath(filepath.Join(config.Root, SnapshotterPluginPrefix+"*", metadataDBName)))
+	for _, match := range matches {
+		match = utils.DisplayPath(match)
+		if _, exists := candidates[match]; !exists {
+			candidates[match] = strings.TrimPrefix(filepath.Base(filepath.Dir(match)), SnapshotterPluginPrefix)
+		}
+	}
+
+	defaultSnapshotter := config.DefaultSnapshotter()
+
+	var databases []Database
+	for path, name := range candidates {
+		stat, err := os.Stat(utils.HostPath(path))
+		if err != nil {
+			// Configured plugins that never created a database are skipped
+			continue
+		}
+
+		db := Database{
+			Path:        path,
+			Snapshotter: name,
+			Size:        stat.Size(),
+			Default:     name == defaultSnapshotter,
+		}
+		inspect(&db)
+		databases = append(databases, db)
+	}
+
+	sort.Slice(databases, func(i, j int) bool {
+		return databases[i].Path < databases[j].Path
+	})
+	return databases
+}
+
+// inspect opens the database to detect its schema and lock state
+func inspect(db *Database) {
+	reader, err := database.NewMetaReader(db.Path)
+	if err != nil {
+		db.Error = err.Error()
+		return
+	}
+	defer reader.Close()
+
+	db.Locked = reader.Locked()
+	db.Schema = reader.Decoder().Name()
+}
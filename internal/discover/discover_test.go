@@ -0,0 +1,162 @@
+package discover
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containerd/meta-viewer/internal/utils"
+	bolt "go.etcd.io/bbolt"
+)
+
+func createDB(t *testing.T, path string, buckets ...string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		v1Bkt, err := tx.CreateBucketIfNotExists([]byte("v1"))
+		if err != nil {
+			return err
+		}
+		for _, name := range buckets {
+			if _, err := v1Bkt.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	poolRoot := filepath.Join(t.TempDir(), "devmapper")
+
+	overlayDB := filepath.Join(root, "io.containerd.snapshotter.v1.overlayfs", "metadata.db")
+	devboxDB := filepath.Join(root, "io.containerd.snapshotter.v1.devbox", "metadata.db")
+	devmapperDB := filepath.Join(poolRoot, "metadata.db")
+	createDB(t, overlayDB, "snapshots")
+	createDB(t, devboxDB, "snapshots", "devbox_storage_path")
+	createDB(t, devmapperDB, "snapshots")
+
+	config := &Config{
+		Root: root,
+		Plugins: map[string]map[string]interface{}{
+			"io.containerd.grpc.v1.cri":              {"containerd": map[string]interface{}{"snapshotter": "devbox"}},
+			"io.containerd.snapshotter.v1.devmapper": {"root_path": poolRoot},
+			"io.containerd.snapshotter.v1.native":    {}, // configured, but no database yet
+		},
+	}
+
+	databases := Discover(config)
+	if len(databases) != 3 {
+		t.Fatalf("Expected 3 databases, got %d: %+v", len(databases), databases)
+	}
+
+	byPath := make(map[string]Database)
+	for _, db := range databases {
+		byPath[db.Path] = db
+	}
+
+	tests := []struct {
+		path        string
+		snapshotter string
+		schema      string
+		isDefault   bool
+	}{
+		{overlayDB, "overlayfs", "overlayfs", false},
+		{devboxDB, "devbox", "devbox", true},
+		{devmapperDB, "devmapper", "overlayfs", false},
+	}
+
+	for _, tt := range tests {
+		db, ok := byPath[tt.path]
+		if !ok {
+			t.Errorf("Expected %s to be discovered", tt.path)
+			continue
+		}
+		if db.Snapshotter != tt.snapshotter {
+			t.Errorf("%s: expected snapshotter %s, got %s", tt.path, tt.snapshotter, db.Snapshotter)
+		}
+		if db.Schema != tt.schema {
+			t.Errorf("%s: expected schema %s, got %s", tt.path, tt.schema, db.Schema)
+		}
+		if db.Default != tt.isDefault {
+			t.Errorf("%s: expected default %t, got %t", tt.path, tt.isDefault, db.Default)
+		}
+		if db.Size == 0 {
+			t.Errorf("%s: expected non-zero size", tt.path)
+		}
+		if db.Locked {
+			t.Errorf("%s: expected database to be unlocked", tt.path)
+		}
+	}
+}
+
+func TestDiscover_Locked(t *testing.T) {
+	root := t.TempDir()
+	dbPath := filepath.Join(root, "io.containerd.snapshotter.v1.overlayfs", "metadata.db")
+	createDB(t, dbPath, "snapshots")
+
+	// Hold the write lock like a running snapshotter
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("Failed to lock database: %v", err)
+	}
+	defer db.Close()
+
+	databases := Discover(&Config{Root: root})
+	if len(databases) != 1 {
+		t.Fatalf("Expected 1 database, got %d", len(databases))
+	}
+	if !databases[0].Locked {
+		t.Error("Expected database to be reported as locked")
+	}
+}
+
+func TestDiscover_HostRoot(t *testing.T) {
+	hostRoot := t.TempDir()
+	writeFile(t, filepath.Join(hostRoot, "etc/containerd/config.toml"), `
+version = 2
+root = "/data/containerd"
+imports = ["/etc/containerd/conf.d/*.toml"]
+`)
+	writeFile(t, filepath.Join(hostRoot, "etc/containerd/conf.d/devbox.toml"), `
+[plugins."io.containerd.grpc.v1.cri".containerd]
+  snapshotter = "devbox"
+`)
+	createDB(t, filepath.Join(hostRoot, "data/containerd/io.containerd.snapshotter.v1.devbox/metadata.db"), "snapshots")
+
+	orig := utils.HostRoot
+	utils.HostRoot = hostRoot
+	defer func() { utils.HostRoot = orig }()
+
+	config, err := LoadConfig("/etc/containerd/config.toml")
+	if err != nil {
+		t.Fatalf("Failed to load config under host root: %v", err)
+	}
+	if len(config.Files) != 2 || config.Files[1] != "/etc/containerd/conf.d/devbox.toml" {
+		t.Errorf("Expected host paths for loaded files, got %v", config.Files)
+	}
+
+	databases := Discover(config)
+	if len(databases) != 1 {
+		t.Fatalf("Expected 1 database, got %d", len(databases))
+	}
+	expected := "/data/containerd/io.containerd.snapshotter.v1.devbox/metadata.db"
+	if databases[0].Path != expected {
+		t.Errorf("Expected host path %s, got %s", expected, databases[0].Path)
+	}
+	if !databases[0].Default || databases[0].Error != "" {
+		t.Errorf("Unexpected database entry: %+v", databases[0])
+	}
+}
@@ -0,0 +1,113 @@
+package du
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/containerd/continuity/fs"
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/utils"
+)
+
+// DefaultConcurrency is the default number of snapshots walked in parallel
+const DefaultConcurrency = 4
+
+// Usage measurement states
+const (
+	StateOK      = "ok"
+	StateMissing = "missing"
+	StateError   = "error"
+)
+
+// SnapshotUsage compares the usage recorded for a snapshot with its actual on-disk usage
+type SnapshotUsage struct {
+	Key            string `json:"key"`
+	ID             uint64 `json:"id"`
+	Kind           string `json:"kind"`
+	Dir            string `json:"dir"`
+	State          string `json:"state"`
+	Error          string `json:"error,omitempty"`
+	RecordedSize   int64  `json:"recorded_size"`
+	RecordedInodes int64  `json:"recorded_inodes"`
+	ActualSize     int64  `json:"actual_size"`
+	ActualInodes   int64  `json:"actual_inodes"`
+	SizeDrift      int64  `json:"size_drift"`   // Actual minus recorded
+	InodesDrift    int64  `json:"inodes_drift"` // Actual minus recorded
+}
+
+// SnapshotDir returns <root>/snapshots/<id>/fs, the directory holding a
+// snapshot's files for overlayfs style snapshotters
+func SnapshotDir(root string, id uint64) string {
+	return filepath.Join(root, "snapshots", strconv.FormatUint(id, 10), "fs")
+}
+
+// Measure walks the directory of every snapshot with at most concurrency
+// walks in parallel. root is a host path resolved under utils.HostRoot.
+// Hardlinked files are counted once, like containerd's usage calculation.
+func Measure(ctx context.Context, root string, infos []database.SnapshotInfo, concurrency int) []SnapshotUsage {
+	results := make([]SnapshotUsage, len(infos))
+	for i, info := range infos {
+		results[i] = SnapshotUsage{
+			Key:            info.Key,
+			ID:             info.ID,
+			Kind:           database.SnapshotKindString(info.Kind),
+			Dir:            SnapshotDir(root, info.ID),
+			RecordedSize:   info.Size,
+			RecordedInodes: info.Inodes,
+		}
+	}
+
+	parallel(len(results), concurrency, func(i int) {
+		measure(ctx, &results[i])
+	})
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	return results
+}
+
+func measure(ctx context.Context, usage *SnapshotUsage) {
+	dir := utils.HostPath(usage.Dir)
+	if _, err := os.Stat(dir); err != nil {
+		usage.State = StateMissing
+		return
+	}
+
+	actual, err := fs.DiskUsage(ctx, dir)
+	if err != nil {
+		usage.State = StateError
+		usage.Error = err.Error()
+		return
+	}
+
+	usage.State = StateOK
+	usage.ActualSize = actual.Size
+	usage.ActualInodes = actual.Inodes
+	usage.SizeDrift = actual.Size - usage.RecordedSize
+	usage.InodesDrift = actual.Inodes - usage.RecordedInodes
+}
+
+// parallel calls fn for every index below n, running at most concurrency calls at once
+func parallel(n, concurrency int, fn func(i int)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,101 @@
+package du
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/utils"
+)
+
+func writeSnapshotFile(t *testing.T, root string, id uint64, name string, size int) string {
+	t.Helper()
+	path := filepath.Join(SnapshotDir(root, id), name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	return path
+}
+
+func TestMeasure(t *testing.T) {
+	root := t.TempDir()
+
+	// Snapshot 1 holds a file and a hardlink to it, which must be counted once
+	file := writeSnapshotFile(t, root, 1, "data", 64*1024)
+	if err := os.Link(file, filepath.Join(filepath.Dir(file), "link")); err != nil {
+		t.Fatalf("Failed to create hardlink: %v", err)
+	}
+	single := writeSnapshotFile(t, root, 2, "data", 64*1024)
+
+	infos := []database.SnapshotInfo{
+		{Key: "missing", ID: 3, Kind: snapshots.KindActive},
+		{Key: "linked", ID: 1, Kind: snapshots.KindCommitted, Size: 4096, Inodes: 1},
+		{Key: "single", ID: 2, Kind: snapshots.KindActive},
+	}
+
+	usages := Measure(context.Background(), root, infos, 2)
+	if len(usages) != 3 {
+		t.Fatalf("Expected 3 usages, got %d", len(usages))
+	}
+	for i, usage := range usages {
+		if usage.ID != uint64(i+1) {
+			t.Errorf("Expected usages sorted by ID, got %d at %d", usage.ID, i)
+		}
+	}
+
+	linked, plain, missing := usages[0], usages[1], usages[2]
+	if linked.State != StateOK || plain.State != StateOK {
+		t.Fatalf("Expected existing snapshots to be measured, got %s and %s", linked.State, plain.State)
+	}
+	if linked.ActualSize != plain.ActualSize || linked.ActualInodes != plain.ActualInodes {
+		t.Errorf("Expected hardlink to be counted once: linked %d/%d, single %d/%d",
+			linked.ActualSize, linked.ActualInodes, plain.ActualSize, plain.ActualInodes)
+	}
+	if linked.SizeDrift != linked.ActualSize-4096 || linked.InodesDrift != linked.ActualInodes-1 {
+		t.Errorf("Unexpected drift %d/%d for %+v", linked.SizeDrift, linked.InodesDrift, linked)
+	}
+	if plain.Kind != "active" || linked.Kind != "committed" {
+		t.Errorf("Unexpected kinds %s and %s", plain.Kind, linked.Kind)
+	}
+	if plain.Dir != filepath.Dir(single) {
+		t.Errorf("Expected dir %s, got %s", filepath.Dir(single), plain.Dir)
+	}
+
+	if missing.State != StateMissing {
+		t.Errorf("Expected %s, got %s", StateMissing, missing.State)
+	}
+	if missing.ActualSize != 0 || missing.SizeDrift != 0 {
+		t.Errorf("Expected no usage for missing snapshot, got %+v", missing)
+	}
+}
+
+func TestMeasure_HostRoot(t *testing.T) {
+	hostRoot := t.TempDir()
+	writeSnapshotFile(t, filepath.Join(hostRoot, "var/lib/snap"), 7, "data", 1024)
+
+	old := utils.HostRoot
+	utils.HostRoot = hostRoot
+	defer func() { utils.HostRoot = old }()
+
+	infos := []database.SnapshotInfo{{Key: "k", ID: 7, Kind: snapshots.KindActive}}
+	usages := Measure(context.Background(), "/var/lib/snap", infos, 0)
+
+	if usages[0].State != StateOK {
+		t.Fatalf("Expected snapshot under host root to be measured, got %s", usages[0].State)
+	}
+	if usages[0].Dir != "/var/lib/snap/snapshots/7/fs" {
+		t.Errorf("Expected host path to be displayed, got %s", usages[0].Dir)
+	}
+}
+
+func TestMeasure_Empty(t *testing.T) {
+	if usages := Measure(context.Background(), t.TempDir(), nil, 4); len(usages) != 0 {
+		t.Errorf("Expected no usages, got %d", len(usages))
+	}
+}
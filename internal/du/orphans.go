@@ -0,0 +1,123 @@
+package du
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/containerd/continuity/fs"
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/utils"
+)
+
+// Orphan types
+const (
+	// OrphanDirectory is a snapshot directory no database entry references
+	OrphanDirectory = "orphan-dir"
+
+	// OrphanEntry is a database entry whose snapshot directory is gone
+	OrphanEntry = "missing-dir"
+)
+
+// Orphan is a snapshot directory or database entry without its counterpart.
+// Directories report their measured usage, entries the usage recorded in the
+// database.
+type Orphan struct {
+	Type   string `json:"type"`
+	ID     uint64 `json:"id"`
+	Key    string `json:"key,omitempty"`
+	Kind   string `json:"kind,omitempty"`
+	Dir    string `json:"dir"`
+	Size   int64  `json:"size"`
+	Inodes int64  `json:"inodes"`
+	Error  string `json:"error,omitempty"`
+}
+
+// FindOrphans compares the snapshot IDs in the database with the numeric
+// directories under <root>/snapshots. Orphan directories are measured with
+// at most concurrency walks in parallel. root is a host path resolved under
+// utils.HostRoot.
+func FindOrphans(ctx context.Context, root string, infos []database.SnapshotInfo, concurrency int) ([]Orphan, error) {
+	snapshotsDir := filepath.Join(root, "snapshots")
+	entries, err := os.ReadDir(utils.HostPath(snapshotsDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshots directory: %w", err)
+	}
+
+	dirs := make(map[uint64]bool)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		// Snapshotters stage new snapshots in non-numeric temporary directories
+		id, err := strconv.ParseUint(entry.Name(), 10, 64)
+		if err != nil {
+			continue
+		}
+		dirs[id] = true
+	}
+
+	known := make(map[uint64]bool)
+	orphans := []Orphan{}
+	for _, info := range infos {
+		known[info.ID] = true
+		if dirs[info.ID] {
+			continue
+		}
+		orphans = append(orphans, Orphan{
+			Type:   OrphanEntry,
+			ID:     info.ID,
+			Key:    info.Key,
+			Kind:   database.SnapshotKindString(info.Kind),
+			Dir:    filepath.Join(snapshotsDir, strconv.FormatUint(info.ID, 10)),
+			Size:   info.Size,
+			Inodes: info.Inodes,
+		})
+	}
+
+	measured := len(orphans)
+	for id := range dirs {
+		if known[id] {
+			continue
+		}
+		orphans = append(orphans, Orphan{
+			Type: OrphanDirectory,
+			ID:   id,
+			Dir:  filepath.Join(snapshotsDir, strconv.FormatUint(id, 10)),
+		})
+	}
+
+	// Measure the whole snapshot directory, as that is what removing it reclaims
+	parallel(len(orphans)-measured, concurrency, func(i int) {
+		orphan := &orphans[measured+i]
+		usage, err := fs.DiskUsage(ctx, utils.HostPath(orphan.Dir))
+		if err != nil {
+			orphan.Error = err.Error()
+			return
+		}
+		orphan.Size = usage.Size
+		orphan.Inodes = usage.Inodes
+	})
+
+	sort.Slice(orphans, func(i, j int) bool {
+		if orphans[i].Type != orphans[j].Type {
+			return orphans[i].Type == OrphanDirectory
+		}
+		return orphans[i].ID < orphans[j].ID
+	})
+	return orphans, nil
+}
+
+// ReclaimableSize sums the measured size of orphan directories
+func ReclaimableSize(orphans []Orphan) int64 {
+	var size int64
+	for _, orphan := range orphans {
+		if orphan.Type == OrphanDirectory {
+			size += orphan.Size
+		}
+	}
+	return size
+}
@@ -0,0 +1,63 @@
+package du
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+func TestFindOrphans(t *testing.T) {
+	root := t.TempDir()
+
+	writeSnapshotFile(t, root, 1, "data", 1024)
+	writeSnapshotFile(t, root, 5, "data", 64*1024)
+	writeSnapshotFile(t, root, 6, "data", 1024)
+	// Work directories outside fs count towards the reclaimable size
+	if err := os.MkdirAll(filepath.Join(root, "snapshots", "6", "work"), 0755); err != nil {
+		t.Fatalf("Failed to create work directory: %v", err)
+	}
+	// Temporary directories and plain files are ignored
+	if err := os.MkdirAll(filepath.Join(root, "snapshots", "new-123"), 0755); err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "snapshots", "7"), nil, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	infos := []database.SnapshotInfo{
+		{Key: "present", ID: 1, Kind: snapshots.KindCommitted},
+		{Key: "gone", ID: 2, Kind: snapshots.KindCommitted, Size: 8192, Inodes: 3},
+	}
+
+	orphans, err := FindOrphans(context.Background(), root, infos, 2)
+	if err != nil {
+		t.Fatalf("FindOrphans failed: %v", err)
+	}
+	if len(orphans) != 3 {
+		t.Fatalf("Expected 3 orphans, got %d: %+v", len(orphans), orphans)
+	}
+
+	if o := orphans[0]; o.Type != OrphanDirectory || o.ID != 5 || o.Size < 64*1024 || o.Inodes == 0 || o.Key != "" {
+		t.Errorf("Unexpected first orphan %+v", o)
+	}
+	if o := orphans[1]; o.Type != OrphanDirectory || o.ID != 6 || o.Dir != filepath.Join(root, "snapshots", "6") {
+		t.Errorf("Unexpected second orphan %+v", o)
+	}
+	if o := orphans[2]; o.Type != OrphanEntry || o.ID != 2 || o.Key != "gone" || o.Kind != "committed" || o.Size != 8192 || o.Inodes != 3 {
+		t.Errorf("Unexpected third orphan %+v", o)
+	}
+
+	if size := ReclaimableSize(orphans); size != orphans[0].Size+orphans[1].Size {
+		t.Errorf("Expected reclaimable size to sum orphan directories, got %d", size)
+	}
+}
+
+func TestFindOrphans_NoSnapshotsDir(t *testing.T) {
+	if _, err := FindOrphans(context.Background(), t.TempDir(), nil, 1); err == nil {
+		t.Error("Expected an error for a root without a snapshots directory")
+	}
+}
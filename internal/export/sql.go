@@ -0,0 +1,200 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+// Dump is the content of a snapshotter database exported for analysis
+type Dump struct {
+	Source    string
+	Buckets   []database.BucketInfo
+	Snapshots []database.SnapshotInfo
+	Storage   []database.DevboxStorageInfo
+}
+
+// schema creates the exported tables. Devbox storage comes first so that
+// snapshots can reference it; the types work in both SQLite and PostgreSQL.
+var schema = []string{
+	`CREATE TABLE buckets (
+  name TEXT PRIMARY KEY,
+  key_count INTEGER NOT NULL
+);`,
+	`CREATE TABLE devbox_storage (
+  content_id TEXT PRIMARY KEY,
+  lv_name TEXT,
+  path TEXT,
+  status TEXT
+);`,
+	`CREATE TABLE snapshots (
+  key TEXT PRIMARY KEY,
+  id BIGINT NOT NULL UNIQUE,
+  kind TEXT NOT NULL,
+  parent TEXT REFERENCES snapshots (key),
+  created_at TIMESTAMP WITH TIME ZONE,
+  updated_at TIMESTAMP WITH TIME ZONE,
+  inodes BIGINT NOT NULL,
+  size BIGINT NOT NULL,
+  content_id TEXT REFERENCES devbox_storage (content_id),
+  path TEXT
+);`,
+	`CREATE TABLE snapshot_labels (
+  snapshot_key TEXT NOT NULL REFERENCES snapshots (key),
+  name TEXT NOT NULL,
+  value TEXT NOT NULL,
+  PRIMARY KEY (snapshot_key, name)
+);`,
+}
+
+// tables lists the exported tables, referenced tables first
+var tables = []string{"buckets", "devbox_storage", "snapshots", "snapshot_labels"}
+
+// WriteSQL writes dump as a SQL script of CREATE TABLE and INSERT statements
+// in a single transaction. Snapshots are inserted parents first, so foreign
+// keys hold while loading. Parents and content IDs without a row of their
+// own are written as NULL, with a comment keeping the original value. With
+// dropTables, existing tables are dropped first.
+func WriteSQL(w io.Writer, dump Dump, dropTables bool) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintln(out, "-- containerd-meta-viewer SQL export")
+	if dump.Source != "" {
+		fmt.Fprintf(out, "-- Source: %s\n", commentText(dump.Source))
+	}
+	fmt.Fprintln(out, "BEGIN;")
+	fmt.Fprintln(out)
+
+	if dropTables {
+		for i := len(tables) - 1; i >= 0; i-- {
+			fmt.Fprintf(out, "DROP TABLE IF EXISTS %s;\n", tables[i])
+		}
+		fmt.Fprintln(out)
+	}
+	for _, statement := range schema {
+		fmt.Fprintln(out, statement)
+	}
+	fmt.Fprintln(out)
+
+	for _, bucket := range dump.Buckets {
+		fmt.Fprintf(out, "INSERT INTO buckets (name, key_count) VALUES (%s, %d);\n",
+			quote(bucket.Name), bucket.KeyCount)
+	}
+
+	contentIDs := make(map[string]bool, len(dump.Storage))
+	for _, item := range dump.Storage {
+		contentIDs[item.ContentID] = true
+		fmt.Fprintf(out, "INSERT INTO devbox_storage (content_id, lv_name, path, status) VALUES (%s, %s, %s, %s);\n",
+			quote(item.ContentID), nullable(item.LvName), nullable(item.Path), nullable(item.Status))
+	}
+
+	snapshots := parentsFirst(dump.Snapshots)
+	keys := make(map[string]bool, len(snapshots))
+	for _, snapshot := range snapshots {
+		keys[snapshot.Key] = true
+	}
+	for _, snapshot := range snapshots {
+		parent := nullable(snapshot.Parent)
+		if snapshot.Parent != "" && !keys[snapshot.Parent] {
+			fmt.Fprintf(out, "-- Parent of %s is missing: %s\n", commentText(snapshot.Key), commentText(snapshot.Parent))
+			parent = "NULL"
+		}
+		contentID := nullable(snapshot.ContentID)
+		if snapshot.ContentID != "" && !contentIDs[snapshot.ContentID] {
+			fmt.Fprintf(out, "-- Devbox storage of %s is missing: %s\n", commentText(snapshot.Key), commentText(snapshot.ContentID))
+			contentID = "NULL"
+		}
+
+		fmt.Fprintf(out, "INSERT INTO snapshots (key, id, kind, parent, created_at, updated_at, inodes, size, content_id, path) VALUES (%s, %d, %s, %s, %s, %s, %d, %d, %s, %s);\n",
+			quote(snapshot.Key),
+			snapshot.ID,
+			quote(database.SnapshotKindString(snapshot.Kind)),
+			parent,
+			timestamp(snapshot.CreatedAt),
+			timestamp(snapshot.UpdatedAt),
+			snapshot.Inodes,
+			snapshot.Size,
+			contentID,
+			nullable(snapshot.Path))
+	}
+
+	for _, snapshot := range snapshots {
+		names := make([]string, 0, len(snapshot.Labels))
+		for name := range snapshot.Labels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(out, "INSERT INTO snapshot_labels (snapshot_key, name, value) VALUES (%s, %s, %s);\n",
+				quote(snapshot.Key), quote(name), quote(snapshot.Labels[name]))
+		}
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "COMMIT;")
+	return out.Flush()
+}
+
+// parentsFirst orders snapshots by key, moving each parent before its children
+func parentsFirst(snapshots []database.SnapshotInfo) []database.SnapshotInfo {
+	byKey := make(map[string]database.SnapshotInfo, len(snapshots))
+	keys := make([]string, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		byKey[snapshot.Key] = snapshot
+		keys = append(keys, snapshot.Key)
+	}
+	sort.Strings(keys)
+
+	ordered := make([]database.SnapshotInfo, 0, len(snapshots))
+	visited := make(map[string]bool, len(snapshots))
+	var visit func(key string)
+	visit = func(key string) {
+		snapshot, ok := byKey[key]
+		if !ok || visited[key] {
+			return
+		}
+		// Mark before visiting the parent, so cycles end here
+		visited[key] = true
+		visit(snapshot.Parent)
+		ordered = append(ordered, snapshot)
+	}
+	for _, key := range keys {
+		visit(key)
+	}
+	return ordered
+}
+
+// quote returns s as a SQL string literal. Single quotes are doubled, which
+// is the only escape standard SQL strings need; NUL bytes, which PostgreSQL
+// does not accept in text, are dropped.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, "\x00", "")
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// nullable returns s as a SQL string literal, or NULL when it is empty
+func nullable(s string) string {
+	if s == "" {
+		return "NULL"
+	}
+	return quote(s)
+}
+
+// timestamp returns t as a UTC timestamp literal, or NULL when it is unset
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return "NULL"
+	}
+	return quote(t.UTC().Format("2006-01-02 15:04:05.999999Z07:00"))
+}
+
+// commentText makes s safe to place in a "--" comment
+func commentText(s string) string {
+	return strconv.Quote(s)
+}
@@ -0,0 +1,107 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+func TestWriteSQL(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.FixedZone("CST", 8*3600))
+	dump := Dump{
+		Source:  "/var/lib/db'\n.db",
+		Buckets: []database.BucketInfo{{Name: "v1", KeyCount: 2}},
+		Storage: []database.DevboxStorageInfo{{ContentID: "content-1", LvName: "lv-1", Status: "active"}},
+		Snapshots: []database.SnapshotInfo{
+			{Key: "container-1", ID: 3, Kind: snapshots.KindActive, Parent: "layer-1", CreatedAt: created,
+				ContentID: "content-1", Labels: map[string]string{"b": "it's", "a": "1"}},
+			{Key: "layer-1", ID: 1, Kind: snapshots.KindCommitted, Size: 1024},
+			{Key: "orphan", ID: 4, Kind: snapshots.KindView, Parent: "gone", ContentID: "content-9"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSQL(&buf, dump, false); err != nil {
+		t.Fatalf("WriteSQL failed: %v", err)
+	}
+	out := buf.String()
+
+	expected := []string{
+		"-- Source: \"/var/lib/db'\\n.db\"\n",
+		"INSERT INTO buckets (name, key_count) VALUES ('v1', 2);",
+		"INSERT INTO devbox_storage (content_id, lv_name, path, status) VALUES ('content-1', 'lv-1', NULL, 'active');",
+		"INSERT INTO snapshots (key, id, kind, parent, created_at, updated_at, inodes, size, content_id, path) VALUES ('container-1', 3, 'active', 'layer-1', '2024-01-01 19:04:05.6Z', NULL, 0, 0, 'content-1', NULL);",
+		"VALUES ('orphan', 4, 'view', NULL, NULL, NULL, 0, 0, NULL, NULL);",
+		"-- Parent of \"orphan\" is missing: \"gone\"",
+		"-- Devbox storage of \"orphan\" is missing: \"content-9\"",
+		"INSERT INTO snapshot_labels (snapshot_key, name, value) VALUES ('container-1', 'b', 'it''s');",
+		"COMMIT;",
+	}
+	for _, s := range expected {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected output to contain %q\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "DROP TABLE") {
+		t.Error("Expected no DROP TABLE statements without dropTables")
+	}
+
+	// Parents are inserted before their children
+	if strings.Index(out, "VALUES ('layer-1'") > strings.Index(out, "VALUES ('container-1'") {
+		t.Error("Expected layer-1 to be inserted before its child container-1")
+	}
+	if strings.Index(out, "'a', '1'") > strings.Index(out, "'b', 'it''s'") {
+		t.Error("Expected labels to be sorted by name")
+	}
+}
+
+func TestWriteSQL_DropTables(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSQL(&buf, Dump{}, true); err != nil {
+		t.Fatalf("WriteSQL failed: %v", err)
+	}
+
+	out := buf.String()
+	drop := strings.Index(out, "DROP TABLE IF EXISTS snapshot_labels;")
+	if drop < 0 || drop > strings.Index(out, "DROP TABLE IF EXISTS snapshots;") {
+		t.Errorf("Expected tables to be dropped, referencing tables first\n%s", out)
+	}
+	if strings.Index(out, "DROP TABLE") > strings.Index(out, "CREATE TABLE") {
+		t.Error("Expected tables to be dropped before they are created")
+	}
+}
+
+func TestParentsFirst(t *testing.T) {
+	infos := []database.SnapshotInfo{
+		{Key: "a", Parent: "c"},
+		{Key: "b"},
+		{Key: "c", Parent: "b"},
+		{Key: "d", Parent: "missing"},
+	}
+
+	var keys []string
+	for _, info := range parentsFirst(infos) {
+		keys = append(keys, info.Key)
+	}
+	if strings.Join(keys, ",") != "b,c,a,d" {
+		t.Errorf("Expected order b,c,a,d, got %v", keys)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":       "'plain'",
+		"it's":        "'it''s'",
+		`back\slash`:  `'back\slash'`,
+		"nul\x00byte": "'nulbyte'",
+	}
+	for input, expected := range tests {
+		if got := quote(input); got != expected {
+			t.Errorf("quote(%q) = %s, expected %s", input, got, expected)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package filter
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the layouts of times written as strings, e.g. "2024-01-02"
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+func (n *orNode) eval(fields Fields) (bool, error) {
+	left, err := n.left.eval(fields)
+	if err != nil || left {
+		return left, err
+	}
+	return n.right.eval(fields)
+}
+
+func (n *andNode) eval(fields Fields) (bool, error) {
+	left, err := n.left.eval(fields)
+	if err != nil || !left {
+		return false, err
+	}
+	return n.right.eval(fields)
+}
+
+func (n *notNode) eval(fields Fields) (bool, error) {
+	match, err := n.operand.eval(fields)
+	return !match, err
+}
+
+// eval compares the field of a record with the value, or tests that it
+// exists or is empty. Missing labels compare as empty strings.
+func (c *comparison) eval(fields Fields) (bool, error) {
+	v, ok := fields(c.field.name)
+	if ok && c.field.indexed {
+		m, isMap := v.(map[string]string)
+		if !isMap {
+			return false, &Error{Pos: c.field.pos, Msg: fmt.Sprintf("%s cannot be indexed, only fields holding labels can", c.field.name)}
+		}
+		v, ok = m[c.field.key]
+	}
+
+	switch c.op {
+	case "exists":
+		return ok && !isNil(v), nil
+	case "empty":
+		return !ok || isEmpty(v), nil
+	}
+	if !ok {
+		v = ""
+	}
+	if c.re != nil {
+		return c.re.MatchString(fmt.Sprint(v)) == (c.op == "=~"), nil
+	}
+
+	order, err := c.compare(v)
+	if err != nil {
+		return false, err
+	}
+	switch c.op {
+	case "==":
+		return order == 0, nil
+	case "!=":
+		return order != 0, nil
+	case "<":
+		return order < 0, nil
+	case "<=":
+		return order <= 0, nil
+	case ">":
+		return order > 0, nil
+	default:
+		return order >= 0, nil
+	}
+}
+
+// compare orders the field value v against the value of the comparison,
+// converting the value to the type of the field
+func (c *comparison) compare(v interface{}) (int, error) {
+	switch field := v.(type) {
+	case time.Time:
+		t, err := c.value.toTime(c.location)
+		if err != nil {
+			return 0, c.mismatch("a time", "a time such as now-7d or \"2024-01-02\"")
+		}
+		return field.Compare(t), nil
+
+	case time.Duration:
+		if c.value.kind != valueDuration {
+			return 0, c.mismatch("a duration", "a duration such as 7d or 12h")
+		}
+		return cmp.Compare(field, c.value.duration), nil
+
+	case string:
+		// Numbers, sizes and durations compare as written, e.g. 10GiB
+		if c.value.kind == valueTime {
+			return 0, c.mismatch("a string", "a string such as active or \"layer-1\"")
+		}
+		return strings.Compare(field, c.value.text), nil
+
+	case bool:
+		b, err := strconv.ParseBool(c.value.text)
+		if c.value.kind != valueString || err != nil {
+			return 0, c.mismatch("a boolean", "true or false")
+		}
+		return cmp.Compare(boolInt(field), boolInt(b)), nil
+
+	case map[string]string:
+		return 0, &Error{Pos: c.field.pos, Msg: fmt.Sprintf("%s holds labels, compare %s[\"name\"], or use exists or empty", c.field.name, c.field.name)}
+	}
+
+	number, ok := toNumber(v)
+	if !ok {
+		return 0, &Error{Pos: c.field.pos, Msg: fmt.Sprintf("%s cannot be compared", c.field)}
+	}
+	switch c.value.kind {
+	case valueNumber:
+		return cmp.Compare(number, c.value.number), nil
+	case valueString:
+		if parsed, err := strconv.ParseFloat(c.value.text, 64); err == nil {
+			return cmp.Compare(number, parsed), nil
+		}
+		if c.value.numberErr != nil {
+			return 0, c.value.numberErr
+		}
+	}
+	return 0, c.mismatch("a number", "a number or a size such as 10GiB")
+}
+
+// mismatch returns the error of a value that cannot be compared with the field
+func (c *comparison) mismatch(fieldType, expected string) error {
+	return &Error{Pos: c.value.pos, Msg: fmt.Sprintf("%s is %s, compare it with %s", c.field, fieldType, expected)}
+}
+
+// toTime returns the time of a now-relative value or of a string holding a
+// time, read in loc
+func (v value) toTime(loc *time.Location) (time.Time, error) {
+	switch v.kind {
+	case valueTime:
+		return v.time, nil
+	case valueString:
+		for _, layout := range timeLayouts {
+			if t, err := time.ParseInLocation(layout, v.text, loc); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("%s is not a time", v.text)
+}
+
+// toNumber converts integers and floats to float64
+func toNumber(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// isNil reports whether a value is unset: nil, or a nil pointer, map or list
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Ptr:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// isEmpty reports whether a value is unset or empty: nil, zero, or an empty
+// map or list
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice:
+		return rv.Len() == 0
+	case reflect.Ptr:
+		return rv.IsNil()
+	}
+	return rv.IsZero()
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
\ No newline at end of file
@@ -0,0 +1,442 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Fields returns the value of a field of the record an expression is
+// matched against, and false when the record has no such field
+type Fields func(name string) (interface{}, bool)
+
+// Expr is a parsed filter expression, such as
+//
+//	kind==active && size>10GiB && created_at<now-7d && labels["containerd.io/gc.root"] exists
+type Expr struct {
+	input string
+	root  node
+}
+
+// Error is an error in a filter expression, at a byte offset of the input
+type Error struct {
+	Input string
+	Pos   int
+	Msg   string
+}
+
+// Error describes the error and points at its position in the input
+func (e *Error) Error() string {
+	column := utf8.RuneCountInString(e.Input[:e.Pos])
+	return fmt.Sprintf("invalid filter at position %d: %s\n  %s\n  %s^",
+		column+1, e.Msg, e.Input, strings.Repeat(" ", column))
+}
+
+func errorf(input string, pos int, format string, args ...interface{}) *Error {
+	return &Error{Input: input, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Parse parses a filter expression. now is the time "now" refers to, and
+// times written as strings, such as "2024-01-02", are in its location.
+//
+// A filter combines comparisons with && (and), || (or), ! (not) and
+// parentheses. A comparison is a field, an operator and a value:
+//
+//   - ==, !=, <, <=, > and >= compare numbers, sizes (10GiB, 500MB), times
+//     (now-7d, "2024-01-02") and strings (active, "layer-1")
+//   - =~ and !~ match a regular expression
+//   - exists, without a value, holds when the field is set, e.g. a label
+//     set to "", and empty when it is unset, zero, "" or has no entries
+//
+// Fields are the JSON field names of the records; fields holding labels are
+// indexed by name, e.g. labels["containerd.io/gc.root"].
+func Parse(input string, now time.Time) (*Expr, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{input: input, tokens: tokens, now: now}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, errorf(input, t.pos, "unexpected %s, expected && or ||", t)
+	}
+	return &Expr{input: input, root: root}, nil
+}
+
+// String returns the expression as given to Parse
+func (e *Expr) String() string {
+	return e.input
+}
+
+// Check calls check with the name of every field the expression refers
+// to, returning its first error at the position of the field
+func (e *Expr) Check(check func(name string) error) error {
+	var err error
+	walk(e.root, func(c *comparison) {
+		if err == nil {
+			if checkErr := check(c.field.name); checkErr != nil {
+				err = errorf(e.input, c.field.pos, "%v", checkErr)
+			}
+		}
+	})
+	return err
+}
+
+// Match reports whether the record with the given fields matches the
+// expression. It fails when a value cannot be compared with a field, e.g.
+// a size with a string.
+func (e *Expr) Match(fields Fields) (bool, error) {
+	match, err := e.root.eval(fields)
+	if err != nil {
+		if evalErr, ok := err.(*Error); ok {
+			evalErr.Input = e.input
+		}
+		return false, err
+	}
+	return match, nil
+}
+
+// node is a node of the expression tree
+type node interface {
+	eval(fields Fields) (bool, error)
+}
+
+type orNode struct{ left, right node }
+
+type andNode struct{ left, right node }
+
+type notNode struct{ operand node }
+
+// comparison compares a field with a value, or tests that it exists or is
+// empty. Times written as strings are read in location.
+type comparison struct {
+	field    fieldRef
+	op       string
+	value    value
+	re       *regexp.Regexp
+	location *time.Location
+}
+
+// fieldRef names a field, or an entry of a map field such as labels["name"]
+type fieldRef struct {
+	name    string
+	key     string
+	indexed bool
+	pos     int
+}
+
+func (f fieldRef) String() string {
+	if f.indexed {
+		return fmt.Sprintf("%s[%q]", f.name, f.key)
+	}
+	return f.name
+}
+
+// Value kinds
+const (
+	valueString   = "string"
+	valueNumber   = "number"
+	valueDuration = "duration"
+	valueTime     = "time"
+)
+
+// value is the literal a field is compared with. text is the literal as
+// written, number holds numbers and sizes in bytes. numberErr is why a
+// string starting with a digit, such as 1abc, is not a number.
+type value struct {
+	kind      string
+	text      string
+	number    float64
+	duration  time.Duration
+	time      time.Time
+	pos       int
+	numberErr error
+}
+
+// walk calls fn with every comparison of the tree
+func walk(n node, fn func(c *comparison)) {
+	switch n := n.(type) {
+	case *orNode:
+		walk(n.left, fn)
+		walk(n.right, fn)
+	case *andNode:
+		walk(n.left, fn)
+		walk(n.right, fn)
+	case *notNode:
+		walk(n.operand, fn)
+	case *comparison:
+		fn(n)
+	}
+}
+
+// parser is a recursive descent parser of filter expressions
+type parser struct {
+	input  string
+	tokens []token
+	i      int
+	now    time.Time
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+	return t
+}
+
+// accept consumes the next token when it is the given operator
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenPunct && t.text == op {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return errorf(p.input, t.pos, format, args...)
+}
+
+// parseOr parses and-expressions joined by ||
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses unary expressions joined by &&
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left, right}
+	}
+	return left, nil
+}
+
+// parseUnary parses a negation, a parenthesized expression or a comparison
+func (p *parser) parseUnary() (node, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand}, nil
+	}
+
+	if t := p.peek(); p.accept("(") {
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.errorf(p.peek(), "unexpected %s, expected ) closing the ( at position %d", p.peek(), t.pos+1)
+		}
+		return n, nil
+	}
+	return p.parseComparison()
+}
+
+// comparisonOperators are the operators comparing a field with a value
+var comparisonOperators = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true, "=~": true, "!~": true,
+}
+
+// parseComparison parses a field followed by an operator and a value, or by
+// exists or empty
+func (p *parser) parseComparison() (node, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return nil, p.errorf(t, "unexpected %s, expected a field name", t)
+	}
+	field := fieldRef{name: t.text, pos: t.pos}
+	if p.accept("[") {
+		key := p.next()
+		if key.kind != tokenString {
+			return nil, p.errorf(key, "unexpected %s, expected a quoted key, e.g. %s[\"name\"]", key, t.text)
+		}
+		if !p.accept("]") {
+			return nil, p.errorf(p.peek(), "unexpected %s, expected ]", p.peek())
+		}
+		field.key, field.indexed = key.text, true
+	}
+
+	op := p.next()
+	if op.kind == tokenIdent && (op.text == "exists" || op.text == "empty") {
+		return &comparison{field: field, op: op.text}, nil
+	}
+	if op.kind != tokenPunct || !comparisonOperators[op.text] {
+		return nil, p.errorf(op, "unexpected %s after %s, expected a comparison operator, exists or empty", op, field)
+	}
+
+	v, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	c := &comparison{field: field, op: op.text, value: v, location: p.now.Location()}
+	if c.op == "=~" || c.op == "!~" {
+		if v.kind != valueString {
+			return nil, errorf(p.input, v.pos, "expected a regular expression after %s", c.op)
+		}
+		re, err := regexp.Compile(v.text)
+		if err != nil {
+			return nil, errorf(p.input, v.pos, "invalid regular expression: %v", err)
+		}
+		c.re = re
+	}
+	return c, nil
+}
+
+// parseValue parses a string, a bare word, a number, a size, a duration or
+// now, optionally plus or minus a duration
+func (p *parser) parseValue() (value, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return value{kind: valueString, text: t.text, pos: t.pos}, nil
+
+	case tokenIdent:
+		if t.text != "now" {
+			return value{kind: valueString, text: t.text, pos: t.pos}, nil
+		}
+		v := value{kind: valueTime, text: t.text, time: p.now, pos: t.pos}
+		for {
+			sign := time.Duration(1)
+			switch {
+			case p.accept("-"):
+				sign = -1
+			case p.accept("+"):
+			default:
+				return v, nil
+			}
+			d, err := p.parseNumber(p.next())
+			if err != nil {
+				return value{}, err
+			}
+			if d.kind != valueDuration {
+				return value{}, errorf(p.input, d.pos, "expected a duration such as 7d or 12h after now")
+			}
+			v.time = v.time.Add(sign * d.duration)
+		}
+
+	case tokenNumber:
+		// Bare words starting with a digit, such as content IDs, are strings
+		// unless they are numbers, sizes or durations
+		v, err := p.parseNumber(t)
+		if err != nil {
+			return value{kind: valueString, text: t.text, pos: t.pos, numberErr: err}, nil
+		}
+		return v, nil
+
+	case tokenPunct:
+		if t.text == "-" {
+			v, err := p.parseNumber(p.next())
+			if err != nil {
+				return value{}, err
+			}
+			if v.kind != valueNumber {
+				return value{}, errorf(p.input, v.pos, "expected a number after -")
+			}
+			v.number, v.text, v.pos = -v.number, "-"+v.text, t.pos
+			return v, nil
+		}
+	}
+	return value{}, p.errorf(t, "unexpected %s, expected a value", t)
+}
+
+// Size units, by lower case suffix
+var sizeUnits = map[string]float64{
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+}
+
+// Duration units
+var durationUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// numberPart matches a number and the unit that follows it
+var numberPart = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)([a-zA-Z]*)`)
+
+// parseNumber parses a plain number, a size such as 1.5GiB or a duration
+// such as 7d or 1h30m
+func (p *parser) parseNumber(t token) (value, error) {
+	if t.kind != tokenNumber {
+		return value{}, p.errorf(t, "unexpected %s, expected a number", t)
+	}
+
+	v := value{text: t.text, pos: t.pos}
+	for rest := t.text; rest != ""; {
+		m := numberPart.FindStringSubmatch(rest)
+		if m == nil {
+			return value{}, p.errorf(t, "invalid number %q", t.text)
+		}
+		number, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return value{}, p.errorf(t, "invalid number %q", t.text)
+		}
+		unit := m[2]
+		rest = rest[len(m[0]):]
+
+		switch d, isDuration := durationUnits[unit]; {
+		case unit == "" || sizeUnits[strings.ToLower(unit)] > 0:
+			if v.kind != "" || rest != "" {
+				return value{}, p.errorf(t, "invalid number %q", t.text)
+			}
+			v.kind = valueNumber
+			v.number = number
+			if unit != "" {
+				v.number = number * sizeUnits[strings.ToLower(unit)]
+			}
+		case isDuration:
+			if v.kind == valueNumber {
+				return value{}, p.errorf(t, "invalid number %q", t.text)
+			}
+			v.kind = valueDuration
+			v.duration += time.Duration(number * float64(d))
+		default:
+			return value{}, p.errorf(t, "unknown unit %q in %q, use a size unit (B, KiB, MiB, GiB, TiB, kB, MB, GB, TB) or a duration unit (s, m, h, d, w)", unit, t.text)
+		}
+	}
+	return v, nil
+}
\ No newline at end of file
@@ -0,0 +1,207 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+// testRecord is a snapshot like record used by the tests
+func testRecord() Fields {
+	record := map[string]interface{}{
+		"key":        "layer-2",
+		"kind":       "committed",
+		"parent":     "layer-1",
+		"size":       int64(20 << 30),
+		"inodes":     int64(100),
+		"id":         uint64(2),
+		"created_at": testNow.Add(-8 * 24 * time.Hour),
+		"age":        8 * 24 * time.Hour,
+		"labels":     map[string]string{"containerd.io/gc.root": "2024-01-01T00:00:00Z", "tier": "gold", "empty": ""},
+		"path":       "",
+		"content_id": "1abc",
+		"found":      true,
+	}
+	return func(name string) (interface{}, bool) {
+		v, ok := record[name]
+		return v, ok
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		filter   string
+		expected bool
+	}{
+		{`kind==committed`, true},
+		{`kind=="committed"`, true},
+		{`kind!=committed`, false},
+		{`size>10GiB`, true},
+		{`size>=20GiB && size<=20GiB`, true},
+		{`size<21474836480`, false},
+		{`size>21.5GB`, false},
+		{`inodes==100 && id>1`, true},
+		{`created_at<now-7d`, true},
+		{`created_at<now-1w-2d`, false},
+		{`age>7d && age<=1w1d`, true},
+		{`created_at>"2024-01-01" && created_at<"2024-01-02 12:00:00"`, true},
+		{`labels["containerd.io/gc.root"] exists`, true},
+		{`labels["missing"] exists`, false},
+		{`labels["missing"]==""`, true},
+		{`labels['tier']==gold`, true},
+		{`labels exists && !(labels empty) && parent exists && path exists && path empty`, true},
+		{`labels["empty"] exists && labels["empty"] empty`, true},
+		{`labels["missing"] empty && !(parent empty)`, true},
+		{`missing exists`, false},
+		{`key=~"^layer-[0-9]+$"`, true},
+		{`key!~'^layer'`, false},
+		{`found==true`, true},
+		{`kind==active || kind==committed && size>1TiB`, false},
+		{`(kind==active || kind==committed) && size>1GiB`, true},
+		{`kind==committed && size>10GiB && created_at<now-7d && labels["containerd.io/gc.root"] exists`, true},
+		{`size > -1`, true},
+		{`content_id==1abc && content_id!=1.2.3 && content_id>10GiB`, true},
+		{`key==1abc || parent=="1.2.3" || parent==7d`, false},
+		{`labels["tier"]!=10GiB && labels["tier"]>1abc`, true},
+	}
+
+	for _, tt := range tests {
+		expr, err := Parse(tt.filter, testNow)
+		if err != nil {
+			t.Errorf("Parse(%s) failed: %v", tt.filter, err)
+			continue
+		}
+		match, err := expr.Match(testRecord())
+		if err != nil {
+			t.Errorf("Match(%s) failed: %v", tt.filter, err)
+			continue
+		}
+		if match != tt.expected {
+			t.Errorf("Match(%s) = %t, expected %t", tt.filter, match, tt.expected)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		filter string
+		pos    int
+		msg    string
+	}{
+		{`kind==active & size>1`, 13, `use &&`},
+		{`kind=active`, 4, `compare with ==`},
+		{`(kind==active`, 13, `expected ) closing the ( at position 1`},
+		{`kind==active)`, 12, `expected && or ||`},
+		{`key==`, 5, `expected a value`},
+		{`key active`, 4, `expected a comparison operator, exists or empty`},
+		{`==active`, 0, `expected a field name`},
+		{`labels[tier]==gold`, 7, `expected a quoted key`},
+		{`key=~"("`, 5, `invalid regular expression`},
+		{`created_at<now-7`, 15, `expected a duration`},
+		{`key=="unterminated`, 5, `unterminated string`},
+		{`key==$`, 5, `unexpected character "$"`},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.filter, testNow)
+		filterErr, ok := err.(*Error)
+		if !ok {
+			t.Errorf("Parse(%s): expected an *Error, got %v", tt.filter, err)
+			continue
+		}
+		if filterErr.Pos != tt.pos || !strings.Contains(filterErr.Msg, tt.msg) {
+			t.Errorf("Parse(%s): expected %q at %d, got %q at %d", tt.filter, tt.msg, tt.pos, filterErr.Msg, filterErr.Pos)
+		}
+	}
+}
+
+func TestMatch_Location(t *testing.T) {
+	// created_at is 2024-01-02T00:00:00Z, 09:00 in UTC+9
+	filter := `created_at=="2024-01-02 09:00:00"`
+	for _, tt := range []struct {
+		now      time.Time
+		expected bool
+	}{
+		{testNow, false},
+		{testNow.In(time.FixedZone("UTC+9", 9*3600)), true},
+	} {
+		expr, err := Parse(filter, tt.now)
+		if err != nil {
+			t.Fatalf("Parse(%s) failed: %v", filter, err)
+		}
+		if match, err := expr.Match(testRecord()); err != nil || match != tt.expected {
+			t.Errorf("Match(%s) in %s = %t, %v, expected %t", filter, tt.now.Location(), match, err, tt.expected)
+		}
+	}
+}
+
+func TestMatch_Errors(t *testing.T) {
+	tests := []struct {
+		filter string
+		pos    int
+		msg    string
+	}{
+		{`size>active`, 5, `size is a number`},
+		{`created_at<7d`, 11, `created_at is a time`},
+		{`kind>now`, 5, `kind is a string`},
+		{`size>10XB`, 5, `unknown unit "XB"`},
+		{`size>10GiB5d`, 5, `invalid number`},
+		{`age>10GiB`, 4, `age is a duration`},
+		{`found==maybe`, 7, `found is a boolean`},
+		{`labels==gold`, 0, `labels holds labels`},
+		{`kind["a"]==b`, 0, `kind cannot be indexed`},
+	}
+
+	for _, tt := range tests {
+		expr, err := Parse(tt.filter, testNow)
+		if err != nil {
+			t.Errorf("Parse(%s) failed: %v", tt.filter, err)
+			continue
+		}
+		_, err = expr.Match(testRecord())
+		filterErr, ok := err.(*Error)
+		if !ok {
+			t.Errorf("Match(%s): expected an *Error, got %v", tt.filter, err)
+			continue
+		}
+		if filterErr.Pos != tt.pos || !strings.Contains(filterErr.Msg, tt.msg) {
+			t.Errorf("Match(%s): expected %q at %d, got %q at %d", tt.filter, tt.msg, tt.pos, filterErr.Msg, filterErr.Pos)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	_, err := Parse(`kind==active & size>1`, testNow)
+	expected := "invalid filter at position 14: unexpected '&', use &&\n" +
+		"  kind==active & size>1\n" +
+		"               ^"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%v", expected, err)
+	}
+
+	// Positions count characters, not bytes
+	_, err = Parse(`key=="é" &`, testNow)
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid filter at position 10:") {
+		t.Errorf("Expected an error at position 10, got %v", err)
+	}
+}
+
+func TestExpr_Check(t *testing.T) {
+	expr, err := Parse(`kind==active && (nope>1 || size>1)`, testNow)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	err = expr.Check(func(name string) error {
+		if name == "nope" {
+			return fmt.Errorf("unknown field 'nope'")
+		}
+		return nil
+	})
+	filterErr, ok := err.(*Error)
+	if !ok || filterErr.Pos != 17 || !strings.Contains(filterErr.Msg, "unknown field 'nope'") {
+		t.Errorf("Expected an unknown field error at 17, got %v", err)
+	}
+}
\ No newline at end of file
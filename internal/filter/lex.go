@@ -0,0 +1,115 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Token kinds
+const (
+	tokenEOF    = "end of filter"
+	tokenIdent  = "name"
+	tokenString = "string"
+	tokenNumber = "number"
+	tokenPunct  = "operator"
+)
+
+// token is a word, literal or operator of a filter expression. pos is its
+// byte offset in the input.
+type token struct {
+	kind string
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return t.kind
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// operators lists the operators, longest first so that "<=" is not read as "<"
+var operators = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "<", ">", "!", "(", ")", "[", "]", "+", "-"}
+
+// lex splits a filter expression into tokens, ending with an EOF token
+func lex(input string) ([]token, error) {
+	var tokens []token
+	for pos := 0; pos < len(input); {
+		c := rune(input[pos])
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			pos++
+
+		case c == '"' || c == '\'':
+			text, end, err := lexString(input, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: pos})
+			pos = end
+
+		case unicode.IsDigit(c):
+			// A number runs into its unit, e.g. 10GiB, 1.5d or 1h30m
+			end := pos
+			for end < len(input) && (isIdentChar(rune(input[end])) || input[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: input[pos:end], pos: pos})
+			pos = end
+
+		case isIdentChar(c):
+			end := pos
+			for end < len(input) && (isIdentChar(rune(input[end])) || input[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: input[pos:end], pos: pos})
+			pos = end
+
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(input[pos:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			switch {
+			case op != "":
+				tokens = append(tokens, token{kind: tokenPunct, text: op, pos: pos})
+				pos += len(op)
+			case c == '=':
+				return nil, errorf(input, pos, "unexpected \"=\", compare with ==")
+			case c == '&' || c == '|':
+				return nil, errorf(input, pos, "unexpected %q, use %c%c", c, c, c)
+			default:
+				return nil, errorf(input, pos, "unexpected character %q", input[pos:pos+1])
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(input)}), nil
+}
+
+// lexString reads a quoted string starting at pos, returning its text and
+// the offset after the closing quote. Backslash escapes the next character.
+func lexString(input string, pos int) (string, int, error) {
+	quote := input[pos]
+	var b strings.Builder
+	for i := pos + 1; i < len(input); i++ {
+		switch input[i] {
+		case quote:
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 < len(input) {
+				i++
+			}
+		}
+		b.WriteByte(input[i])
+	}
+	return "", 0, errorf(input, pos, "unterminated string")
+}
+
+func isIdentChar(c rune) bool {
+	return c == '_' || c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c))
+}
\ No newline at end of file
@@ -0,0 +1,42 @@
+package formatters
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+)
+
+// DelimitedFormatter formats output as comma or tab separated values with a header row
+type DelimitedFormatter struct {
+	writer    io.Writer
+	separator rune
+}
+
+// NewCSVFormatter creates a new comma separated values formatter
+func NewCSVFormatter() *DelimitedFormatter {
+	return &DelimitedFormatter{writer: os.Stdout, separator: ','}
+}
+
+// NewTSVFormatter creates a new tab separated values formatter
+func NewTSVFormatter() *DelimitedFormatter {
+	return &DelimitedFormatter{writer: os.Stdout, separator: '\t'}
+}
+
+// Format writes data flattened by Records, one row per item
+func (f *DelimitedFormatter) Format(data interface{}) error {
+	header, rows, err := Records(data)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(f.writer)
+	writer.Comma = f.separator
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write rows: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,380 @@
+package formatters
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/filter"
+)
+
+// LabelPrefix selects a single label as a field, e.g. label:containerd.io/gc.root
+const LabelPrefix = "label:"
+
+// labelsField is the JSON field holding the labels read by LabelPrefix fields
+const labelsField = "labels"
+
+// Fields computed from the struct fields of records, so that every output
+// format, --sort-by and --filter know them as well as table columns
+const (
+	// ageField is the time since created_at, in seconds in selected records
+	ageField = "age"
+	// kindValueField is the numeric snapshot kind
+	kindValueField = "kind_value"
+)
+
+// Field is a named value of a record reduced to selected fields
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Record is a record reduced to the fields selected by Options.Fields, in
+// the order they were selected
+type Record []Field
+
+// MarshalJSON encodes the record as an object keeping the field order
+func (r Record) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, field := range r {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, err := json.Marshal(field.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// SortRecords sorts a slice of structs in place by a field: a JSON field
+// name such as size or created_at, or label:<name>. The sort is stable and
+// records without the label come last, also when reversed.
+func SortRecords(data interface{}, name string, reverse bool) error {
+	value := reflect.ValueOf(data)
+	if value.Kind() != reflect.Slice {
+		return fmt.Errorf("cannot sort %T", data)
+	}
+	t, ok := recordType(value.Type().Elem())
+	if !ok {
+		return fmt.Errorf("cannot sort %T", data)
+	}
+	if err := checkField(t, name); err != nil {
+		return err
+	}
+	if field, ok := structField(t, name); ok && !sortable(field.Type) {
+		if name == labelsField {
+			return fmt.Errorf("cannot sort by %s, sort by %s<name> instead", name, LabelPrefix)
+		}
+		return fmt.Errorf("cannot sort by %s, it is not a single value", name)
+	}
+
+	sort.SliceStable(data, func(i, j int) bool {
+		a, aok := fieldValue(value.Index(i), name)
+		b, bok := fieldValue(value.Index(j), name)
+		if !aok || !bok {
+			return aok && !bok
+		}
+		if reverse {
+			return compareValues(b, a) < 0
+		}
+		return compareValues(a, b) < 0
+	})
+	return nil
+}
+
+// FilterRecords returns the elements of a slice of structs matching a
+// filter expression, as a slice of the same type. The fields the
+// expression refers to are JSON field names.
+func FilterRecords(data interface{}, expr *filter.Expr) (interface{}, error) {
+	value := reflect.ValueOf(data)
+	if value.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("cannot filter %T", data)
+	}
+	t, ok := recordType(value.Type().Elem())
+	if !ok {
+		return nil, fmt.Errorf("cannot filter %T", data)
+	}
+	err := expr.Check(func(name string) error {
+		if !hasField(t, name) {
+			_, names := jsonFields(t)
+			return unknownField(name, append(names, computedFields(t)...))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	matches := reflect.MakeSlice(value.Type(), 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		record := value.Index(i)
+		match, err := expr.Match(func(name string) (interface{}, bool) {
+			return fieldValue(record, name)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			matches = reflect.Append(matches, record)
+		}
+	}
+	return matches.Interface(), nil
+}
+
+// SelectFields reduces a struct, a pointer to a struct or a slice of structs
+// to the named fields, returning a Record or a []Record. Fields are JSON
+// field names or label:<name>; missing labels are nil.
+func SelectFields(data interface{}, names []string) (interface{}, error) {
+	value := reflect.ValueOf(data)
+	list := value.Kind() == reflect.Slice
+	elem := value.Type()
+	if list {
+		elem = elem.Elem()
+	}
+	t, ok := recordType(elem)
+	if !ok {
+		return nil, fmt.Errorf("cannot select fields of %T", data)
+	}
+	for _, name := range names {
+		if err := checkField(t, name); err != nil {
+			return nil, err
+		}
+	}
+
+	if !list {
+		return selectRecord(value, names), nil
+	}
+	records := make([]Record, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		records = append(records, selectRecord(value.Index(i), names))
+	}
+	return records, nil
+}
+
+// WithFields wraps the formatter of a record format, such as yaml or csv,
+// so that it formats records reduced to the selected fields. Factories
+// registered with Register use it to honour Options.Fields.
+func WithFields(f Formatter, names []string) Formatter {
+	if len(names) == 0 {
+		return f
+	}
+	return &fieldsFormatter{formatter: f, names: names}
+}
+
+// fieldsFormatter reduces records to the selected fields before formatting them
+type fieldsFormatter struct {
+	formatter Formatter
+	names     []string
+}
+
+func (f *fieldsFormatter) Format(data interface{}) error {
+	// Snapshots are reduced from their JSON encoding, like in json output,
+	// with kind_value so that it can be selected
+	if list, ok := data.([]database.SnapshotInfo); ok {
+		encoded := make([]database.SnapshotJSON, 0, len(list))
+		for _, snapshot := range list {
+			encoded = append(encoded, snapshot.JSON(true))
+		}
+		data = encoded
+	}
+	selected, err := SelectFields(data, f.names)
+	if err != nil {
+		return err
+	}
+	return f.formatter.Format(selected)
+}
+
+func selectRecord(v reflect.Value, names []string) Record {
+	record := make(Record, 0, len(names))
+	for _, name := range names {
+		value, ok := fieldValue(v, name)
+		if !ok {
+			value = nil
+		}
+		if age, ok := value.(time.Duration); ok {
+			value = int64(age / time.Second)
+		}
+		record = append(record, Field{Name: name, Value: value})
+	}
+	return record
+}
+
+// recordType returns the struct type of the records of a list, dereferencing pointers
+func recordType(t reflect.Type) (reflect.Type, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t, t.Kind() == reflect.Struct && t != timeType
+}
+
+// fieldNames returns the fields records of a struct type can be sorted by
+// and reduced to, and tables of them can show
+func fieldNames(t reflect.Type) []string {
+	_, names := jsonFields(t)
+	names = append(names, computedFields(t)...)
+	if _, ok := structField(t, labelsField); ok {
+		names = append(names, LabelPrefix+"<name>")
+	}
+	return names
+}
+
+// checkField fails when a struct type has no field of the given name
+func checkField(t reflect.Type, name string) error {
+	field := name
+	if strings.HasPrefix(name, LabelPrefix) {
+		field = labelsField
+	}
+	if !hasField(t, field) {
+		return unknownField(name, fieldNames(t))
+	}
+	return nil
+}
+
+// hasField reports whether a struct type has a JSON or computed field of the given name
+func hasField(t reflect.Type, name string) bool {
+	if _, ok := structField(t, name); ok {
+		return true
+	}
+	for _, computed := range computedFields(t) {
+		if computed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// computedFields returns the computed fields of a struct type: age when it
+// has a created_at time and kind_value when it has a snapshot kind. Types
+// holding a field of the same name, such as SnapshotJSON, keep their own.
+func computedFields(t reflect.Type) []string {
+	var names []string
+	if field, ok := structField(t, "created_at"); ok && field.Type == timeType {
+		if _, ok := structField(t, ageField); !ok {
+			names = append(names, ageField)
+		}
+	}
+	if field, ok := structField(t, "kind"); ok && field.Type == kindType {
+		if _, ok := structField(t, kindValueField); !ok {
+			names = append(names, kindValueField)
+		}
+	}
+	return names
+}
+
+func unknownField(name string, names []string) error {
+	return fmt.Errorf("unknown field '%s'. Use one of: %s", name, strings.Join(names, ", "))
+}
+
+// structField returns the field of a struct type with the given JSON name
+func structField(t reflect.Type, name string) (reflect.StructField, bool) {
+	fields, names := jsonFields(t)
+	for i, fieldName := range names {
+		if fieldName == name {
+			return fields[i], true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// fieldValue returns the value of a field of a record. Snapshot kinds are
+// returned by name. ok is false for a nil record or a missing label.
+func fieldValue(v reflect.Value, name string) (interface{}, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+
+	label, isLabel := strings.CutPrefix(name, LabelPrefix)
+	if isLabel {
+		name = labelsField
+	}
+	field, ok := structField(v.Type(), name)
+	if !ok {
+		return computedValue(v, name)
+	}
+	value := v.FieldByIndex(field.Index)
+
+	switch {
+	case isLabel:
+		labels, _ := value.Interface().(map[string]string)
+		text, ok := labels[label]
+		return text, ok
+	case value.Type() == kindType:
+		return database.SnapshotKindString(value.Interface().(snapshots.Kind)), true
+	}
+	return value.Interface(), true
+}
+
+// computedValue returns the value of a computed field of a record: the age
+// as a time.Duration and the snapshot kind as a number. ok is false for a
+// record without a creation time or a type without the field.
+func computedValue(v reflect.Value, name string) (interface{}, bool) {
+	if !hasField(v.Type(), name) {
+		return nil, false
+	}
+	switch name {
+	case ageField:
+		created, _ := fieldValue(v, "created_at")
+		if created.(time.Time).IsZero() {
+			return nil, false
+		}
+		return time.Since(created.(time.Time)), true
+	case kindValueField:
+		field, _ := structField(v.Type(), "kind")
+		return uint8(v.FieldByIndex(field.Index).Interface().(snapshots.Kind)), true
+	}
+	return nil, false
+}
+
+// sortable reports whether records can be ordered by values of a type
+func sortable(t reflect.Type) bool {
+	return t == timeType || t == kindType || isPlain(t)
+}
+
+// compareValues orders two values of the same field: times chronologically,
+// numbers numerically, false before true and strings lexically
+func compareValues(a, b interface{}) int {
+	if ta, ok := a.(time.Time); ok {
+		return ta.Compare(b.(time.Time))
+	}
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch va.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(va.Int(), vb.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(va.Uint(), vb.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(va.Float(), vb.Float())
+	case reflect.Bool:
+		return cmp.Compare(boolInt(va.Bool()), boolInt(vb.Bool()))
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
\ No newline at end of file
@@ -0,0 +1,233 @@
+package formatters
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/filter"
+)
+
+func fieldsTestSnapshots() []database.SnapshotInfo {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	return []database.SnapshotInfo{
+		{Key: "b", ID: 2, Kind: snapshots.KindCommitted, CreatedAt: now.Add(-48 * time.Hour), Size: 20 << 30,
+			Labels: map[string]string{"tier": "gold"}},
+		{Key: "a", ID: 1, Kind: snapshots.KindActive, CreatedAt: now.Add(-time.Hour), Size: 4096},
+		{Key: "c", ID: 3, Kind: snapshots.KindView, CreatedAt: now.Add(-240 * time.Hour), Size: 1 << 20,
+			Labels: map[string]string{"tier": "bronze"}},
+	}
+}
+
+func snapshotKeys(list []database.SnapshotInfo) string {
+	keys := make([]string, len(list))
+	for i, s := range list {
+		keys[i] = s.Key
+	}
+	return strings.Join(keys, ",")
+}
+
+func TestSortRecords(t *testing.T) {
+	tests := []struct {
+		field    string
+		reverse  bool
+		expected string
+	}{
+		{field: "key", expected: "a,b,c"},
+		{field: "id", reverse: true, expected: "c,b,a"},
+		{field: "size", expected: "a,c,b"},
+		{field: "size", reverse: true, expected: "b,c,a"},
+		{field: "created_at", expected: "c,b,a"},
+		{field: "kind", expected: "a,b,c"},
+		{field: "kind_value", expected: "c,a,b"},
+		{field: "age", expected: "a,b,c"},
+		{field: "age", reverse: true, expected: "c,b,a"},
+		// Snapshots without the label come last in both directions
+		{field: "label:tier", expected: "c,b,a"},
+		{field: "label:tier", reverse: true, expected: "b,c,a"},
+	}
+
+	for _, tt := range tests {
+		list := fieldsTestSnapshots()
+		if err := SortRecords(list, tt.field, tt.reverse); err != nil {
+			t.Errorf("SortRecords(%s) failed: %v", tt.field, err)
+			continue
+		}
+		if got := snapshotKeys(list); got != tt.expected {
+			t.Errorf("SortRecords(%s, reverse=%t) = %s, expected %s", tt.field, tt.reverse, got, tt.expected)
+		}
+	}
+
+	for _, field := range []string{"nope", "labels", "extra"} {
+		if err := SortRecords(fieldsTestSnapshots(), field, false); err == nil {
+			t.Errorf("Expected sorting by %s to fail", field)
+		}
+	}
+	if err := SortRecords([]database.BucketInfo{{Name: "a"}}, "label:a", false); err == nil {
+		t.Error("Expected sorting buckets by a label to fail")
+	}
+	if err := SortRecords(&database.BucketInfo{}, "name", false); err == nil {
+		t.Error("Expected sorting a single record to fail")
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	selected, err := SelectFields(fieldsTestSnapshots(), []string{"size", "key", "kind", "label:tier"})
+	if err != nil {
+		t.Fatalf("SelectFields failed: %v", err)
+	}
+	records, ok := selected.([]Record)
+	if !ok || len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %#v", selected)
+	}
+
+	data, err := json.Marshal(records[:2])
+	if err != nil {
+		t.Fatalf("Failed to marshal records: %v", err)
+	}
+	expected := `[{"size":21474836480,"key":"b","kind":"committed","label:tier":"gold"},` +
+		`{"size":4096,"key":"a","kind":"active","label:tier":null}]`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	header, rows, err := Records(records)
+	if err != nil {
+		t.Fatalf("Records failed: %v", err)
+	}
+	if !reflect.DeepEqual(header, []string{"size", "key", "kind", "label:tier"}) ||
+		!reflect.DeepEqual(rows[1], []string{"4096", "a", "active", ""}) {
+		t.Errorf("Unexpected records %v %v", header, rows)
+	}
+
+	item, err := SelectFields(&database.BucketInfo{Name: "v1", KeyCount: 2}, []string{"key_count"})
+	if err != nil {
+		t.Fatalf("SelectFields failed: %v", err)
+	}
+	if data, _ := json.Marshal(item); string(data) != `{"key_count":2}` {
+		t.Errorf("Expected a single record, got %s", data)
+	}
+
+	selected, err = SelectFields(fieldsTestSnapshots()[:1], []string{"kind_value", "age"})
+	if err != nil {
+		t.Fatalf("SelectFields failed: %v", err)
+	}
+	if record := selected.([]Record)[0]; record[0].Value != uint8(snapshots.KindCommitted) || record[1].Value.(int64) < 48*3600 {
+		t.Errorf("Expected the numeric kind and the age in seconds, got %v", record)
+	}
+
+	_, err = SelectFields([]database.DevboxStorageInfo{}, []string{"content_id", "size"})
+	if err == nil || !strings.Contains(err.Error(), "unknown field 'size'") || !strings.Contains(err.Error(), "lv_name") {
+		t.Errorf("Expected an unknown field error listing the fields, got %v", err)
+	}
+}
+
+func TestFormatters_Fields(t *testing.T) {
+	for _, format := range []string{"json", "yaml", "csv", "ndjson", "jsonpath={[*].key}"} {
+		var buf bytes.Buffer
+		f, err := New(format, Options{Writer: &buf, Fields: []string{"key", "size"}})
+		if err != nil {
+			t.Fatalf("New(%s) failed: %v", format, err)
+		}
+		if err := f.Format(fieldsTestSnapshots()); err != nil {
+			t.Errorf("%s Format failed: %v", format, err)
+			continue
+		}
+		if out := buf.String(); !strings.Contains(out, "b") || strings.Contains(out, "committed") {
+			t.Errorf("Expected %s output without the kind, got:\n%s", format, out)
+		}
+	}
+}
+
+func TestTableFormatter_Fields(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	f := newTestTableFormatter(TableOptions{}, &buf, now)
+	f.fields = []string{"key", "age", "label:tier", "size"}
+	if err := f.FormatSnapshots(fieldsTestSnapshots()); err != nil {
+		t.Fatalf("FormatSnapshots failed: %v", err)
+	}
+	expected := "KEY  AGE      TIER    SIZE\n" +
+		"b    2d ago   gold    20.0GiB\n" +
+		"a    1h ago   -       4.0KiB\n" +
+		"c    10d ago  bronze  1.0MiB\n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	f.fields = []string{"status", "nope"}
+	err := f.FormatDevboxStorage(nil)
+	if err == nil || !strings.Contains(err.Error(), "unknown field 'nope'") || strings.Contains(err.Error(), "label:") {
+		t.Errorf("Expected an unknown field error without labels, got %v", err)
+	}
+}
+
+// TestTableFormatter_FieldSets checks that list tables have a column for
+// every field the other formats and --sort-by accept
+func TestTableFormatter_FieldSets(t *testing.T) {
+	tests := []struct {
+		record interface{}
+		format func(f *TableFormatter) error
+	}{
+		{database.BucketInfo{}, func(f *TableFormatter) error { return f.FormatBuckets(nil) }},
+		{database.SnapshotInfo{}, func(f *TableFormatter) error { return f.FormatSnapshots(nil) }},
+		{database.DevboxStorageInfo{}, func(f *TableFormatter) error { return f.FormatDevboxStorage(nil) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(reflect.TypeOf(tt.record).Name(), func(t *testing.T) {
+			var buf bytes.Buffer
+			f := newTestTableFormatter(TableOptions{}, &buf, time.Now())
+			for _, name := range fieldNames(reflect.TypeOf(tt.record)) {
+				f.fields = []string{strings.Replace(name, "<name>", "a", 1)}
+				if err := tt.format(f); err != nil {
+					t.Errorf("Expected a column for %s, got %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterRecords(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		filter   string
+		expected string
+	}{
+		{`kind==committed || kind==view`, "b,c"},
+		{`size>=1MiB && created_at<now-1d`, "b,c"},
+		{`labels["tier"] exists && labels["tier"]!=gold`, "c"},
+		{`size>1TiB`, ""},
+		{`kind_value==1 && age>1d`, "c"},
+	}
+
+	for _, tt := range tests {
+		expr, err := filter.Parse(tt.filter, now)
+		if err != nil {
+			t.Fatalf("Parse(%s) failed: %v", tt.filter, err)
+		}
+		matches, err := FilterRecords(fieldsTestSnapshots(), expr)
+		if err != nil {
+			t.Errorf("FilterRecords(%s) failed: %v", tt.filter, err)
+			continue
+		}
+		list, ok := matches.([]database.SnapshotInfo)
+		if !ok {
+			t.Fatalf("Expected []database.SnapshotInfo, got %T", matches)
+		}
+		if got := snapshotKeys(list); got != tt.expected {
+			t.Errorf("FilterRecords(%s) = %s, expected %s", tt.filter, got, tt.expected)
+		}
+	}
+
+	expr, _ := filter.Parse(`status==active && size>1`, now)
+	_, err := FilterRecords([]database.DevboxStorageInfo{}, expr)
+	if err == nil || !strings.Contains(err.Error(), "position 19: unknown field 'size'") || strings.Contains(err.Error(), "label:") {
+		t.Errorf("Expected an unknown field error at position 19, got %v", err)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,191 @@
+package formatters
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+// Formatter writes the result of a command in one output format
+type Formatter interface {
+	// Format writes data, e.g. a []database.SnapshotInfo or a *gc.Report
+	Format(data interface{}) error
+}
+
+// Options configure the formatters created by New
+type Options struct {
+	// Writer receives the output. Nil means os.Stdout.
+	Writer io.Writer
+	// Pretty indents JSON output and adds the numeric snapshot kind
+	Pretty bool
+	// Source is the database the data was read from, recorded in the JSON envelope
+	Source *database.Source
+	// Table lays out table output
+	Table TableOptions
+	// Fields selects and orders the fields of list records, e.g. key,size or
+	// label:<name>. Empty means every field.
+	Fields []string
+}
+
+// Factory creates the formatter of an output format. arg is the text after
+// "=" of formats taking a template, e.g. {.key} in jsonpath={.key}.
+type Factory func(arg string, opts Options) (Formatter, error)
+
+// format is a registered output format
+type format struct {
+	name     string
+	template bool
+	factory  Factory
+}
+
+// registry holds the output formats in registration order
+var registry []format
+
+// Register adds an output format. Template formats take their argument after
+// "=", e.g. -o go-template=..., the others take none.
+func Register(name string, template bool, factory Factory) {
+	for _, f := range registry {
+		if f.name == name {
+			panic(fmt.Sprintf("output format %s registered twice", name))
+		}
+	}
+	registry = append(registry, format{name: name, template: template, factory: factory})
+}
+
+// Formats returns the names of the output formats taking no template
+func Formats() []string {
+	return formatNames(false)
+}
+
+// TemplateFormats returns the names of the output formats taking a template after "="
+func TemplateFormats() []string {
+	return formatNames(true)
+}
+
+func formatNames(template bool) []string {
+	var names []string
+	for _, f := range registry {
+		if f.template == template {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+// New creates the formatter for an output format value, e.g. "json" or
+// "jsonpath={.key}"
+func New(value string, opts Options) (Formatter, error) {
+	if opts.Writer == nil {
+		opts.Writer = os.Stdout
+	}
+
+	name, arg, hasArg := strings.Cut(value, "=")
+	for _, f := range registry {
+		if f.name != name {
+			continue
+		}
+		if f.template && !hasArg {
+			return nil, fmt.Errorf("output format '%s' needs a template, e.g. -o %s=...", name, name)
+		}
+		if !f.template && hasArg {
+			return nil, fmt.Errorf("output format '%s' does not take a template. Use one of: %s",
+				name, strings.Join(TemplateFormats(), ", "))
+		}
+		return f.factory(arg, opts)
+	}
+
+	if hasArg {
+		return nil, fmt.Errorf("output format '%s' does not take a template. Use one of: %s",
+			name, strings.Join(TemplateFormats(), ", "))
+	}
+	return nil, fmt.Errorf("invalid output format '%s'. Use one of: %s, or %s=...",
+		name, strings.Join(Formats(), ", "), strings.Join(TemplateFormats(), "=..., "))
+}
+
+// readTemplateFile returns a factory reading the template of a -file format
+// from the file named by its argument
+func readTemplateFile(name string, factory Factory) Factory {
+	return func(path string, opts Options) (Formatter, error) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		return factory(string(content), opts)
+	}
+}
+
+func init() {
+	Register("table", false, func(_ string, opts Options) (Formatter, error) {
+		f := NewTableFormatterWithOptions(opts.Table)
+		f.writer = opts.Writer
+		f.fields = opts.Fields
+		return f, nil
+	})
+	Register("json", false, func(_ string, opts Options) (Formatter, error) {
+		f := NewJSONFormatter(opts.Pretty)
+		f.writer = opts.Writer
+		f.fields = opts.Fields
+		if opts.Source != nil {
+			f.WithSource(*opts.Source)
+		}
+		return f, nil
+	})
+	Register("yaml", false, func(_ string, opts Options) (Formatter, error) {
+		return WithFields(&YAMLFormatter{writer: opts.Writer}, opts.Fields), nil
+	})
+	Register("csv", false, func(_ string, opts Options) (Formatter, error) {
+		return WithFields(&DelimitedFormatter{writer: opts.Writer, separator: ','}, opts.Fields), nil
+	})
+	Register("tsv", false, func(_ string, opts Options) (Formatter, error) {
+		return WithFields(&DelimitedFormatter{writer: opts.Writer, separator: '\t'}, opts.Fields), nil
+	})
+	Register("ndjson", false, func(_ string, opts Options) (Formatter, error) {
+		return WithFields(&NDJSONFormatter{writer: opts.Writer}, opts.Fields), nil
+	})
+	Register("name", false, func(_ string, opts Options) (Formatter, error) {
+		return WithFields(&NameFormatter{writer: opts.Writer}, opts.Fields), nil
+	})
+
+	goTemplate := func(text string, opts Options) (Formatter, error) {
+		f, err := NewGoTemplateFormatter(text)
+		if err != nil {
+			return nil, err
+		}
+		f.writer = opts.Writer
+		return WithFields(f, opts.Fields), nil
+	}
+	jsonPath := func(text string, opts Options) (Formatter, error) {
+		f, err := NewJSONPathFormatter(text)
+		if err != nil {
+			return nil, err
+		}
+		f.writer = opts.Writer
+		return WithFields(f, opts.Fields), nil
+	}
+	customColumns := func(spec string, opts Options) (Formatter, error) {
+		f, err := NewCustomColumnsFormatter(spec)
+		if err != nil {
+			return nil, err
+		}
+		f.writer = opts.Writer
+		return WithFields(f, opts.Fields), nil
+	}
+	customColumnsFile := func(content string, opts Options) (Formatter, error) {
+		f, err := NewCustomColumnsFileFormatter(content)
+		if err != nil {
+			return nil, err
+		}
+		f.writer = opts.Writer
+		return WithFields(f, opts.Fields), nil
+	}
+
+	Register("go-template", true, goTemplate)
+	Register("go-template-file", true, readTemplateFile("go-template-file", goTemplate))
+	Register("jsonpath", true, jsonPath)
+	Register("jsonpath-file", true, readTemplateFile("jsonpath-file", jsonPath))
+	Register("custom-columns", true, customColumns)
+	Register("custom-columns-file", true, readTemplateFile("custom-columns-file", customColumnsFile))
+}
\ No newline at end of file
@@ -0,0 +1,126 @@
+package formatters
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+func TestNew(t *testing.T) {
+	buckets := []database.BucketInfo{{Name: "v1", KeyCount: 3}}
+	expected := map[string]string{
+		"table":  "NAME  KEYS\nv1    3\n",
+		"json":   `{"apiVersion":"meta-viewer/v1","kind":"BucketList","items":[{"name":"v1","key_count":3}]}` + "\n",
+		"yaml":   "- name: v1\n  key_count: 3\n",
+		"csv":    "name,key_count\nv1,3\n",
+		"tsv":    "name\tkey_count\nv1\t3\n",
+		"ndjson": `{"name":"v1","key_count":3}` + "\n",
+		"name":   "v1\n",
+		"go-template={{range .}}{{.name}}{{end}}": "v1",
+		"jsonpath={[0].key_count}":                "3",
+		"custom-columns=BUCKET:.name":             "BUCKET\nv1\n",
+	}
+
+	for value, want := range expected {
+		var buf bytes.Buffer
+		formatter, err := New(value, Options{Writer: &buf})
+		if err != nil {
+			t.Errorf("New(%q) failed: %v", value, err)
+			continue
+		}
+		if err := formatter.Format(buckets); err != nil {
+			t.Errorf("Format with %q failed: %v", value, err)
+			continue
+		}
+		if buf.String() != want {
+			t.Errorf("Format with %q wrote %q, expected %q", value, buf.String(), want)
+		}
+	}
+
+	for _, value := range []string{"xml", "jsonpath", "yaml=x", "xml=x", "go-template={{.key", "jsonpath-file=/nonexistent"} {
+		if _, err := New(value, Options{}); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestFormats(t *testing.T) {
+	if formats := strings.Join(Formats(), ","); formats != "table,json,yaml,csv,tsv,ndjson,name" {
+		t.Errorf("Formats() = %s", formats)
+	}
+	if formats := strings.Join(TemplateFormats(), ","); !strings.HasPrefix(formats, "go-template,go-template-file,jsonpath") {
+		t.Errorf("TemplateFormats() = %s", formats)
+	}
+}
+
+// upperFormatter is a format registered by the test
+type upperFormatter struct {
+	opts Options
+}
+
+func (f *upperFormatter) Format(data interface{}) error {
+	_, err := f.opts.Writer.Write([]byte(strings.ToUpper(data.(string))))
+	return err
+}
+
+func TestRegister(t *testing.T) {
+	orig := registry
+	defer func() { registry = orig }()
+
+	Register("upper", false, func(_ string, opts Options) (Formatter, error) {
+		return &upperFormatter{opts: opts}, nil
+	})
+	var buf bytes.Buffer
+	formatter, err := New("upper", Options{Writer: &buf})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := formatter.Format("abc"); err != nil || buf.String() != "ABC" {
+		t.Errorf("Expected ABC, got %q (%v)", buf.String(), err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a format twice to panic")
+		}
+	}()
+	Register("json", false, nil)
+}
+
+func TestJSONFormatter_Format(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		kind string
+	}{
+		{"storage list", []database.DevboxStorageInfo{{ContentID: "c1"}}, "DevboxStorageList"},
+		{"storage item", &database.DevboxStorageInfo{ContentID: "c1"}, "DevboxStorage"},
+		{"snapshot", &database.SnapshotInfo{Key: "k"}, "Snapshot"},
+		{"lvm map", []LVMMapping{{LvName: "lv-1", Path: "/p"}}, "LVMMap"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			formatter, _ := New("json", Options{Writer: &buf})
+			if err := formatter.Format(tt.data); err != nil {
+				t.Fatalf("Format failed: %v", err)
+			}
+			var envelope map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &envelope); err != nil {
+				t.Fatalf("Failed to decode %s: %v", buf.String(), err)
+			}
+			if envelope["kind"] != tt.kind {
+				t.Errorf("Expected kind %s, got %v", tt.kind, envelope["kind"])
+			}
+		})
+	}
+
+	formatter, _ := New("json", Options{Writer: &bytes.Buffer{}})
+	if err := formatter.Format(42); err == nil {
+		t.Error("Expected an error for data without an output kind")
+	}
+}
\ No newline at end of file
@@ -3,72 +3,163 @@ package formatters
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
 
 	"github.com/containerd/meta-viewer/internal/database"
 )
 
+// APIVersion is the version of the JSON output envelope. It changes whenever
+// the envelope or the encoding of an output kind changes incompatibly.
+const APIVersion = "meta-viewer/v1"
+
+// Envelope wraps JSON output with its kind and the database state it was
+// read from. Lists are in Items, single objects in Item.
+type Envelope struct {
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Source     string      `json:"source,omitempty"`
+	ReadAt     *time.Time  `json:"readAt,omitempty"`
+	TxID       int         `json:"txid,omitempty"`
+	Items      interface{} `json:"items,omitempty"`
+	Item       interface{} `json:"item,omitempty"`
+}
+
 // JSONFormatter formats output as JSON
 type JSONFormatter struct {
 	pretty bool
+	source *database.Source
+	writer io.Writer
+	fields []string
 }
 
-// NewJSONFormatter creates a new JSON formatter
+// NewJSONFormatter creates a new JSON formatter. Pretty output is indented
+// and includes the numeric snapshot kind as kind_value.
 func NewJSONFormatter(pretty bool) *JSONFormatter {
-	return &JSONFormatter{pretty: pretty}
+	return &JSONFormatter{pretty: pretty, writer: os.Stdout}
+}
+
+// WithSource records the database the output was read from in the envelope
+func (f *JSONFormatter) WithSource(source database.Source) *JSONFormatter {
+	f.source = &source
+	return f
+}
+
+// Format formats the result of any command as JSON, in the envelope of the
+// output kind of its type
+func (f *JSONFormatter) Format(data interface{}) error {
+	kind, ok := kindOf(reflect.TypeOf(data))
+	if !ok {
+		return fmt.Errorf("json output is not supported for %T", data)
+	}
+	if kind.encode != nil {
+		data = kind.encode(f, data)
+	}
+	return f.toJSON(kind, data)
+}
+
+// withKindValue reports whether snapshots include kind_value: in pretty
+// output, and whenever fields are selected so that kind_value can be one
+func (f *JSONFormatter) withKindValue() bool {
+	return f.pretty || len(f.fields) > 0
 }
 
 // FormatBuckets formats bucket information as JSON
 func (f *JSONFormatter) FormatBuckets(buckets []database.BucketInfo) error {
-	return f.toJSON(buckets)
+	return f.Format(buckets)
 }
 
 // FormatSnapshots formats snapshot information as JSON
 func (f *JSONFormatter) FormatSnapshots(snapshots []database.SnapshotInfo) error {
-	return f.toJSON(snapshots)
+	return f.Format(snapshots)
 }
 
 // FormatSnapshot formats a single snapshot as JSON
 func (f *JSONFormatter) FormatSnapshot(snapshot *database.SnapshotInfo) error {
-	return f.toJSON(snapshot)
+	return f.Format(snapshot)
 }
 
 // FormatDevboxStorage formats devbox storage information as JSON
 func (f *JSONFormatter) FormatDevboxStorage(storage []database.DevboxStorageInfo) error {
-	return f.toJSON(storage)
+	return f.Format(storage)
 }
 
 // FormatDevboxStorageItem formats a single devbox storage item as JSON
 func (f *JSONFormatter) FormatDevboxStorageItem(item *database.DevboxStorageInfo) error {
-	return f.toJSON(item)
+	return f.Format(item)
 }
 
 // FormatLVMMap formats LVM mapping information as JSON
 func (f *JSONFormatter) FormatLVMMap(storage []database.DevboxStorageInfo) error {
-	// Filter and create LVM map
+	return f.Format(LVMMappings(storage))
+}
+
+// encodeSnapshots encodes snapshots with their kind by name
+func encodeSnapshots(f *JSONFormatter, data interface{}) interface{} {
+	snapshots := data.([]database.SnapshotInfo)
+	encoded := make([]database.SnapshotJSON, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		encoded = append(encoded, snapshot.JSON(f.withKindValue()))
+	}
+	return encoded
+}
+
+// encodeSnapshot encodes a single snapshot with its kind by name
+func encodeSnapshot(f *JSONFormatter, data interface{}) interface{} {
+	return data.(*database.SnapshotInfo).JSON(f.withKindValue())
+}
+
+// encodeLVMMap encodes LVM mappings as an object of paths by LV name
+func encodeLVMMap(_ *JSONFormatter, data interface{}) interface{} {
 	lvmMap := make(map[string]string)
-	for _, item := range storage {
-		if item.LvName != "" && item.Path != "" {
-			lvmMap[item.LvName] = item.Path
-		}
+	for _, mapping := range data.([]LVMMapping) {
+		lvmMap[mapping.LvName] = mapping.Path
 	}
-	return f.toJSON(lvmMap)
+	return lvmMap
 }
 
-// toJSON marshals data to JSON with optional pretty printing
-func (f *JSONFormatter) toJSON(data interface{}) error {
+// toJSON marshals data wrapped in the envelope of kind, with optional pretty
+// printing. Records are reduced to the selected fields, if any.
+func (f *JSONFormatter) toJSON(kind outputKind, data interface{}) error {
+	if len(f.fields) > 0 {
+		selected, err := SelectFields(data, f.fields)
+		if err != nil {
+			return err
+		}
+		data = selected
+	}
+
+	envelope := Envelope{APIVersion: APIVersion, Kind: kind.name}
+	if f.source != nil {
+		envelope.Source = f.source.Path
+		envelope.ReadAt = &f.source.ReadAt
+		envelope.TxID = f.source.TxID
+	}
+	if kind.list {
+		// Empty lists are encoded as [] rather than null
+		if v := reflect.ValueOf(data); v.Kind() == reflect.Slice && v.IsNil() {
+			data = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+		}
+		envelope.Items = data
+	} else {
+		envelope.Item = data
+	}
+
 	var output []byte
 	var err error
 
 	if f.pretty {
-		output, err = json.MarshalIndent(data, "", "  ")
+		output, err = json.MarshalIndent(envelope, "", "  ")
 	} else {
-		output, err = json.Marshal(data)
+		output, err = json.Marshal(envelope)
 	}
 
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 
-	fmt.Println(string(output))
-	return nil
+	_, err = fmt.Fprintln(f.writer, string(output))
+	return err
 }
\ No newline at end of file
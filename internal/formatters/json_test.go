@@ -337,7 +337,7 @@ func TestJSONFormatter_ErrorHandling(t *testing.T) {
 	// Test with invalid data (function that can't be marshaled)
 	invalidData := func() {} // functions can't be marshaled to JSON
 
-	err := formatter.toJSON(invalidData)
+	err := formatter.toJSON(kindSnapshot, invalidData)
 	if err == nil {
 		t.Error("Expected error when marshaling invalid data")
 	}
@@ -0,0 +1,364 @@
+package formatters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONPath is a parsed kubectl style JSONPath template such as
+// {range [*]}{.key}{"\t"}{.size}{"\n"}{end}. Text outside braces is printed
+// as is. Inside braces it supports field access (.name, with "\." for dots
+// in names, or ['name']), indexes ([0], [-1]), wildcards ([*] and .*),
+// string literals and range/end blocks. Filters, slices and recursive
+// descent are not supported.
+type JSONPath struct {
+	nodes []jsonPathNode
+}
+
+// jsonPathNode is literal text, a path whose values are printed, or a range
+// over the values of a path
+type jsonPathNode struct {
+	text  string
+	path  []pathStep
+	body  []jsonPathNode
+	block bool
+}
+
+type stepType int
+
+const (
+	stepField stepType = iota
+	stepIndex
+	stepWildcard
+)
+
+// pathStep is a single field, index or wildcard step of a path
+type pathStep struct {
+	typ   stepType
+	field string
+	index int
+}
+
+// ParseJSONPath parses a JSONPath template
+func ParseJSONPath(template string) (*JSONPath, error) {
+	nodes, _, err := parseJSONPathNodes(template, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONPath{nodes: nodes}, nil
+}
+
+// parseJSONPathNodes parses nodes from offset until the end of the template
+// or, inside a range, until the matching {end}. It returns the offset after
+// the last consumed action.
+func parseJSONPathNodes(template string, offset int, inRange bool) ([]jsonPathNode, int, error) {
+	var nodes []jsonPathNode
+	for offset < len(template) {
+		open := strings.IndexByte(template[offset:], '{')
+		if open < 0 {
+			nodes = append(nodes, jsonPathNode{text: template[offset:]})
+			offset = len(template)
+			break
+		}
+		if open > 0 {
+			nodes = append(nodes, jsonPathNode{text: template[offset : offset+open]})
+		}
+		start := offset + open
+		end, err := actionEnd(template, start)
+		if err != nil {
+			return nil, 0, err
+		}
+		action := strings.TrimSpace(template[start+1 : end])
+		next := end + 1
+
+		switch {
+		case action == "end":
+			if !inRange {
+				return nil, 0, fmt.Errorf("jsonpath: {end} without {range} at offset %d", start)
+			}
+			return nodes, next, nil
+		case action == "range" || strings.HasPrefix(action, "range "):
+			path, err := parsePath(strings.TrimSpace(strings.TrimPrefix(action, "range")))
+			if err != nil {
+				return nil, 0, fmt.Errorf("jsonpath: offset %d: %w", start, err)
+			}
+			body, after, err := parseJSONPathNodes(template, next, true)
+			if err != nil {
+				return nil, 0, err
+			}
+			nodes = append(nodes, jsonPathNode{path: path, body: body, block: true})
+			next = after
+		case strings.HasPrefix(action, `"`) || strings.HasPrefix(action, "'"):
+			text, err := unquote(action)
+			if err != nil {
+				return nil, 0, fmt.Errorf("jsonpath: offset %d: invalid string literal %s", start, action)
+			}
+			nodes = append(nodes, jsonPathNode{text: text})
+		default:
+			path, err := parsePath(action)
+			if err != nil {
+				return nil, 0, fmt.Errorf("jsonpath: offset %d: %w", start, err)
+			}
+			nodes = append(nodes, jsonPathNode{path: path})
+		}
+		offset = next
+	}
+
+	if inRange {
+		return nil, 0, fmt.Errorf("jsonpath: {range} has no {end}")
+	}
+	return nodes, offset, nil
+}
+
+// actionEnd returns the offset of the brace closing the action opened at start, skipping quoted strings
+func actionEnd(template string, start int) (int, error) {
+	var quote byte
+	for i := start + 1; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '}':
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("jsonpath: unclosed action at offset %d", start)
+}
+
+// unquote decodes a double or single quoted string literal
+func unquote(literal string) (string, error) {
+	if strings.HasPrefix(literal, "'") {
+		if len(literal) < 2 || !strings.HasSuffix(literal, "'") {
+			return "", fmt.Errorf("unterminated string")
+		}
+		inner := strings.ReplaceAll(literal[1:len(literal)-1], `\'`, `'`)
+		literal = `"` + strings.ReplaceAll(inner, `"`, `\"`) + `"`
+	}
+	return strconv.Unquote(literal)
+}
+
+// parsePath parses a path such as .labels.containerd\.io/gc\.root, [*].key or $['key']
+func parsePath(expr string) ([]pathStep, error) {
+	expr = strings.TrimPrefix(expr, "$")
+	expr = strings.TrimPrefix(expr, "@")
+
+	var steps []pathStep
+	for i := 0; i < len(expr); {
+		switch expr[i] {
+		case '.':
+			i++
+			if i < len(expr) && expr[i] == '.' {
+				return nil, fmt.Errorf("recursive descent is not supported")
+			}
+			if i >= len(expr) || expr[i] == '[' {
+				continue
+			}
+			if expr[i] == '*' {
+				steps = append(steps, pathStep{typ: stepWildcard})
+				i++
+				continue
+			}
+			var name strings.Builder
+			for i < len(expr) && expr[i] != '.' && expr[i] != '[' {
+				if expr[i] == '\\' && i+1 < len(expr) {
+					i++
+				}
+				name.WriteByte(expr[i])
+				i++
+			}
+			steps = append(steps, pathStep{typ: stepField, field: name.String()})
+		case '[':
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in %q", expr)
+			}
+			inner := strings.TrimSpace(expr[i+1 : i+end])
+			i += end + 1
+			switch {
+			case inner == "*":
+				steps = append(steps, pathStep{typ: stepWildcard})
+			case strings.HasPrefix(inner, "'") || strings.HasPrefix(inner, `"`):
+				field, err := unquote(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid key %s", inner)
+				}
+				steps = append(steps, pathStep{typ: stepField, field: field})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("unsupported subscript [%s]", inner)
+				}
+				steps = append(steps, pathStep{typ: stepIndex, index: index})
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q in %q", expr[i], expr)
+		}
+	}
+	return steps, nil
+}
+
+// evalPath returns the values a path selects from value. Missing fields and
+// out of range indexes select nothing.
+func evalPath(value interface{}, steps []pathStep) []interface{} {
+	current := []interface{}{value}
+	for _, step := range steps {
+		var next []interface{}
+		for _, v := range current {
+			switch typed := v.(type) {
+			case map[string]interface{}:
+				switch step.typ {
+				case stepField:
+					if field, ok := typed[step.field]; ok {
+						next = append(next, field)
+					}
+				case stepWildcard:
+					keys := make([]string, 0, len(typed))
+					for k := range typed {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						next = append(next, typed[k])
+					}
+				}
+			case []interface{}:
+				switch step.typ {
+				case stepIndex:
+					index := step.index
+					if index < 0 {
+						index += len(typed)
+					}
+					if index >= 0 && index < len(typed) {
+						next = append(next, typed[index])
+					}
+				case stepWildcard:
+					next = append(next, typed...)
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// envelopeFields are the fields of the JSON output envelope, which
+// templates do not see
+var envelopeFields = map[string]bool{
+	"apiVersion": true, "kind": true, "source": true, "readAt": true, "txid": true, "items": true, "item": true,
+}
+
+// Execute renders the template for data, which must be a JSON value as
+// decoded by toJSONValue
+func (p *JSONPath) Execute(data interface{}) (string, error) {
+	if err := checkRootFields(p.nodes, data); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := executeJSONPathNodes(&buf, p.nodes, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// checkRootFields fails for top-level paths that select a field of a list,
+// or an envelope field missing from an object. Templates are executed on
+// the items of the output rather than on the JSON envelope, so such paths,
+// e.g. {.items[*].key}, would otherwise silently print nothing.
+func checkRootFields(nodes []jsonPathNode, data interface{}) error {
+	for _, node := range nodes {
+		if len(node.path) == 0 || node.path[0].typ != stepField {
+			continue
+		}
+		field := node.path[0].field
+		switch typed := data.(type) {
+		case []interface{}:
+			return fmt.Errorf("jsonpath: cannot select field %q of a list; templates see the list of items, not the JSON envelope, use e.g. {[*].key} or {range [*]}{.key}{end}", field)
+		case map[string]interface{}:
+			if _, ok := typed[field]; !ok && envelopeFields[field] {
+				return fmt.Errorf("jsonpath: field %q not found; templates see the item itself, not the JSON envelope", field)
+			}
+		}
+	}
+	return nil
+}
+
+func executeJSONPathNodes(buf *bytes.Buffer, nodes []jsonPathNode, data interface{}) error {
+	for _, node := range nodes {
+		switch {
+		case node.block:
+			values := evalPath(data, node.path)
+			if len(values) == 1 {
+				if items, ok := values[0].([]interface{}); ok {
+					values = items
+				}
+			}
+			for _, value := range values {
+				if err := executeJSONPathNodes(buf, node.body, value); err != nil {
+					return err
+				}
+			}
+		case node.path != nil:
+			values := evalPath(data, node.path)
+			for i, value := range values {
+				if i > 0 {
+					buf.WriteByte(' ')
+				}
+				text, err := jsonValueString(value)
+				if err != nil {
+					return err
+				}
+				buf.WriteString(text)
+			}
+		default:
+			buf.WriteString(node.text)
+		}
+	}
+	return nil
+}
+
+// jsonValueString prints strings and numbers as is and anything else as compact JSON
+func jsonValueString(value interface{}) (string, error) {
+	switch typed := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return typed, nil
+	case json.Number:
+		return typed.String(), nil
+	case bool:
+		return strconv.FormatBool(typed), nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return string(data), nil
+}
+
+// toJSONValue converts data to the maps, slices and scalars of its JSON
+// encoding, so templates see the same field names as the JSON output
+func toJSONValue(data interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	return value, nil
+}
@@ -0,0 +1,144 @@
+package formatters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+func TestJSONPath_Execute(t *testing.T) {
+	data, err := toJSONValue([]database.SnapshotInfo{
+		{Key: "layer-1", ID: 1, Size: 1024, Labels: map[string]string{"containerd.io/gc.root": "yes"}},
+		{Key: "layer-2", ID: 2, Parent: "layer-1", Size: 18446744073709},
+	})
+	if err != nil {
+		t.Fatalf("toJSONValue failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{name: "wildcard", template: "{[*].key}", expected: "layer-1 layer-2"},
+		{name: "leading dot", template: "{.[*].id}", expected: "1 2"},
+		{name: "index", template: "{[1].parent}", expected: "layer-1"},
+		{name: "negative index", template: "{[-1].key}", expected: "layer-2"},
+		{name: "large numbers stay exact", template: "{[1].size}", expected: "18446744073709"},
+		{name: "escaped dots", template: `{[0].labels.containerd\.io/gc\.root}`, expected: "yes"},
+		{name: "bracket key", template: "{[0].labels['containerd.io/gc.root']}", expected: "yes"},
+		{name: "missing field", template: "{[1].labels}", expected: ""},
+		{name: "object as JSON", template: "{[0].labels}", expected: `{"containerd.io/gc.root":"yes"}`},
+		{name: "range", template: `{range [*]}{.key}{"\t"}{.size}{"\n"}{end}`, expected: "layer-1\t1024\nlayer-2\t18446744073709\n"},
+		{name: "range over a list", template: `{range .}[{.id}]{end}`, expected: "[1][2]"},
+		{name: "single quoted literal", template: `{range [*]}{.key}{'\n'}{end}`, expected: "layer-1\nlayer-2\n"},
+		{name: "text and braces in literals", template: `ids: {"{"}{[*].id}{"}"}`, expected: "ids: {1 2}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := ParseJSONPath(tt.template)
+			if err != nil {
+				t.Fatalf("ParseJSONPath failed: %v", err)
+			}
+			result, err := path.Execute(data)
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestParseJSONPath_Errors(t *testing.T) {
+	tests := []struct {
+		template string
+		message  string
+	}{
+		{template: "{.key", message: "unclosed action"},
+		{template: "{range [*]}{.key}", message: "has no {end}"},
+		{template: "{.key}{end}", message: "{end} without {range}"},
+		{template: "{..key}", message: "recursive descent"},
+		{template: "{[?(@.size>1)]}", message: "unsupported subscript"},
+		{template: "{[0}", message: "unclosed ["},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseJSONPath(tt.template)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("Expected error containing %q for %s, got %v", tt.message, tt.template, err)
+		}
+	}
+}
+
+func TestJSONPath_EnvelopeFields(t *testing.T) {
+	list, err := toJSONValue([]database.SnapshotInfo{{Key: "layer-1"}})
+	if err != nil {
+		t.Fatalf("toJSONValue failed: %v", err)
+	}
+	item, err := toJSONValue(database.DevboxStorageInfo{ContentID: "content-1"})
+	if err != nil {
+		t.Fatalf("toJSONValue failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		data     interface{}
+		message  string
+	}{
+		{name: "items of a list", template: "{.items[*].key}", data: list, message: `cannot select field "items" of a list`},
+		{name: "range over items", template: "{range .items[*]}{.key}{end}", data: list, message: "not the JSON envelope"},
+		{name: "field of a list", template: "{.key}", data: list, message: "use e.g. {[*].key}"},
+		{name: "item of an object", template: "{.item.content_id}", data: item, message: `field "item" not found`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := ParseJSONPath(tt.template)
+			if err != nil {
+				t.Fatalf("ParseJSONPath failed: %v", err)
+			}
+			if _, err := path.Execute(tt.data); err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+
+	// Missing fields of an item other than envelope fields print nothing
+	path, err := ParseJSONPath("{.extra}")
+	if err != nil {
+		t.Fatalf("ParseJSONPath failed: %v", err)
+	}
+	if result, err := path.Execute(item); err != nil || result != "" {
+		t.Errorf("Expected no output for a missing field, got %q, %v", result, err)
+	}
+}
+
+func TestNewCustomColumnsFormatter(t *testing.T) {
+	formatter, err := NewCustomColumnsFormatter("KEY:.key,LV:{.lv_name}")
+	if err != nil {
+		t.Fatalf("NewCustomColumnsFormatter failed: %v", err)
+	}
+	if len(formatter.columns) != 2 || formatter.columns[1].header != "LV" {
+		t.Errorf("Expected KEY and LV columns, got %+v", formatter.columns)
+	}
+
+	if _, err := NewCustomColumnsFormatter("KEY"); err == nil {
+		t.Error("Expected an error for a column without a path")
+	}
+	if _, err := NewCustomColumnsFileFormatter("KEY SIZE\n.key\n"); err == nil {
+		t.Error("Expected an error for a columns file with fewer paths than headers")
+	}
+
+	formatter, err = NewCustomColumnsFileFormatter("KEY   SIZE\n.key  .size\n")
+	if err != nil {
+		t.Fatalf("NewCustomColumnsFileFormatter failed: %v", err)
+	}
+	if len(formatter.columns) != 2 || formatter.columns[1].header != "SIZE" {
+		t.Errorf("Expected KEY and SIZE columns, got %+v", formatter.columns)
+	}
+}
@@ -0,0 +1,51 @@
+package formatters
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// nameColumns are the columns identifying a record, in order of preference
+var nameColumns = []string{"key", "content_id", "lv_name", "name"}
+
+// NameFormatter formats output as the name of each record, one per line:
+// snapshot keys, content IDs, LV names or bucket names
+type NameFormatter struct {
+	writer io.Writer
+}
+
+// NewNameFormatter creates a new name-only formatter
+func NewNameFormatter() *NameFormatter {
+	return &NameFormatter{writer: os.Stdout}
+}
+
+// Format writes the first non-empty identifying column of each record
+func (f *NameFormatter) Format(data interface{}) error {
+	header, rows, err := Records(data)
+	if err != nil {
+		return err
+	}
+
+	var columns []int
+	for _, name := range nameColumns {
+		for i, column := range header {
+			if column == name {
+				columns = append(columns, i)
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("name output is not supported for %T", data)
+	}
+
+	for _, row := range rows {
+		for _, column := range columns {
+			if row[column] != "" {
+				fmt.Fprintln(f.writer, row[column])
+				break
+			}
+		}
+	}
+	return nil
+}
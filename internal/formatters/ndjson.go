@@ -0,0 +1,42 @@
+package formatters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+)
+
+// NDJSONFormatter formats output as newline delimited JSON, one record per line
+type NDJSONFormatter struct {
+	writer io.Writer
+}
+
+// NewNDJSONFormatter creates a new newline delimited JSON formatter
+func NewNDJSONFormatter() *NDJSONFormatter {
+	return &NDJSONFormatter{writer: os.Stdout}
+}
+
+// Format writes each element of a slice on its own line, or any other value as a single line
+func (f *NDJSONFormatter) Format(data interface{}) error {
+	encoder := json.NewEncoder(f.writer)
+	value := reflect.ValueOf(data)
+	if value.Kind() != reflect.Slice {
+		return encode(encoder, data)
+	}
+
+	for i := 0; i < value.Len(); i++ {
+		if err := encode(encoder, value.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encode(encoder *json.Encoder, record interface{}) error {
+	if err := encoder.Encode(record); err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package formatters
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+// LVMMapping is a single LV name to mount path entry of the LVM map
+type LVMMapping struct {
+	LvName string `json:"lv_name"`
+	Path   string `json:"path"`
+}
+
+// LVMMappings returns the storage entries that have both an LV name and a path
+func LVMMappings(storage []database.DevboxStorageInfo) []LVMMapping {
+	mappings := make([]LVMMapping, 0, len(storage))
+	for _, item := range storage {
+		if item.LvName != "" && item.Path != "" {
+			mappings = append(mappings, LVMMapping{LvName: item.LvName, Path: item.Path})
+		}
+	}
+	return mappings
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	kindType = reflect.TypeOf(snapshots.Kind(0))
+)
+
+// Records flattens a struct, a pointer to a struct or a slice of structs into
+// a header and rows for the tabular output formats. Columns are named after
+// the JSON fields, nested structs become "<field>.<subfield>" columns and
+// lists of plain values are joined by commas. Label style maps, whose keys
+// and values may hold any character, and anything more deeply nested are
+// written as compact JSON, e.g. {"a":"1","b":"x,y=z"}; empty maps are empty.
+func Records(data interface{}) ([]string, [][]string, error) {
+	switch v := data.(type) {
+	case Record:
+		return selectedRecords([]Record{v})
+	case []Record:
+		return selectedRecords(v)
+	}
+
+	value := reflect.ValueOf(data)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, nil, fmt.Errorf("no data to format")
+		}
+		value = value.Elem()
+	}
+
+	var elemType reflect.Type
+	var rows []reflect.Value
+	switch value.Kind() {
+	case reflect.Struct:
+		elemType = value.Type()
+		rows = []reflect.Value{value}
+	case reflect.Slice:
+		elemType = value.Type().Elem()
+		for elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		for i := 0; i < value.Len(); i++ {
+			rows = append(rows, value.Index(i))
+		}
+	}
+	if elemType == nil || elemType.Kind() != reflect.Struct || elemType == timeType {
+		return nil, nil, fmt.Errorf("cannot format %T as records", data)
+	}
+
+	header := columnNames(elemType, "")
+	records := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		records = append(records, columnValues(row, elemType))
+	}
+	return header, records, nil
+}
+
+// selectedRecords returns the header and rows of records reduced to selected
+// fields, named after the fields
+func selectedRecords(records []Record) ([]string, [][]string, error) {
+	var header []string
+	if len(records) > 0 {
+		for _, field := range records[0] {
+			header = append(header, field.Name)
+		}
+	}
+
+	rows := make([][]string, 0, len(records))
+	for _, record := range records {
+		row := make([]string, 0, len(record))
+		for _, field := range record {
+			if field.Value == nil {
+				row = append(row, "")
+				continue
+			}
+			row = append(row, cellString(reflect.ValueOf(field.Value)))
+		}
+		rows = append(rows, row)
+	}
+	return header, rows, nil
+}
+
+// jsonFields returns the exported fields of a struct type that are marshalled to JSON and their names
+func jsonFields(t reflect.Type) ([]reflect.StructField, []string) {
+	var fields []reflect.StructField
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if tagName := strings.Split(tag, ",")[0]; tagName != "" {
+				name = tagName
+			}
+		}
+		fields = append(fields, field)
+		names = append(names, name)
+	}
+	return fields, names
+}
+
+// nestedStruct returns the struct type a field is flattened into, if any
+func nestedStruct(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct && t != timeType {
+		return t, true
+	}
+	return nil, false
+}
+
+// columnNames returns the column names of a struct type
+func columnNames(t reflect.Type, prefix string) []string {
+	var columns []string
+	fields, names := jsonFields(t)
+	for i, field := range fields {
+		if nested, ok := nestedStruct(field.Type); ok {
+			columns = append(columns, columnNames(nested, prefix+names[i]+".")...)
+			continue
+		}
+		columns = append(columns, prefix+names[i])
+	}
+	return columns
+}
+
+// columnValues returns the cells of a struct value, with empty cells when it is a nil pointer
+func columnValues(v reflect.Value, t reflect.Type) []string {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return make([]string, len(columnNames(t, "")))
+		}
+		v = v.Elem()
+	}
+
+	var cells []string
+	fields, _ := jsonFields(t)
+	for _, field := range fields {
+		fieldValue := v.FieldByIndex(field.Index)
+		if nested, ok := nestedStruct(field.Type); ok {
+			cells = append(cells, columnValues(fieldValue, nested)...)
+			continue
+		}
+		cells = append(cells, cellString(fieldValue))
+	}
+	return cells
+}
+
+// cellString formats a single field value as a table cell
+func cellString(v reflect.Value) string {
+	switch {
+	case v.Type() == timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339)
+	case v.Type() == kindType:
+		return database.SnapshotKindString(v.Interface().(snapshots.Kind))
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Len() == 0 {
+			return ""
+		}
+	case reflect.Slice:
+		if isPlain(v.Type().Elem()) {
+			items := make([]string, v.Len())
+			for i := range items {
+				items[i] = fmt.Sprint(v.Index(i).Interface())
+			}
+			return strings.Join(items, ",")
+		}
+	default:
+		if isPlain(v.Type()) {
+			return fmt.Sprint(v.Interface())
+		}
+	}
+
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return ""
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Sprint(v.Interface())
+	}
+	return string(data)
+}
+
+// isPlain reports whether values of a type print as a single word or number
+func isPlain(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// flattenLabels formats labels as "key=value" pairs sorted by key and joined
+// by commas, for reading in tables
+func flattenLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + labels[k]
+	}
+	return strings.Join(pairs, ",")
+}
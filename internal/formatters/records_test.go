@@ -0,0 +1,122 @@
+package formatters
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/blockdev"
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+func TestRecords_Snapshots(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	snapshotList := []database.SnapshotInfo{
+		{
+			Key:       "container-1",
+			ID:        3,
+			Kind:      snapshots.KindActive,
+			Parent:    "layer-1",
+			CreatedAt: created,
+			UpdatedAt: created,
+			Labels:    map[string]string{"b": "x,y=z", "a": "1"},
+			Size:      4096,
+			ContentID: "content-1",
+		},
+	}
+
+	header, rows, err := Records(snapshotList)
+	if err != nil {
+		t.Fatalf("Records failed: %v", err)
+	}
+
+	expectedHeader := []string{"key", "id", "kind", "parent", "created_at", "updated_at", "labels",
+		"inodes", "size", "content_id", "path", "extra"}
+	if !reflect.DeepEqual(header, expectedHeader) {
+		t.Errorf("Expected header %v, got %v", expectedHeader, header)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(rows))
+	}
+
+	expectedRow := []string{"container-1", "3", "active", "layer-1", "2024-01-02T03:04:05Z", "2024-01-02T03:04:05Z",
+		`{"a":"1","b":"x,y=z"}`, "0", "4096", "content-1", "", ""}
+	if !reflect.DeepEqual(rows[0], expectedRow) {
+		t.Errorf("Expected row %v, got %v", expectedRow, rows[0])
+	}
+}
+
+func TestRecords_NestedStruct(t *testing.T) {
+	devices := []blockdev.DevboxDevice{
+		{ContentID: "content-1", LvName: "lv-1", Found: true,
+			Device: &blockdev.DMDevice{Name: "vg-lv--1", Kernel: "dm-1", Slaves: []string{"dm-0", "sda"}}},
+		{ContentID: "content-2", LvName: "lv-2"},
+	}
+
+	header, rows, err := Records(devices)
+	if err != nil {
+		t.Fatalf("Records failed: %v", err)
+	}
+	if len(header) != len(rows[0]) || len(header) != len(rows[1]) {
+		t.Fatalf("Expected every row to have %d cells, got %d and %d", len(header), len(rows[0]), len(rows[1]))
+	}
+
+	cells := make(map[string]string)
+	for i, column := range header {
+		cells[column] = rows[0][i]
+	}
+	if cells["device.kernel"] != "dm-1" {
+		t.Errorf("Expected device.kernel = dm-1, got %q", cells["device.kernel"])
+	}
+	if cells["device.slaves"] != "dm-0,sda" {
+		t.Errorf("Expected device.slaves = dm-0,sda, got %q", cells["device.slaves"])
+	}
+	if strings.Join(rows[1][len(rows[1])-3:], "") != "" {
+		t.Errorf("Expected empty device cells for an unresolved entry, got %v", rows[1])
+	}
+}
+
+func TestRecords_Unsupported(t *testing.T) {
+	if _, _, err := Records(map[string]string{"lv": "/path"}); err == nil {
+		t.Error("Expected an error formatting a map as records")
+	}
+	if _, _, err := Records((*database.SnapshotInfo)(nil)); err == nil {
+		t.Error("Expected an error formatting a nil pointer as records")
+	}
+}
+
+func TestLVMMappings(t *testing.T) {
+	storage := []database.DevboxStorageInfo{
+		{ContentID: "content-1", LvName: "lv-1", Path: "/data/1"},
+		{ContentID: "content-2", LvName: "lv-2"},
+		{ContentID: "content-3", Path: "/data/3"},
+	}
+
+	mappings := LVMMappings(storage)
+	expected := []LVMMapping{{LvName: "lv-1", Path: "/data/1"}}
+	if !reflect.DeepEqual(mappings, expected) {
+		t.Errorf("Expected mappings %v, got %v", expected, mappings)
+	}
+}
+
+func TestToYAML(t *testing.T) {
+	storage := []database.DevboxStorageInfo{
+		{ContentID: "123", LvName: "lv-1", Path: "/data/1", Status: "active"},
+	}
+
+	output, err := toYAML(storage)
+	if err != nil {
+		t.Fatalf("toYAML failed: %v", err)
+	}
+
+	expected := `- content_id: "123"
+  lv_name: lv-1
+  path: /data/1
+  status: active
+`
+	if string(output) != expected {
+		t.Errorf("Expected YAML:\n%s\ngot:\n%s", expected, output)
+	}
+}
@@ -0,0 +1,209 @@
+package formatters
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/containerd/meta-viewer/internal/blockdev"
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/discover"
+	"github.com/containerd/meta-viewer/internal/du"
+	"github.com/containerd/meta-viewer/internal/gc"
+	"github.com/containerd/meta-viewer/internal/lvm"
+	"github.com/containerd/meta-viewer/internal/mounts"
+	"github.com/containerd/meta-viewer/internal/procs"
+)
+
+// outputKind is the kind of an output: the type commands pass to Format,
+// the JSON encoding of its items and its table layout. A type is supported
+// by every output format once its kind is listed in outputKinds.
+type outputKind struct {
+	name string
+	data reflect.Type // Type passed to Format, e.g. []database.BucketInfo
+	item reflect.Type // Type of the JSON items, or of the item of single objects
+	list bool
+	// encode converts data to its JSON encoding when that differs from data
+	encode func(f *JSONFormatter, data interface{}) interface{}
+	// table is the TableFormatter method writing data as a table
+	table reflect.Value
+}
+
+var (
+	kindBuckets           = listKind("BucketList", database.BucketInfo{}, (*TableFormatter).FormatBuckets)
+	kindSnapshots         = listKind("SnapshotList", database.SnapshotJSON{}, (*TableFormatter).FormatSnapshots).encodedFrom([]database.SnapshotInfo(nil), encodeSnapshots)
+	kindSnapshot          = itemKind("Snapshot", database.SnapshotJSON{}, (*TableFormatter).FormatSnapshot).encodedFrom((*database.SnapshotInfo)(nil), encodeSnapshot)
+	kindDevboxStorage     = listKind("DevboxStorageList", database.DevboxStorageInfo{}, (*TableFormatter).FormatDevboxStorage)
+	kindDevboxStorageItem = itemKind("DevboxStorage", database.DevboxStorageInfo{}, (*TableFormatter).FormatDevboxStorageItem)
+	kindLVMMap            = itemKind("LVMMap", map[string]string{}, (*TableFormatter).formatLVMMappings).encodedFrom([]LVMMapping(nil), encodeLVMMap)
+	kindDevmapperDevices  = listKind("DevmapperDeviceList", database.DevmapperDeviceInfo{}, (*TableFormatter).FormatDevmapperDevices)
+	kindDevmapperDevice   = itemKind("DevmapperDevice", database.DevmapperDeviceInfo{}, (*TableFormatter).FormatDevmapperDevice)
+	kindDevmapperMappings = listKind("DevmapperMappingList", database.DevmapperMapping{}, (*TableFormatter).FormatDevmapperMappings)
+	kindGCPreview         = itemKind("GCPreview", gc.Report{}, (*TableFormatter).FormatGCPreview)
+	kindDatabases         = listKind("DatabaseList", discover.Database{}, (*TableFormatter).FormatDiscoveredDatabases)
+	kindDevboxChecks      = listKind("DevboxCheckList", mounts.DevboxCheck{}, (*TableFormatter).FormatDevboxVerify)
+	kindLVMReconcile      = listKind("LVMReconciliationList", lvm.Reconciliation{}, (*TableFormatter).FormatLVMReconcile)
+	kindCapacity          = itemKind("CapacityReport", lvm.CapacityReport{}, (*TableFormatter).FormatCapacity)
+	kindDevboxDevices     = listKind("DevboxDeviceList", blockdev.DevboxDevice{}, (*TableFormatter).FormatDevboxDevices)
+	kindDevboxUsage       = listKind("DevboxUsageList", mounts.Usage{}, (*TableFormatter).FormatDevboxUsage)
+	kindSnapshotUsage     = listKind("SnapshotUsageList", du.SnapshotUsage{}, (*TableFormatter).FormatSnapshotUsage)
+	kindSnapshotOrphans   = listKind("SnapshotOrphanList", du.Orphan{}, (*TableFormatter).FormatSnapshotOrphans)
+	kindSnapshotMounts    = itemKind("SnapshotMounts", mounts.SnapshotMounts{}, (*TableFormatter).FormatSnapshotMounts)
+	kindProcessUsers      = listKind("ProcessUserList", procs.User{}, (*TableFormatter).FormatProcessUsers)
+)
+
+// outputKinds are the kinds of every output
+var outputKinds = []outputKind{
+	kindBuckets, kindSnapshots, kindSnapshot, kindDevboxStorage, kindDevboxStorageItem,
+	kindLVMMap, kindDevmapperDevices, kindDevmapperDevice, kindDevmapperMappings,
+	kindGCPreview, kindDatabases, kindDevboxChecks, kindLVMReconcile, kindCapacity,
+	kindDevboxDevices, kindDevboxUsage, kindSnapshotUsage, kindSnapshotOrphans,
+	kindSnapshotMounts, kindProcessUsers,
+}
+
+// kindOf returns the output kind of values of type t, as passed to Format
+func kindOf(t reflect.Type) (outputKind, bool) {
+	for _, kind := range outputKinds {
+		if t != nil && kind.data == t {
+			return kind, true
+		}
+	}
+	return outputKind{}, false
+}
+
+// listKind is the kind of a slice of item, written as a table by the
+// TableFormatter method table, e.g. (*TableFormatter).FormatBuckets
+func listKind(name string, item interface{}, table interface{}) outputKind {
+	t := reflect.TypeOf(item)
+	return outputKind{name: name, data: reflect.SliceOf(t), item: t, list: true, table: reflect.ValueOf(table)}
+}
+
+// itemKind is the kind of a pointer to item, written as a table by the
+// TableFormatter method table
+func itemKind(name string, item interface{}, table interface{}) outputKind {
+	t := reflect.TypeOf(item)
+	return outputKind{name: name, data: reflect.PtrTo(t), item: t, table: reflect.ValueOf(table)}
+}
+
+// encodedFrom returns the kind for values of the type of data, which encode
+// converts to the JSON items of the kind
+func (kind outputKind) encodedFrom(data interface{}, encode func(f *JSONFormatter, data interface{}) interface{}) outputKind {
+	kind.data = reflect.TypeOf(data)
+	kind.encode = encode
+	return kind
+}
+
+// OutputKinds returns the names of the JSON output kinds, sorted
+func OutputKinds() []string {
+	names := make([]string, 0, len(outputKinds))
+	for _, kind := range outputKinds {
+		names = append(names, kind.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// JSONSchema returns the JSON Schema of the envelope of the named output kind
+func JSONSchema(name string) (map[string]interface{}, error) {
+	for _, kind := range outputKinds {
+		if strings.EqualFold(kind.name, name) {
+			return envelopeSchema(kind), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown output kind '%s'. Use one of: %s", name, strings.Join(OutputKinds(), ", "))
+}
+
+// envelopeSchema describes the Envelope holding kind
+func envelopeSchema(kind outputKind) map[string]interface{} {
+	properties := map[string]interface{}{
+		"apiVersion": map[string]interface{}{"const": APIVersion},
+		"kind":       map[string]interface{}{"const": kind.name},
+		"source":     map[string]interface{}{"type": "string", "description": "Path of the database the output was read from"},
+		"readAt":     map[string]interface{}{"type": "string", "format": "date-time", "description": "When the database was opened"},
+		"txid":       map[string]interface{}{"type": "integer", "description": "ID of the last transaction committed to the database"},
+	}
+	payload := "item"
+	if kind.list {
+		payload = "items"
+		properties[payload] = map[string]interface{}{"type": "array", "items": typeSchema(kind.item)}
+	} else {
+		properties[payload] = typeSchema(kind.item)
+	}
+
+	return map[string]interface{}{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"title":      kind.name,
+		"type":       "object",
+		"properties": properties,
+		"required":   []string{"apiVersion", "kind", payload},
+	}
+}
+
+// typeSchema describes the JSON encoding of values of type t
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		required := []string{}
+		addFields(t, properties, &required)
+		return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// addFields adds the JSON fields of struct type t, following encoding/json:
+// fields without omitempty are required, and nil slices, maps and pointers
+// in them are encoded as null. An enum tag lists the values of a field.
+func addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addFields(field.Type, properties, required)
+			continue
+		}
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := typeSchema(field.Type)
+		if enum := field.Tag.Get("enum"); enum != "" {
+			schema["enum"] = strings.Split(enum, ",")
+		}
+		omitEmpty := strings.Contains(options, "omitempty")
+		if !omitEmpty {
+			*required = append(*required, name)
+			switch field.Type.Kind() {
+			case reflect.Slice, reflect.Map, reflect.Ptr:
+				schema["type"] = []interface{}{schema["type"], "null"}
+			}
+		}
+		properties[name] = schema
+	}
+}
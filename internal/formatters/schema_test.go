@@ -0,0 +1,81 @@
+package formatters
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOutputKinds(t *testing.T) {
+	names := OutputKinds()
+	if len(names) != len(outputKinds) {
+		t.Fatalf("Expected %d kinds, got %d", len(outputKinds), len(names))
+	}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			t.Errorf("Expected unique kind names, %s is repeated", name)
+		}
+		seen[name] = true
+	}
+
+	// Every kind is written as a table by a method taking its data
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	for _, kind := range outputKinds {
+		table := kind.table.Type()
+		if table.NumIn() != 2 || table.In(0) != reflect.TypeOf(&TableFormatter{}) || table.In(1) != kind.data ||
+			table.NumOut() != 1 || table.Out(0) != errorType {
+			t.Errorf("%s: expected a table method taking %s, got %s", kind.name, kind.data, table)
+		}
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	for _, name := range OutputKinds() {
+		schema, err := JSONSchema(name)
+		if err != nil {
+			t.Fatalf("JSONSchema(%s) failed: %v", name, err)
+		}
+		if _, err := json.Marshal(schema); err != nil {
+			t.Errorf("Expected the schema of %s to marshal, got %v", name, err)
+		}
+	}
+
+	schema, err := JSONSchema("snapshotlist")
+	if err != nil {
+		t.Fatalf("Expected kind names to be case insensitive, got %v", err)
+	}
+	properties := schema["properties"].(map[string]interface{})
+	if kind := properties["kind"].(map[string]interface{}); kind["const"] != "SnapshotList" {
+		t.Errorf("Expected kind to be const SnapshotList, got %v", kind)
+	}
+	if required := schema["required"]; !reflect.DeepEqual(required, []string{"apiVersion", "kind", "items"}) {
+		t.Errorf("Expected lists to require items, got %v", required)
+	}
+
+	item := properties["items"].(map[string]interface{})["items"].(map[string]interface{})
+	fields := item["properties"].(map[string]interface{})
+	kind := fields["kind"].(map[string]interface{})
+	if kind["type"] != "string" || !reflect.DeepEqual(kind["enum"], []string{"Active", "Committed", "View", "Unknown"}) {
+		t.Errorf("Expected kind to be a string enum, got %v", kind)
+	}
+	if created := fields["created_at"].(map[string]interface{}); created["format"] != "date-time" {
+		t.Errorf("Expected created_at to be a date-time, got %v", created)
+	}
+	for _, name := range item["required"].([]string) {
+		if name == "kind_value" || name == "labels" {
+			t.Errorf("Expected omitempty field %s to be optional", name)
+		}
+	}
+
+	schema, _ = JSONSchema("GCPreview")
+	report := schema["properties"].(map[string]interface{})["item"].(map[string]interface{})
+	snapshots := report["properties"].(map[string]interface{})["snapshots"].(map[string]interface{})
+	if !reflect.DeepEqual(snapshots["type"], []interface{}{"array", "null"}) {
+		t.Errorf("Expected a slice without omitempty to be nullable, got %v", snapshots["type"])
+	}
+
+	if _, err := JSONSchema("Unknown"); err == nil {
+		t.Error("Expected an error for an unknown kind")
+	}
+}
@@ -2,90 +2,183 @@ package formatters
 
 import (
 	"fmt"
+	"io"
 	"os"
-	"text/tabwriter"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/containerd/meta-viewer/internal/blockdev"
 	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/discover"
+	"github.com/containerd/meta-viewer/internal/du"
+	"github.com/containerd/meta-viewer/internal/gc"
+	"github.com/containerd/meta-viewer/internal/lvm"
+	"github.com/containerd/meta-viewer/internal/mounts"
+	"github.com/containerd/meta-viewer/internal/procs"
 )
 
+// Size units accepted by TableOptions.Units
+const (
+	UnitsIEC   = "iec"   // Binary suffixes, e.g. 1.5GiB
+	UnitsSI    = "si"    // Decimal suffixes, e.g. 1.6GB
+	UnitsBytes = "bytes" // Plain byte counts
+)
+
+// SizeUnits lists the supported size units
+var SizeUnits = []string{UnitsIEC, UnitsSI, UnitsBytes}
+
+// TableOptions control how tables are laid out
+type TableOptions struct {
+	// Width is the number of terminal columns tables are fitted to by
+	// truncating their widest cells. 0 leaves tables unfitted.
+	Width int
+	// NoTrunc prints cells in full even when the table is wider than Width
+	NoTrunc bool
+	// Wide adds less used columns, such as snapshot labels
+	Wide bool
+	// Units are the units of sizes, one of SizeUnits. Empty means UnitsIEC.
+	Units string
+	// Location is the time zone of absolute times. Nil means local time.
+	Location *time.Location
+	// Color highlights kinds and statuses with ANSI escape sequences
+	Color bool
+}
+
+// Size formats a byte count in the units of the options, e.g. 1.5GiB
+func (o TableOptions) Size(bytes int64) string {
+	if bytes < 0 {
+		return "-" + FormatSize(uint64(-bytes), o.Units)
+	}
+	return FormatSize(uint64(bytes), o.Units)
+}
+
+// Time formats an absolute time with its zone in the location of the
+// options, or "-" for the zero time
+func (o TableOptions) Time(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	location := o.Location
+	if location == nil {
+		location = time.Local
+	}
+	return t.In(location).Format("2006-01-02 15:04:05 MST")
+}
+
 // TableFormatter formats output as tables
 type TableFormatter struct {
-	writer *tabwriter.Writer
+	writer io.Writer
+	opts   TableOptions
+	now    func() time.Time
+	// fields selects and orders the columns of list tables
+	fields []string
 }
 
-// NewTableFormatter creates a new table formatter
+// NewTableFormatter creates a new table formatter with the default options
 func NewTableFormatter() *TableFormatter {
+	return NewTableFormatterWithOptions(TableOptions{})
+}
+
+// NewTableFormatterWithOptions creates a new table formatter
+func NewTableFormatterWithOptions(opts TableOptions) *TableFormatter {
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
 	return &TableFormatter{
-		writer: tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0),
+		writer: os.Stdout,
+		opts:   opts,
+		now:    time.Now,
 	}
 }
 
+// Format formats the result of any command with the table layout of its
+// output kind
+func (f *TableFormatter) Format(data interface{}) error {
+	kind, ok := kindOf(reflect.TypeOf(data))
+	if !ok || !kind.table.IsValid() {
+		return fmt.Errorf("table output is not supported for %T", data)
+	}
+	result := kind.table.Call([]reflect.Value{reflect.ValueOf(f), reflect.ValueOf(data)})
+	if err, _ := result[0].Interface().(error); err != nil {
+		return err
+	}
+	return nil
+}
+
 // FormatBuckets formats bucket information as a table
 func (f *TableFormatter) FormatBuckets(buckets []database.BucketInfo) error {
-	fmt.Fprintln(f.writer, "NAME\tKEYS")
-	for _, bucket := range buckets {
-		fmt.Fprintf(f.writer, "%s\t%d\n", bucket.Name, bucket.KeyCount)
+	columns := []tableColumn{
+		{"name", "NAME", func(i int) string { return buckets[i].Name }},
+		{"key_count", "KEYS", func(i int) string { return strconv.Itoa(buckets[i].KeyCount) }},
 	}
-	return f.writer.Flush()
+	return f.writeColumns(reflect.TypeOf(database.BucketInfo{}), len(buckets), columns, []string{"name", "key_count"}, nil)
 }
 
-// FormatSnapshots formats snapshot information as a table
+// FormatSnapshots formats snapshot information as a table. Wide tables add
+// the absolute creation and update times and the labels.
 func (f *TableFormatter) FormatSnapshots(snapshots []database.SnapshotInfo) error {
-	fmt.Fprintln(f.writer, "ID\tKEY\tKIND\tPARENT\tCONTENT_ID\tPATH\tINODES\tSIZE\tCREATED")
-	for _, snapshot := range snapshots {
-		created := snapshot.CreatedAt.Format("2006-01-02 15:04:05")
-		parent := snapshot.Parent
-		if parent == "" {
-			parent = "-"
-		}
-		contentID := snapshot.ContentID
-		if contentID == "" {
-			contentID = "-"
-		}
-		path := snapshot.Path
-		if path == "" {
-			path = "-"
-		}
-
-		fmt.Fprintf(f.writer, "%d\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\n",
-			snapshot.ID,
-			truncateString(snapshot.Key, 12),
-			database.SnapshotKindString(snapshot.Kind),
-			parent,
-			truncateString(contentID, 12),
-			truncateString(path, 20),
-			snapshot.Inodes,
-			snapshot.Size,
-			created)
+	columns := []tableColumn{
+		{"id", "ID", func(i int) string { return strconv.FormatUint(snapshots[i].ID, 10) }},
+		{"key", "KEY", func(i int) string { return snapshots[i].Key }},
+		{"kind", "KIND", func(i int) string { return database.SnapshotKindString(snapshots[i].Kind) }},
+		{"kind_value", "KIND_VALUE", func(i int) string { return strconv.Itoa(int(snapshots[i].Kind)) }},
+		{"parent", "PARENT", func(i int) string { return dashIfEmpty(snapshots[i].Parent) }},
+		{"content_id", "CONTENT_ID", func(i int) string { return dashIfEmpty(snapshots[i].ContentID) }},
+		{"path", "PATH", func(i int) string { return dashIfEmpty(snapshots[i].Path) }},
+		{"inodes", "INODES", func(i int) string { return strconv.FormatInt(snapshots[i].Inodes, 10) }},
+		{"size", "SIZE", func(i int) string { return f.size(snapshots[i].Size) }},
+		{"age", "AGE", func(i int) string { return f.age(snapshots[i].CreatedAt) }},
+		{"created_at", "CREATED", func(i int) string { return f.time(snapshots[i].CreatedAt) }},
+		{"updated_at", "UPDATED", func(i int) string { return f.time(snapshots[i].UpdatedAt) }},
+		{"labels", "LABELS", func(i int) string { return dashIfEmpty(flattenLabels(snapshots[i].Labels)) }},
+		{"extra", "EXTRA", func(i int) string { return dashIfEmpty(flattenLabels(snapshots[i].Extra)) }},
 	}
-	return f.writer.Flush()
+	defaults := []string{"id", "key", "kind", "parent", "content_id", "path", "inodes", "size", "age"}
+	if f.opts.Wide {
+		defaults = append(defaults, "created_at", "updated_at", "labels")
+	}
+	return f.writeColumns(reflect.TypeOf(database.SnapshotInfo{}), len(snapshots), columns, defaults, func(i int) map[string]string {
+		return snapshots[i].Labels
+	})
 }
 
 // FormatSnapshot formats a single snapshot as detailed information
 func (f *TableFormatter) FormatSnapshot(snapshot *database.SnapshotInfo) error {
-	fmt.Printf("Snapshot Information:\n")
-	fmt.Printf("====================\n")
-	fmt.Printf("ID:       %d\n", snapshot.ID)
-	fmt.Printf("Key:      %s\n", snapshot.Key)
-	fmt.Printf("Kind:     %s\n", database.SnapshotKindString(snapshot.Kind))
-	fmt.Printf("Parent:   %s\n", snapshot.Parent)
-	fmt.Printf("Created:  %s\n", snapshot.CreatedAt.Format("2006-01-02 15:04:05"))
-	fmt.Printf("Updated:  %s\n", snapshot.UpdatedAt.Format("2006-01-02 15:04:05"))
-	fmt.Printf("Inodes:   %d\n", snapshot.Inodes)
-	fmt.Printf("Size:     %d bytes\n", snapshot.Size)
+	fmt.Fprintf(f.writer, "Snapshot Information:\n")
+	fmt.Fprintf(f.writer, "====================\n")
+	fmt.Fprintf(f.writer, "ID:       %d\n", snapshot.ID)
+	fmt.Fprintf(f.writer, "Key:      %s\n", snapshot.Key)
+	fmt.Fprintf(f.writer, "Kind:     %s\n", f.color("KIND", database.SnapshotKindString(snapshot.Kind)))
+	fmt.Fprintf(f.writer, "Parent:   %s\n", snapshot.Parent)
+	fmt.Fprintf(f.writer, "Created:  %s\n", f.timeWithAge(snapshot.CreatedAt))
+	fmt.Fprintf(f.writer, "Updated:  %s\n", f.timeWithAge(snapshot.UpdatedAt))
+	fmt.Fprintf(f.writer, "Inodes:   %d\n", snapshot.Inodes)
+	fmt.Fprintf(f.writer, "Size:     %s\n", f.sizeWithBytes(snapshot.Size))
 
 	if snapshot.ContentID != "" {
-		fmt.Printf("ContentID: %s\n", snapshot.ContentID)
+		fmt.Fprintf(f.writer, "ContentID: %s\n", snapshot.ContentID)
 	}
 
 	if snapshot.Path != "" {
-		fmt.Printf("Path:      %s\n", snapshot.Path)
+		fmt.Fprintf(f.writer, "Path:      %s\n", snapshot.Path)
+	}
+
+	if len(snapshot.Extra) > 0 {
+		fmt.Fprintf(f.writer, "\nSnapshotter Fields:\n")
+		for _, k := range sortedKeys(snapshot.Extra) {
+			fmt.Fprintf(f.writer, "  %s: %s\n", escapeControl(k), escapeControl(snapshot.Extra[k]))
+		}
 	}
 
 	if len(snapshot.Labels) > 0 {
-		fmt.Printf("\nLabels:\n")
-		for k, v := range snapshot.Labels {
-			fmt.Printf("  %s: %s\n", k, v)
+		fmt.Fprintf(f.writer, "\nLabels:\n")
+		for _, k := range sortedKeys(snapshot.Labels) {
+			fmt.Fprintf(f.writer, "  %s: %s\n", escapeControl(k), escapeControl(snapshot.Labels[k]))
 		}
 	}
 
@@ -94,72 +187,778 @@ func (f *TableFormatter) FormatSnapshot(snapshot *database.SnapshotInfo) error {
 
 // FormatDevboxStorage formats devbox storage information as a table
 func (f *TableFormatter) FormatDevboxStorage(storage []database.DevboxStorageInfo) error {
-	fmt.Fprintln(f.writer, "CONTENT_ID\tLV_NAME\tPATH\tSTATUS")
-	for _, item := range storage {
-		lvName := item.LvName
-		if lvName == "" {
-			lvName = "-"
-		}
-		path := item.Path
-		if path == "" {
-			path = "-"
-		}
-		status := item.Status
-		if status == "" {
-			status = "unknown"
-		}
-
-		fmt.Fprintf(f.writer, "%s\t%s\t%s\t%s\n",
-			truncateString(item.ContentID, 12),
-			lvName,
-			truncateString(path, 30),
-			status)
+	columns := []tableColumn{
+		{"content_id", "CONTENT_ID", func(i int) string { return storage[i].ContentID }},
+		{"lv_name", "LV_NAME", func(i int) string { return dashIfEmpty(storage[i].LvName) }},
+		{"path", "PATH", func(i int) string { return dashIfEmpty(storage[i].Path) }},
+		{"status", "STATUS", func(i int) string {
+			if storage[i].Status == "" {
+				return "unknown"
+			}
+			return storage[i].Status
+		}},
+		{"extra", "EXTRA", func(i int) string { return dashIfEmpty(flattenLabels(storage[i].Extra)) }},
 	}
-	return f.writer.Flush()
+	return f.writeColumns(reflect.TypeOf(database.DevboxStorageInfo{}), len(storage), columns, []string{"content_id", "lv_name", "path", "status"}, nil)
 }
 
 // FormatDevboxStorageItem formats a single devbox storage item as detailed information
 func (f *TableFormatter) FormatDevboxStorageItem(item *database.DevboxStorageInfo) error {
-	fmt.Printf("Devbox Storage Information:\n")
-	fmt.Printf("==========================\n")
-	fmt.Printf("ContentID: %s\n", item.ContentID)
-	fmt.Printf("LV Name:   %s\n", item.LvName)
-	fmt.Printf("Path:      %s\n", item.Path)
-	fmt.Printf("Status:    %s\n", item.Status)
+	fmt.Fprintf(f.writer, "Devbox Storage Information:\n")
+	fmt.Fprintf(f.writer, "==========================\n")
+	fmt.Fprintf(f.writer, "ContentID: %s\n", item.ContentID)
+	fmt.Fprintf(f.writer, "LV Name:   %s\n", item.LvName)
+	fmt.Fprintf(f.writer, "Path:      %s\n", item.Path)
+	fmt.Fprintf(f.writer, "Status:    %s\n", f.color("STATUS", item.Status))
+
+	if len(item.Extra) > 0 {
+		fmt.Fprintf(f.writer, "\nProfile Fields:\n")
+		for _, k := range sortedKeys(item.Extra) {
+			fmt.Fprintf(f.writer, "  %s: %s\n", escapeControl(k), escapeControl(item.Extra[k]))
+		}
+	}
 	return nil
 }
 
 // FormatLVMMap formats LVM mapping information as a table
 func (f *TableFormatter) FormatLVMMap(storage []database.DevboxStorageInfo) error {
-	fmt.Fprintln(f.writer, "LV_NAME\tPATH")
-	for _, item := range storage {
-		if item.LvName != "" && item.Path != "" {
-			fmt.Fprintf(f.writer, "%s\t%s\n",
-				item.LvName,
-				truncateString(item.Path, 50))
+	return f.formatLVMMappings(LVMMappings(storage))
+}
+
+// formatLVMMappings formats LVM mappings as a table
+func (f *TableFormatter) formatLVMMappings(mappings []LVMMapping) error {
+	t := newTable("LV_NAME", "PATH")
+	for _, mapping := range mappings {
+		t.add(mapping.LvName, mapping.Path)
+	}
+	return f.write(t)
+}
+
+// FormatDevmapperDevices formats devmapper thin devices as a table
+func (f *TableFormatter) FormatDevmapperDevices(devices []database.DevmapperDeviceInfo) error {
+	t := newTable("DEVICE_ID", "NAME", "PARENT", "SIZE", "STATE", "ERROR")
+	for _, device := range devices {
+		t.add(
+			strconv.FormatUint(uint64(device.DeviceID), 10),
+			device.Name,
+			dashIfEmpty(device.ParentName),
+			f.usize(device.Size),
+			database.DevmapperStateString(device.State),
+			dashIfEmpty(device.Error))
+	}
+	return f.write(t)
+}
+
+// FormatDevmapperDevice formats a single devmapper thin device as detailed information
+func (f *TableFormatter) FormatDevmapperDevice(device *database.DevmapperDeviceInfo) error {
+	fmt.Fprintf(f.writer, "Devmapper Device Information:\n")
+	fmt.Fprintf(f.writer, "============================\n")
+	fmt.Fprintf(f.writer, "Name:      %s\n", device.Name)
+	fmt.Fprintf(f.writer, "Device ID: %d\n", device.DeviceID)
+	fmt.Fprintf(f.writer, "Parent:    %s\n", device.ParentName)
+	fmt.Fprintf(f.writer, "Size:      %s\n", f.sizeWithBytes(int64(device.Size)))
+	fmt.Fprintf(f.writer, "State:     %s\n", database.DevmapperStateString(device.State))
+	if snapshotID, ok := database.DevmapperSnapshotID(device.Name); ok {
+		fmt.Fprintf(f.writer, "Snapshot:  %s\n", snapshotID)
+	}
+	if device.Error != "" {
+		fmt.Fprintf(f.writer, "Error:     %s\n", device.Error)
+	}
+	return nil
+}
+
+// FormatDevmapperMappings formats the device to snapshot cross-reference as a table
+func (f *TableFormatter) FormatDevmapperMappings(mappings []database.DevmapperMapping) error {
+	t := newTable("SNAPSHOT_ID", "KEY", "KIND", "DEVICE_ID", "DEVICE", "STATE", "STATUS")
+	for _, mapping := range mappings {
+		device := mapping.DeviceName
+		deviceID := strconv.FormatUint(uint64(mapping.DeviceID), 10)
+		state := mapping.State
+		if device == "" {
+			device, deviceID, state = "-", "-", "-"
+		}
+
+		t.add(
+			mapping.SnapshotID,
+			dashIfEmpty(mapping.SnapshotKey),
+			dashIfEmpty(mapping.Kind),
+			deviceID,
+			device,
+			state,
+			mapping.Status)
+	}
+	return f.write(t)
+}
+
+// FormatGCPreview formats a garbage collection preview as a table followed by a summary
+func (f *TableFormatter) FormatGCPreview(report *gc.Report) error {
+	t := newTable("ID", "KEY", "KIND", "NAMESPACE", "SIZE", "STATUS", "REASONS")
+	for _, snapshot := range report.Snapshots {
+		t.add(
+			strconv.FormatUint(snapshot.ID, 10),
+			snapshot.Key,
+			snapshot.Kind,
+			dashIfEmpty(snapshot.Namespace),
+			f.size(snapshot.Size),
+			string(snapshot.Status),
+			dashIfEmpty(strings.Join(snapshot.Reasons, ",")))
+	}
+	if err := f.write(t); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(f.writer, "\nSnapshotter:  %s\n", report.Snapshotter)
+	fmt.Fprintf(f.writer, "Roots:        %d\n", report.Roots)
+	fmt.Fprintf(f.writer, "Referenced:   %d\n", report.Referenced)
+	fmt.Fprintf(f.writer, "Unreferenced: %d\n", report.Unreferenced)
+	fmt.Fprintf(f.writer, "Reclaimable:  %s\n", f.sizeWithBytes(report.ReclaimableSize))
+	return nil
+}
+
+// FormatDiscoveredDatabases formats discovered snapshotter databases as a table
+func (f *TableFormatter) FormatDiscoveredDatabases(databases []discover.Database) error {
+	t := newTable("SNAPSHOTTER", "SCHEMA", "SIZE", "LOCKED", "DEFAULT", "PATH")
+	for _, db := range databases {
+		schema := db.Schema
+		if db.Error != "" {
+			schema = "error: " + db.Error
+		}
+
+		t.add(
+			db.Snapshotter,
+			schema,
+			f.size(db.Size),
+			strconv.FormatBool(db.Locked),
+			strconv.FormatBool(db.Default),
+			db.Path)
+	}
+	return f.write(t)
+}
+
+// FormatDevboxVerify formats devbox mount verification results as a table
+func (f *TableFormatter) FormatDevboxVerify(checks []mounts.DevboxCheck) error {
+	t := newTable("CONTENT_ID", "LV_NAME", "STATUS", "MOUNTED", "SOURCE", "FSTYPE", "OPTIONS", "RESULT", "PATH")
+	for _, check := range checks {
+		source, fsType, options := check.Source, check.FSType, check.Options
+		if !check.Mounted {
+			source, fsType, options = "-", "-", "-"
+		}
+
+		t.add(
+			check.ContentID,
+			check.LvName,
+			check.Status,
+			strconv.FormatBool(check.Mounted),
+			source,
+			fsType,
+			options,
+			check.Result,
+			check.Path)
+	}
+	return f.write(t)
+}
+
+// FormatLVMReconcile formats devbox storage and LVM reconciliation results as a table
+func (f *TableFormatter) FormatLVMReconcile(results []lvm.Reconciliation) error {
+	t := newTable("LV_NAME", "VG", "CONTENT_ID", "STATUS", "SIZE", "ATTR", "ACTIVE", "RESULT")
+	for _, result := range results {
+		t.add(
+			result.LvName,
+			dashIfEmpty(result.VGName),
+			dashIfEmpty(result.ContentID),
+			dashIfEmpty(result.Status),
+			dashIfEmpty(result.Size),
+			dashIfEmpty(result.Attr),
+			strconv.FormatBool(result.Active),
+			result.Result)
+	}
+	return f.write(t)
+}
+
+// FormatCapacity formats a devbox capacity report as volume group, thin pool and status tables
+func (f *TableFormatter) FormatCapacity(report *lvm.CapacityReport) error {
+	vgs := newTable("VG", "SIZE", "FREE", "LVS")
+	for _, vg := range report.VolumeGroups {
+		vgs.add(vg.Name, f.size(vg.Size), f.size(vg.Free), vg.LVCount)
+	}
+	if err := f.write(vgs); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(f.writer)
+	pools := newTable("POOL", "VG", "SIZE", "DATA%", "META%", "ALLOCATED", "OVERPROVISION", "FLAGS")
+	for _, pool := range report.Pools {
+		pools.add(
+			pool.Name,
+			pool.VGName,
+			f.size(pool.Size),
+			fmt.Sprintf("%.2f", pool.DataPercent),
+			fmt.Sprintf("%.2f", pool.MetadataPercent),
+			f.size(pool.Allocated),
+			fmt.Sprintf("%.2fx", pool.Overprovision),
+			dashIfEmpty(strings.Join(pool.Flags, ",")))
+	}
+	if err := f.write(pools); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(f.writer)
+	statuses := newTable("STATUS", "ENTRIES", "ALLOCATED", "USED", "MISSING_LV", "AMBIGUOUS_LV")
+	for _, usage := range report.Statuses {
+		statuses.add(
+			dashIfEmpty(usage.Status),
+			strconv.Itoa(usage.Entries),
+			f.size(usage.Allocated),
+			f.size(usage.Used),
+			strconv.Itoa(usage.MissingLV),
+			strconv.Itoa(usage.AmbiguousLV))
+	}
+	if err := f.write(statuses); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(f.writer, "\nFlagged pools: %d\n", report.Flagged)
+	return nil
+}
+
+// FormatDevboxDevices formats devbox entries resolved to block devices as a table
+func (f *TableFormatter) FormatDevboxDevices(devices []blockdev.DevboxDevice) error {
+	t := newTable("CONTENT_ID", "LV_NAME", "STATUS", "MAPPER", "DM", "MAJ:MIN", "SIZE", "SLAVES", "HOLDERS")
+	for _, item := range devices {
+		mapper, kernel, majorMinor, size, slaves, holders := "-", "-", "-", "-", "-", "-"
+		if item.Ambiguous {
+			mapper = "ambiguous: " + strings.Join(item.Candidates, ",")
+		}
+		if device := item.Device; device != nil {
+			mapper = device.Name
+			if device.MapperPath == "" {
+				mapper += " (no node)"
+			}
+			kernel = device.Kernel
+			majorMinor = device.MajorMinor
+			size = f.size(device.Size)
+			slaves = dashIfEmpty(strings.Join(device.Slaves, ","))
+			holders = dashIfEmpty(strings.Join(device.Holders, ","))
+		}
+
+		t.add(item.ContentID, item.LvName, item.Status, mapper, kernel, majorMinor, size, slaves, holders)
+	}
+	return f.write(t)
+}
+
+// FormatDevboxUsage formats devbox filesystem usage as a table
+func (f *TableFormatter) FormatDevboxUsage(usages []mounts.Usage) error {
+	t := newTable("CONTENT_ID", "LV_NAME", "STATUS", "STATE", "SIZE", "USED", "AVAIL", "USE%", "INODES", "IUSED", "IFREE", "PATH")
+	for _, usage := range usages {
+		size, used, avail, percent := "-", "-", "-", "-"
+		inodes, inodesUsed, inodesFree := "-", "-", "-"
+		if usage.State == mounts.UsageOK || usage.State == mounts.UsageNotMountpoint {
+			size = f.usize(usage.Total)
+			used = f.usize(usage.Used)
+			avail = f.usize(usage.Available)
+			percent = fmt.Sprintf("%.0f%%", usage.UsedPercent)
+			inodes = fmt.Sprintf("%d", usage.Inodes)
+			inodesUsed = fmt.Sprintf("%d", usage.InodesUsed)
+			inodesFree = fmt.Sprintf("%d", usage.InodesFree)
+		}
+
+		t.add(
+			usage.ContentID,
+			dashIfEmpty(usage.LvName),
+			usage.Status,
+			usage.State,
+			size,
+			used,
+			avail,
+			percent,
+			inodes,
+			inodesUsed,
+			inodesFree,
+			dashIfEmpty(usage.Path))
+	}
+	return f.write(t)
+}
+
+// FormatSnapshotUsage formats recorded and on-disk snapshot usage side by side
+func (f *TableFormatter) FormatSnapshotUsage(usages []du.SnapshotUsage) error {
+	t := newTable("ID", "KEY", "KIND", "STATE", "RECORDED", "ACTUAL", "DRIFT", "REC_INODES", "INODES", "INODE_DRIFT")
+	for _, usage := range usages {
+		actual, drift, inodes, inodesDrift := "-", "-", "-", "-"
+		if usage.State == du.StateOK {
+			actual = f.size(usage.ActualSize)
+			drift = signedSize(usage.SizeDrift, f.opts.Units)
+			inodes = fmt.Sprintf("%d", usage.ActualInodes)
+			inodesDrift = fmt.Sprintf("%+d", usage.InodesDrift)
+		}
+
+		t.add(
+			strconv.FormatUint(usage.ID, 10),
+			usage.Key,
+			usage.Kind,
+			usage.State,
+			f.size(usage.RecordedSize),
+			actual,
+			drift,
+			strconv.FormatInt(usage.RecordedInodes, 10),
+			inodes,
+			inodesDrift)
+	}
+	if err := f.write(t); err != nil {
+		return err
+	}
+
+	for _, usage := range usages {
+		if usage.Error != "" {
+			fmt.Fprintf(f.writer, "Error measuring %s (%s): %s\n", usage.Key, usage.Dir, usage.Error)
+		}
+	}
+	return nil
+}
+
+// FormatSnapshotOrphans formats orphan snapshot directories and entries with a reclaimable space summary
+func (f *TableFormatter) FormatSnapshotOrphans(orphans []du.Orphan) error {
+	if len(orphans) == 0 {
+		fmt.Fprintln(f.writer, "No orphan snapshot directories or entries found")
+		return nil
+	}
+
+	t := newTable("TYPE", "ID", "KEY", "KIND", "SIZE", "INODES", "DIR")
+	dirs := 0
+	for _, orphan := range orphans {
+		if orphan.Type == du.OrphanDirectory {
+			dirs++
+		}
+
+		t.add(
+			orphan.Type,
+			strconv.FormatUint(orphan.ID, 10),
+			dashIfEmpty(orphan.Key),
+			dashIfEmpty(orphan.Kind),
+			f.size(orphan.Size),
+			strconv.FormatInt(orphan.Inodes, 10),
+			orphan.Dir)
+	}
+	if err := f.write(t); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(f.writer)
+	fmt.Fprintf(f.writer, "%d orphan directories (%s reclaimable), %d entries without a directory\n",
+		dirs, f.size(du.ReclaimableSize(orphans)), len(orphans)-dirs)
+	for _, orphan := range orphans {
+		if orphan.Error != "" {
+			fmt.Fprintf(f.writer, "Error measuring %s: %s\n", orphan.Dir, orphan.Error)
+		}
+	}
+	return nil
+}
+
+// FormatSnapshotMounts formats the mount specification computed for a snapshot.
+// The table is never truncated, as mounts are meant to be copied.
+func (f *TableFormatter) FormatSnapshotMounts(m *mounts.SnapshotMounts) error {
+	parents := make([]string, len(m.ParentIDs))
+	for i, id := range m.ParentIDs {
+		parents[i] = fmt.Sprintf("%d", id)
+	}
+
+	fmt.Fprintf(f.writer, "Key:         %s\n", m.Key)
+	fmt.Fprintf(f.writer, "ID:          %d\n", m.ID)
+	fmt.Fprintf(f.writer, "Kind:        %s\n", f.color("KIND", m.Kind))
+	fmt.Fprintf(f.writer, "Snapshotter: %s\n", m.Snapshotter)
+	fmt.Fprintf(f.writer, "Parent IDs:  %s\n", dashIfEmpty(strings.Join(parents, " ")))
+	fmt.Fprintln(f.writer)
+
+	t := newTable("TYPE", "SOURCE", "TARGET", "OPTIONS")
+	t.full = true
+	for _, spec := range m.Mounts {
+		t.add(
+			dashIfEmpty(spec.Type),
+			spec.Source,
+			dashIfEmpty(spec.Target),
+			dashIfEmpty(strings.Join(spec.Options, ",")))
+	}
+	return f.write(t)
+}
+
+// FormatProcessUsers formats the processes using a path, one row per use
+func (f *TableFormatter) FormatProcessUsers(users []procs.User) error {
+	if len(users) == 0 {
+		fmt.Fprintln(f.writer, "No processes are using the given paths")
+		return nil
+	}
+
+	t := newTable("PID", "CONTAINER", "USE", "PATH", "COMMAND")
+	for _, user := range users {
+		for _, use := range user.Uses {
+			kind := use.Type
+			if use.Type == procs.UseFD {
+				kind = fmt.Sprintf("fd %d", use.FD)
+			}
+
+			t.add(
+				strconv.Itoa(user.PID),
+				dashIfEmpty(user.ContainerID),
+				kind,
+				use.Path,
+				dashIfEmpty(user.Command))
+		}
+	}
+	return f.write(t)
+}
+
+// tableColumn is a column of a list table showing one field of its records
+type tableColumn struct {
+	field  string
+	header string
+	// cell returns the cell of the i-th record
+	cell func(i int) string
+}
+
+// writeColumns writes a table of n records with the columns selected by
+// fields, or the default columns. Fields are checked against the fields of
+// the record type like in the other formats and --sort-by, so columns must
+// cover all of them. Records with labels also have a column for each
+// label:<name> field.
+func (f *TableFormatter) writeColumns(record reflect.Type, n int, columns []tableColumn, defaults []string, labels func(i int) map[string]string) error {
+	names := defaults
+	if len(f.fields) > 0 {
+		names = f.fields
+	}
+
+	selected := make([]tableColumn, 0, len(names))
+	for _, name := range names {
+		if err := checkField(record, name); err != nil {
+			return err
+		}
+		if label, ok := strings.CutPrefix(name, LabelPrefix); ok {
+			selected = append(selected, tableColumn{name, strings.ToUpper(label), func(i int) string {
+				return dashIfEmpty(labels(i)[label])
+			}})
+			continue
+		}
+
+		found := false
+		for _, c := range columns {
+			if c.field == name {
+				selected = append(selected, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("table output has no column for field %s", name)
+		}
+	}
+
+	header := make([]string, len(selected))
+	for i, c := range selected {
+		header[i] = c.header
+	}
+	t := newTable(header...)
+	for i := 0; i < n; i++ {
+		row := make([]string, len(selected))
+		for j, c := range selected {
+			row[j] = c.cell(i)
 		}
+		t.add(row...)
 	}
-	return f.writer.Flush()
+	return f.write(t)
 }
 
-// TruncateString truncates a string to the specified length
+// table holds the cells of a table before it is fitted to the terminal
+type table struct {
+	header []string
+	rows   [][]string
+	// full tables are never truncated
+	full bool
+}
+
+func newTable(header ...string) *table {
+	return &table{header: header}
+}
+
+func (t *table) add(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// minColumnWidth is the width columns are not truncated below, unless their
+// header is wider
+const minColumnWidth = 12
+
+// columnGap separates table columns
+const columnGap = "  "
+
+// write prints the table with its columns aligned, truncating the widest
+// cells until the table fits the terminal width. Control characters in
+// cells, such as a newline in a label, are escaped so rows stay on one line.
+func (f *TableFormatter) write(t *table) error {
+	for _, row := range t.rows {
+		for i, cell := range row {
+			row[i] = escapeControl(cell)
+		}
+	}
+
+	widths := make([]int, len(t.header))
+	for i, name := range t.header {
+		widths[i] = utf8.RuneCountInString(name)
+	}
+	for _, row := range t.rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	if f.opts.Width > 0 && !f.opts.NoTrunc && !t.full {
+		fitWidths(t.header, widths, f.opts.Width)
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string, header bool) {
+		for i, cell := range cells {
+			cell = TruncateString(cell, widths[i])
+			padding := widths[i] - utf8.RuneCountInString(cell)
+			if !header {
+				cell = f.color(t.header[i], cell)
+			}
+			b.WriteString(cell)
+			if i < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", padding))
+				b.WriteString(columnGap)
+			}
+		}
+		b.WriteString("\n")
+	}
+	writeRow(t.header, true)
+	for _, row := range t.rows {
+		writeRow(row, false)
+	}
+
+	_, err := io.WriteString(f.writer, b.String())
+	return err
+}
+
+// fitWidths narrows the widest columns, one character at a time, until the
+// columns and the gaps between them fit in width or no column can be
+// narrowed further
+func fitWidths(header []string, widths []int, width int) {
+	minimums := make([]int, len(widths))
+	total := len(columnGap) * (len(widths) - 1)
+	for i, w := range widths {
+		minimums[i] = min(w, max(utf8.RuneCountInString(header[i]), minColumnWidth))
+		total += w
+	}
+
+	for total > width {
+		widest := -1
+		for i, w := range widths {
+			if w > minimums[i] && (widest < 0 || w > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+}
+
+// ANSI escape sequences used to color cells
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[34m"
+	colorCyan   = "\x1b[36m"
+)
+
+// cellColors are the colors of the known values of KIND and STATUS columns
+var cellColors = map[string]map[string]string{
+	"KIND": {
+		"active":    colorGreen,
+		"committed": colorBlue,
+		"view":      colorCyan,
+		"unknown":   colorRed,
+	},
+	"STATUS": {
+		"active":       colorGreen,
+		"root":         colorGreen,
+		"referenced":   colorBlue,
+		"removed":      colorYellow,
+		"unreferenced": colorYellow,
+		"unknown":      colorRed,
+	},
+}
+
+// color highlights the value of the named column when color is enabled
+func (f *TableFormatter) color(column, value string) string {
+	if !f.opts.Color {
+		return value
+	}
+	if code, ok := cellColors[column][value]; ok {
+		return code + value + colorReset
+	}
+	return value
+}
+
+// size formats a byte count in the configured units
+func (f *TableFormatter) size(bytes int64) string {
+	return f.opts.Size(bytes)
+}
+
+// usize formats an unsigned byte count in the configured units
+func (f *TableFormatter) usize(bytes uint64) string {
+	return FormatSize(bytes, f.opts.Units)
+}
+
+// sizeWithBytes formats a byte count in the configured units followed by
+// the exact count, e.g. "1.5GiB (1610612736 bytes)"
+func (f *TableFormatter) sizeWithBytes(bytes int64) string {
+	if f.opts.Units == UnitsBytes {
+		return fmt.Sprintf("%d bytes", bytes)
+	}
+	return fmt.Sprintf("%s (%d bytes)", f.size(bytes), bytes)
+}
+
+// time formats an absolute time with its zone in the configured location
+func (f *TableFormatter) time(t time.Time) string {
+	return f.opts.Time(t)
+}
+
+// age formats a time relative to now, e.g. "3d ago"
+func (f *TableFormatter) age(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return RelativeTime(t, f.now())
+}
+
+// timeWithAge formats an absolute time followed by its age
+func (f *TableFormatter) timeWithAge(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%s)", f.time(t), f.age(t))
+}
+
+// RelativeTime formats t relative to now in its largest whole unit, e.g.
+// "45s ago", "3d ago" or "in 2h" for times in the future
+func RelativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	const day = 24 * time.Hour
+	var amount string
+	switch {
+	case d < time.Minute:
+		amount = fmt.Sprintf("%ds", int(d/time.Second))
+	case d < time.Hour:
+		amount = fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 2*day:
+		amount = fmt.Sprintf("%dh", int(d/time.Hour))
+	case d < 2*365*day:
+		amount = fmt.Sprintf("%dd", int(d/day))
+	default:
+		amount = fmt.Sprintf("%dy", int(d/(365*day)))
+	}
+
+	if future {
+		return "in " + amount
+	}
+	return amount + " ago"
+}
+
+// FormatSize formats a byte count in the given units: binary suffixes for
+// UnitsIEC, decimal suffixes for UnitsSI and the plain count for UnitsBytes.
+// Unknown units fall back to UnitsIEC.
+func FormatSize(bytes uint64, units string) string {
+	switch units {
+	case UnitsBytes:
+		return strconv.FormatUint(bytes, 10)
+	case UnitsSI:
+		return scaledSize(bytes, 1000, []string{"kB", "MB", "GB", "TB", "PB", "EB"})
+	default:
+		return scaledSize(bytes, 1024, []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"})
+	}
+}
+
+// scaledSize formats a byte count with the largest suffix it reaches
+func scaledSize(bytes uint64, unit float64, suffixes []string) string {
+	if float64(bytes) < unit {
+		return fmt.Sprintf("%dB", bytes)
+	}
+
+	value := float64(bytes)
+	i := -1
+	for value >= unit && i < len(suffixes)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f%s", value, suffixes[i])
+}
+
+// signedSize formats a signed byte difference in the given units, e.g.
+// +1.5GiB or -4.0KiB
+func signedSize(bytes int64, units string) string {
+	if bytes < 0 {
+		return "-" + FormatSize(uint64(-bytes), units)
+	}
+	return "+" + FormatSize(uint64(bytes), units)
+}
+
+// escapeControl replaces control characters with their Go escape
+// sequences, e.g. a newline with \n, so a value prints on a single line
+func escapeControl(s string) string {
+	if strings.IndexFunc(s, unicode.IsControl) < 0 {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			quoted := strconv.QuoteRune(r)
+			b.WriteString(quoted[1 : len(quoted)-1])
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// dashIfEmpty returns "-" for empty table cells
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// sortedKeys returns the keys of m in order, so labels print stably
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TruncateString truncates a string to the specified number of characters
 func TruncateString(s string, maxLen int) string {
 	if maxLen <= 0 {
 		return ""
 	}
-	if len(s) <= maxLen {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
 		return s
 	}
 	if maxLen <= 3 {
 		if maxLen <= 1 {
-			return s[:1]
+			return string(runes[:1])
 		}
-		return s[:maxLen-1] + "."
+		return string(runes[:maxLen-1]) + "."
 	}
-	return s[:maxLen-3] + "..."
-}
-
-// truncateString truncates a string to the specified length (internal function)
-func truncateString(s string, maxLen int) string {
-	return TruncateString(s, maxLen)
+	return string(runes[:maxLen-3]) + "..."
 }
\ No newline at end of file
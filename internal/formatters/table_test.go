@@ -1,6 +1,8 @@
 package formatters
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 	"time"
 
@@ -259,4 +261,216 @@ func TestSnapshotKindString(t *testing.T) {
 			}
 		})
 	}
+}
+
+func TestSignedSize(t *testing.T) {
+	tests := []struct {
+		bytes    int64
+		expected string
+	}{
+		{0, "+0B"},
+		{2048, "+2.0KiB"},
+		{-1536, "-1.5KiB"},
+	}
+
+	for _, tt := range tests {
+		if result := signedSize(tt.bytes, UnitsIEC); result != tt.expected {
+			t.Errorf("signedSize(%d) = %s, expected %s", tt.bytes, result, tt.expected)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		bytes    uint64
+		units    string
+		expected string
+	}{
+		{0, UnitsIEC, "0B"},
+		{1023, UnitsIEC, "1023B"},
+		{1024, UnitsIEC, "1.0KiB"},
+		{1536, UnitsIEC, "1.5KiB"},
+		{10 << 30, UnitsIEC, "10.0GiB"},
+		{3 << 40, UnitsIEC, "3.0TiB"},
+		{1536, "", "1.5KiB"},
+		{1536, UnitsSI, "1.5kB"},
+		{999, UnitsSI, "999B"},
+		{20 << 30, UnitsSI, "21.5GB"},
+		{20 << 30, UnitsBytes, "21474836480"},
+	}
+
+	for _, tt := range tests {
+		if result := FormatSize(tt.bytes, tt.units); result != tt.expected {
+			t.Errorf("FormatSize(%d, %q) = %s, expected %s", tt.bytes, tt.units, result, tt.expected)
+		}
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago      time.Duration
+		expected string
+	}{
+		{0, "0s ago"},
+		{45 * time.Second, "45s ago"},
+		{5 * time.Minute, "5m ago"},
+		{47 * time.Hour, "47h ago"},
+		{3 * 24 * time.Hour, "3d ago"},
+		{800 * 24 * time.Hour, "2y ago"},
+		{-2 * time.Hour, "in 2h"},
+	}
+
+	for _, tt := range tests {
+		if result := RelativeTime(now.Add(-tt.ago), now); result != tt.expected {
+			t.Errorf("RelativeTime(now-%v) = %s, expected %s", tt.ago, result, tt.expected)
+		}
+	}
+}
+
+// newTestTableFormatter creates a table formatter writing to buf at a fixed time
+func newTestTableFormatter(opts TableOptions, buf *bytes.Buffer, now time.Time) *TableFormatter {
+	f := NewTableFormatterWithOptions(opts)
+	f.writer = buf
+	f.now = func() time.Time { return now }
+	return f
+}
+
+func TestTableFormatter_Fit(t *testing.T) {
+	storage := []database.DevboxStorageInfo{
+		{ContentID: "0123456789abcdef0123", LvName: "lv-1", Path: "/var/lib/devbox/storage/0123456789abcdef0123", Status: "active"},
+	}
+
+	t.Run("unfitted", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := newTestTableFormatter(TableOptions{}, &buf, time.Now()).FormatDevboxStorage(storage); err != nil {
+			t.Fatalf("FormatDevboxStorage failed: %v", err)
+		}
+		if !strings.Contains(buf.String(), storage[0].Path) || !strings.Contains(buf.String(), storage[0].ContentID) {
+			t.Errorf("Expected full cells, got:\n%s", buf.String())
+		}
+	})
+
+	t.Run("fitted to width", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := newTestTableFormatter(TableOptions{Width: 50}, &buf, time.Now()).FormatDevboxStorage(storage); err != nil {
+			t.Fatalf("FormatDevboxStorage failed: %v", err)
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			if len(line) > 50 {
+				t.Errorf("Expected lines of at most 50 columns, got %d: %q", len(line), line)
+			}
+		}
+		// The widest columns are narrowed first, short ones are left alone
+		for _, s := range []string{"0123456789ab...", "/var/lib/devb...", "lv-1", "active"} {
+			if !strings.Contains(buf.String(), s) {
+				t.Errorf("Expected %q in:\n%s", s, buf.String())
+			}
+		}
+	})
+
+	t.Run("no-trunc", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := newTestTableFormatter(TableOptions{Width: 50, NoTrunc: true}, &buf, time.Now()).FormatDevboxStorage(storage); err != nil {
+			t.Fatalf("FormatDevboxStorage failed: %v", err)
+		}
+		if !strings.Contains(buf.String(), storage[0].Path) {
+			t.Errorf("Expected the full path, got:\n%s", buf.String())
+		}
+	})
+}
+
+func TestTableFormatter_Color(t *testing.T) {
+	storage := []database.DevboxStorageInfo{
+		{ContentID: "content-1", LvName: "lv-1", Path: "/active", Status: "active"},
+		{ContentID: "content-2", LvName: "lv-2", Path: "/removed", Status: "removed"},
+		{ContentID: "content-3", LvName: "lv-3", Path: "/other", Status: "pending"},
+	}
+
+	var buf bytes.Buffer
+	if err := newTestTableFormatter(TableOptions{Color: true}, &buf, time.Now()).FormatDevboxStorage(storage); err != nil {
+		t.Fatalf("FormatDevboxStorage failed: %v", err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasSuffix(lines[1], colorGreen+"active"+colorReset) || !strings.HasSuffix(lines[2], colorYellow+"removed"+colorReset) {
+		t.Errorf("Expected colored statuses, got:\n%q", buf.String())
+	}
+	if !strings.HasSuffix(lines[3], "pending") || strings.Contains(lines[0], "\x1b") {
+		t.Errorf("Expected unknown statuses and the header uncolored, got:\n%q", buf.String())
+	}
+
+	// Columns stay aligned, as padding ignores the escape sequences
+	if strings.Index(lines[1], "/active") != strings.Index(lines[2], "/removed") {
+		t.Errorf("Expected aligned columns, got:\n%s", buf.String())
+	}
+}
+
+func TestTableFormatter_ControlCharacters(t *testing.T) {
+	snapshotList := []database.SnapshotInfo{
+		{Key: "layer-1", ID: 1, Labels: map[string]string{"note": "line 1\nline 2\tend"}},
+		{Key: "layer-2", ID: 2},
+	}
+
+	var buf bytes.Buffer
+	if err := newTestTableFormatter(TableOptions{Wide: true}, &buf, time.Now()).FormatSnapshots(snapshotList); err != nil {
+		t.Fatalf("FormatSnapshots failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and one line per snapshot, got:\n%s", buf.String())
+	}
+	if !strings.Contains(lines[1], `note=line 1\nline 2\tend`) {
+		t.Errorf("Expected escaped control characters, got %q", lines[1])
+	}
+}
+
+func TestTableFormatter_FormatSnapshots(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	snapshotList := []database.SnapshotInfo{
+		{Key: "layer-1", ID: 1, Kind: snapshots.KindCommitted, CreatedAt: now.Add(-72 * time.Hour), UpdatedAt: now.Add(-time.Hour),
+			Size: 20 << 30, Labels: map[string]string{"b": "2", "a": "1"}},
+	}
+	shanghai := time.FixedZone("CST", 8*3600)
+
+	var buf bytes.Buffer
+	if err := newTestTableFormatter(TableOptions{Units: UnitsSI}, &buf, now).FormatSnapshots(snapshotList); err != nil {
+		t.Fatalf("FormatSnapshots failed: %v", err)
+	}
+	for _, s := range []string{"AGE", "3d ago", "21.5GB"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("Expected %q in:\n%s", s, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "LABELS") {
+		t.Errorf("Expected no LABELS column without wide, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := newTestTableFormatter(TableOptions{Wide: true, Location: shanghai}, &buf, now).FormatSnapshots(snapshotList); err != nil {
+		t.Fatalf("FormatSnapshots failed: %v", err)
+	}
+	for _, s := range []string{"CREATED", "UPDATED", "LABELS", "2024-05-29 20:00:00 CST", "2024-06-01 19:00:00 CST", "a=1,b=2"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("Expected %q in wide output:\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestTableFormatter_FormatSnapshot(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	snapshot := &database.SnapshotInfo{
+		Key: "layer-1", ID: 1, Kind: snapshots.KindCommitted, CreatedAt: now.Add(-72 * time.Hour), Size: 1536,
+		Labels: map[string]string{"c": "3", "a": "1", "b": "2"},
+	}
+
+	var buf bytes.Buffer
+	if err := newTestTableFormatter(TableOptions{Location: time.UTC}, &buf, now).FormatSnapshot(snapshot); err != nil {
+		t.Fatalf("FormatSnapshot failed: %v", err)
+	}
+	out := buf.String()
+	for _, s := range []string{"Created:  2024-05-29 12:00:00 UTC (3d ago)", "Updated:  -", "Size:     1.5KiB (1536 bytes)", "  a: 1\n  b: 2\n  c: 3\n"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected %q in:\n%s", s, out)
+		}
+	}
 }
\ No newline at end of file
@@ -0,0 +1,175 @@
+package formatters
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+)
+
+// GoTemplateFormatter formats output with a Go text/template. The template
+// is executed on the JSON encoding of the data, so fields are accessed by
+// their JSON names, e.g. {{range .}}{{.key}} {{.size}}{{"\n"}}{{end}}.
+type GoTemplateFormatter struct {
+	template *template.Template
+	writer   io.Writer
+}
+
+// NewGoTemplateFormatter parses text and creates a new Go template formatter
+func NewGoTemplateFormatter(text string) (*GoTemplateFormatter, error) {
+	tmpl, err := template.New("output").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go-template: %w", err)
+	}
+	return &GoTemplateFormatter{template: tmpl, writer: os.Stdout}, nil
+}
+
+// Format executes the template on data
+func (f *GoTemplateFormatter) Format(data interface{}) error {
+	value, err := toJSONValue(data)
+	if err != nil {
+		return err
+	}
+	if err := f.template.Execute(f.writer, value); err != nil {
+		return fmt.Errorf("failed to execute go-template: %w", err)
+	}
+	return nil
+}
+
+// JSONPathFormatter formats output with a kubectl style JSONPath template
+// executed on the JSON encoding of the data
+type JSONPathFormatter struct {
+	path   *JSONPath
+	writer io.Writer
+}
+
+// NewJSONPathFormatter parses text and creates a new JSONPath formatter
+func NewJSONPathFormatter(text string) (*JSONPathFormatter, error) {
+	path, err := ParseJSONPath(text)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONPathFormatter{path: path, writer: os.Stdout}, nil
+}
+
+// Format executes the template on data
+func (f *JSONPathFormatter) Format(data interface{}) error {
+	value, err := toJSONValue(data)
+	if err != nil {
+		return err
+	}
+	text, err := f.path.Execute(value)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(f.writer, text)
+	return nil
+}
+
+// column is a custom column header and the path selecting its values
+type column struct {
+	header string
+	path   []pathStep
+}
+
+// CustomColumnsFormatter formats output as a table whose columns are chosen
+// by JSONPath expressions, e.g. KEY:.key,SIZE:.size
+type CustomColumnsFormatter struct {
+	columns []column
+	writer  io.Writer
+}
+
+// NewCustomColumnsFormatter creates a new custom columns formatter from a
+// HEADER:path list separated by commas
+func NewCustomColumnsFormatter(spec string) (*CustomColumnsFormatter, error) {
+	var headers, paths []string
+	for _, part := range strings.Split(spec, ",") {
+		header, path, ok := strings.Cut(part, ":")
+		if !ok || header == "" {
+			return nil, fmt.Errorf("custom-columns: expected HEADER:path, got %q", part)
+		}
+		headers = append(headers, header)
+		paths = append(paths, path)
+	}
+	return newCustomColumnsFormatter(headers, paths)
+}
+
+// NewCustomColumnsFileFormatter creates a new custom columns formatter from
+// the content of a columns file: a line of headers followed by a line of
+// paths, both separated by whitespace
+func NewCustomColumnsFileFormatter(content string) (*CustomColumnsFormatter, error) {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) != 2 {
+		return nil, fmt.Errorf("custom-columns file: expected a header line and a path line, got %d lines", len(lines))
+	}
+
+	headers, paths := strings.Fields(lines[0]), strings.Fields(lines[1])
+	if len(headers) != len(paths) {
+		return nil, fmt.Errorf("custom-columns file: %d headers but %d paths", len(headers), len(paths))
+	}
+	return newCustomColumnsFormatter(headers, paths)
+}
+
+func newCustomColumnsFormatter(headers, paths []string) (*CustomColumnsFormatter, error) {
+	columns := make([]column, len(headers))
+	for i, header := range headers {
+		expr := strings.TrimSpace(paths[i])
+		expr = strings.TrimSuffix(strings.TrimPrefix(expr, "{"), "}")
+		steps, err := parsePath(expr)
+		if err != nil {
+			return nil, fmt.Errorf("custom-columns: column %s: %w", header, err)
+		}
+		columns[i] = column{header: header, path: steps}
+	}
+	return &CustomColumnsFormatter{
+		columns: columns,
+		writer:  os.Stdout,
+	}, nil
+}
+
+// Format writes one row per item of a list, or a single row for any other value.
+// Columns selecting nothing show <none>, several values are joined by commas.
+func (f *CustomColumnsFormatter) Format(data interface{}) error {
+	value, err := toJSONValue(data)
+	if err != nil {
+		return err
+	}
+	records, ok := value.([]interface{})
+	if !ok {
+		records = []interface{}{value}
+	}
+
+	writer := tabwriter.NewWriter(f.writer, 0, 0, 2, ' ', 0)
+	headers := make([]string, len(f.columns))
+	for i, c := range f.columns {
+		headers[i] = c.header
+	}
+	fmt.Fprintln(writer, strings.Join(headers, "\t"))
+
+	for _, record := range records {
+		cells := make([]string, len(f.columns))
+		for i, c := range f.columns {
+			var texts []string
+			for _, v := range evalPath(record, c.path) {
+				text, err := jsonValueString(v)
+				if err != nil {
+					return err
+				}
+				texts = append(texts, text)
+			}
+			cells[i] = strings.Join(texts, ",")
+			if len(texts) == 0 {
+				cells[i] = "<none>"
+			}
+		}
+		fmt.Fprintln(writer, strings.Join(cells, "\t"))
+	}
+	return writer.Flush()
+}
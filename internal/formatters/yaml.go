@@ -0,0 +1,62 @@
+package formatters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLFormatter formats output as YAML
+type YAMLFormatter struct {
+	writer io.Writer
+}
+
+// NewYAMLFormatter creates a new YAML formatter
+func NewYAMLFormatter() *YAMLFormatter {
+	return &YAMLFormatter{writer: os.Stdout}
+}
+
+// Format writes data as a YAML document. The data goes through its JSON
+// encoding first, so field names and values match the JSON output.
+func (f *YAMLFormatter) Format(data interface{}) error {
+	output, err := toYAML(data)
+	if err != nil {
+		return err
+	}
+	_, err = f.writer.Write(output)
+	return err
+}
+
+// toYAML converts data to YAML by way of its JSON encoding, keeping the
+// field order of the JSON output
+func toYAML(data interface{}) ([]byte, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	// JSON is valid YAML, decoding it into a node keeps the field order
+	var node yaml.Node
+	if err := yaml.Unmarshal(encoded, &node); err != nil {
+		return nil, fmt.Errorf("failed to convert JSON to YAML: %w", err)
+	}
+	blockStyle(&node)
+
+	output, err := yaml.Marshal(&node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	return output, nil
+}
+
+// blockStyle drops the flow style and quoting taken over from JSON, so the
+// encoder picks the block style and only quotes strings where needed
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
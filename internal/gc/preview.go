@@ -0,0 +1,312 @@
+package gc
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+// Labels interpreted by containerd's garbage collector
+const (
+	LabelGCRoot       = "containerd.io/gc.root"
+	LabelGCExpire     = "containerd.io/gc.expire"
+	LabelGCContentRef = "containerd.io/gc.ref.content"
+	LabelGCSnapRef    = "containerd.io/gc.ref.snapshot."
+)
+
+// SnapshotStatus describes whether a snapshot survives garbage collection
+type SnapshotStatus string
+
+const (
+	// StatusRoot means the snapshot is a GC root (gc.root label, lease, container or image label)
+	StatusRoot SnapshotStatus = "root"
+	// StatusReferenced means the snapshot is reachable from a GC root
+	StatusReferenced SnapshotStatus = "referenced"
+	// StatusUnreferenced means the snapshot would be reclaimed by the next GC run
+	StatusUnreferenced SnapshotStatus = "unreferenced"
+)
+
+// SnapshotResult is the GC verdict for a single snapshotter snapshot
+type SnapshotResult struct {
+	Key       string         `json:"key"`
+	ID        uint64         `json:"id"`
+	Kind      string         `json:"kind"`
+	Namespace string         `json:"namespace,omitempty"`
+	MetaKey   string         `json:"meta_key,omitempty"`
+	Size      int64          `json:"size"`
+	Status    SnapshotStatus `json:"status"`
+	Reasons   []string       `json:"reasons,omitempty"`
+}
+
+// Report summarizes what the garbage collector would keep and reclaim
+type Report struct {
+	Snapshotter     string           `json:"snapshotter"`
+	Snapshots       []SnapshotResult `json:"snapshots"`
+	Roots           int              `json:"roots"`
+	Referenced      int              `json:"referenced"`
+	Unreferenced    int              `json:"unreferenced"`
+	ReclaimableSize int64            `json:"reclaimable_size"`
+}
+
+type nodeType int
+
+const (
+	nodeContent nodeType = iota
+	nodeSnapshot
+)
+
+// node identifies a meta.db resource; snapshot keys are "<snapshotter>/<key>"
+type node struct {
+	typ       nodeType
+	namespace string
+	key       string
+}
+
+// graph tracks the marking state of a preview run
+type graph struct {
+	meta      *database.ContainerdMetadata
+	content   map[node]*database.MetaContent
+	snapshots map[node]*database.MetaSnapshot
+
+	roots   map[node]bool
+	reasons map[node][]string
+	marked  map[node]bool
+}
+
+// Preview simulates containerd's garbage collection over the given meta.db
+// resources and classifies the snapshots of one snapshotter
+func Preview(meta *database.ContainerdMetadata, snapshotter string, snapshots []database.SnapshotInfo, now time.Time) *Report {
+	g := &graph{
+		meta:      meta,
+		content:   make(map[node]*database.MetaContent),
+		snapshots: make(map[node]*database.MetaSnapshot),
+		roots:     make(map[node]bool),
+		reasons:   make(map[node][]string),
+		marked:    make(map[node]bool),
+	}
+
+	for i := range meta.Content {
+		c := &meta.Content[i]
+		g.content[node{nodeContent, c.Namespace, c.Digest}] = c
+	}
+	for i := range meta.Snapshots {
+		s := &meta.Snapshots[i]
+		g.snapshots[node{nodeSnapshot, s.Namespace, s.Snapshotter + "/" + s.Key}] = s
+	}
+
+	g.scanRoots(now)
+	g.mark()
+
+	return g.report(snapshotter, snapshots)
+}
+
+// scanRoots records every resource containerd treats as a GC root
+func (g *graph) scanRoots(now time.Time) {
+	for _, lease := range g.meta.Leases {
+		if exp, ok := lease.Labels[LabelGCExpire]; ok {
+			if t, err := time.Parse(time.RFC3339, exp); err == nil && now.After(t) {
+				continue
+			}
+		}
+		reason := "lease:" + lease.ID
+		for _, dgst := range lease.Content {
+			g.addRoot(node{nodeContent, lease.Namespace, dgst}, reason)
+		}
+		for _, key := range lease.Snapshots {
+			g.addRoot(node{nodeSnapshot, lease.Namespace, key}, reason)
+		}
+	}
+
+	for _, image := range g.meta.Images {
+		reason := "image:" + image.Name
+		if image.Target != "" {
+			g.addRoot(node{nodeContent, image.Namespace, image.Target}, reason)
+		}
+		g.labelRefs(image.Namespace, image.Labels, func(n node) { g.addRoot(n, reason) })
+	}
+
+	for _, content := range g.meta.Content {
+		if _, ok := content.Labels[LabelGCRoot]; ok {
+			g.addRoot(node{nodeContent, content.Namespace, content.Digest}, LabelGCRoot)
+		}
+	}
+
+	for _, container := range g.meta.Containers {
+		reason := "container:" + container.ID
+		if container.Snapshotter != "" {
+			g.addRoot(node{nodeSnapshot, container.Namespace, container.Snapshotter + "/" + container.SnapshotKey}, reason)
+		}
+		g.labelRefs(container.Namespace, container.Labels, func(n node) { g.addRoot(n, reason) })
+	}
+
+	for _, snapshot := range g.meta.Snapshots {
+		if _, ok := snapshot.Labels[LabelGCRoot]; ok {
+			g.addRoot(node{nodeSnapshot, snapshot.Namespace, snapshot.Snapshotter + "/" + snapshot.Key}, LabelGCRoot)
+		}
+	}
+}
+
+func (g *graph) addRoot(n node, reason string) {
+	g.roots[n] = true
+	g.addReason(n, reason)
+}
+
+func (g *graph) addReason(n node, reason string) {
+	for _, r := range g.reasons[n] {
+		if r == reason {
+			return
+		}
+	}
+	g.reasons[n] = append(g.reasons[n], reason)
+}
+
+// mark walks references from the roots, recording who references whom
+func (g *graph) mark() {
+	var queue []node
+	for n := range g.roots {
+		queue = append(queue, n)
+	}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if g.marked[n] {
+			continue
+		}
+		g.marked[n] = true
+
+		g.references(n, func(ref node, reason string) {
+			g.addReason(ref, reason)
+			if !g.marked[ref] {
+				queue = append(queue, ref)
+			}
+		})
+	}
+}
+
+// references calls fn for every resource directly referenced by n
+func (g *graph) references(n node, fn func(node, string)) {
+	switch n.typ {
+	case nodeContent:
+		c, ok := g.content[n]
+		if !ok {
+			return
+		}
+		reason := "content:" + c.Digest
+		g.labelRefs(n.namespace, c.Labels, func(ref node) { fn(ref, reason) })
+	case nodeSnapshot:
+		s, ok := g.snapshots[n]
+		if !ok {
+			return
+		}
+		reason := "snapshot:" + s.Key
+		if s.Parent != "" {
+			fn(node{nodeSnapshot, n.namespace, s.Snapshotter + "/" + s.Parent}, "child:"+s.Key)
+		}
+		g.labelRefs(n.namespace, s.Labels, func(ref node) { fn(ref, reason) })
+	}
+}
+
+// labelRefs decodes gc.ref.content and gc.ref.snapshot labels into nodes
+func (g *graph) labelRefs(ns string, labels map[string]string, fn func(node)) {
+	for k, v := range labels {
+		switch {
+		case strings.HasPrefix(k, LabelGCContentRef):
+			if rest := k[len(LabelGCContentRef):]; rest != "" && rest[0] != '.' && rest[0] != '/' {
+				continue
+			}
+			fn(node{nodeContent, ns, v})
+		case strings.HasPrefix(k, LabelGCSnapRef):
+			snapshotter := k[len(LabelGCSnapRef):]
+			if i := strings.IndexByte(snapshotter, '/'); i >= 0 {
+				snapshotter = snapshotter[:i]
+			}
+			fn(node{nodeSnapshot, ns, snapshotter + "/" + v})
+		}
+	}
+}
+
+// report maps marked meta.db snapshots onto the snapshotter's own snapshots
+func (g *graph) report(snapshotter string, snapshots []database.SnapshotInfo) *Report {
+	report := &Report{Snapshotter: snapshotter}
+
+	// Index meta.db snapshots of this snapshotter by their backend name
+	byName := make(map[string][]node)
+	for n, s := range g.snapshots {
+		if s.Snapshotter == snapshotter && s.Name != "" {
+			byName[s.Name] = append(byName[s.Name], n)
+		}
+	}
+
+	results := make(map[string]*SnapshotResult, len(snapshots))
+	for _, info := range snapshots {
+		result := &SnapshotResult{
+			Key:    info.Key,
+			ID:     info.ID,
+			Kind:   database.SnapshotKindString(info.Kind),
+			Size:   info.Size,
+			Status: StatusUnreferenced,
+		}
+
+		nodes := byName[info.Key]
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].key < nodes[j].key })
+		for _, n := range nodes {
+			if result.MetaKey == "" {
+				result.Namespace = n.namespace
+				result.MetaKey = g.snapshots[n].Key
+			}
+			if !g.marked[n] {
+				continue
+			}
+			if g.roots[n] {
+				result.Status = StatusRoot
+			} else if result.Status != StatusRoot {
+				result.Status = StatusReferenced
+			}
+			result.Reasons = append(result.Reasons, g.reasons[n]...)
+		}
+		sort.Strings(result.Reasons)
+		if len(nodes) == 0 {
+			result.Reasons = []string{"no metadata reference"}
+		}
+
+		results[info.Key] = result
+	}
+
+	// A snapshot backing a live child must stay, even without a meta.db edge
+	parents := make(map[string]string, len(snapshots))
+	for _, info := range snapshots {
+		parents[info.Key] = info.Parent
+	}
+	for _, info := range snapshots {
+		if results[info.Key].Status == StatusUnreferenced {
+			continue
+		}
+		for child, parent := info.Key, info.Parent; parent != ""; child, parent = parent, parents[parent] {
+			p, ok := results[parent]
+			if !ok || p.Status != StatusUnreferenced {
+				break
+			}
+			p.Status = StatusReferenced
+			p.Reasons = []string{"child:" + child}
+		}
+	}
+
+	for _, info := range snapshots {
+		result := results[info.Key]
+		switch result.Status {
+		case StatusRoot:
+			report.Roots++
+		case StatusReferenced:
+			report.Referenced++
+		case StatusUnreferenced:
+			report.Unreferenced++
+			report.ReclaimableSize += result.Size
+		}
+		report.Snapshots = append(report.Snapshots, *result)
+	}
+
+	return report
+}
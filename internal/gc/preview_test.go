@@ -0,0 +1,164 @@
+package gc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+func findResult(t *testing.T, report *Report, key string) SnapshotResult {
+	t.Helper()
+	for _, result := range report.Snapshots {
+		if result.Key == key {
+			return result
+		}
+	}
+	t.Fatalf("Expected snapshot %s in report", key)
+	return SnapshotResult{}
+}
+
+func TestPreview(t *testing.T) {
+	now := time.Date(2024, 11, 2, 10, 0, 0, 0, time.UTC)
+
+	meta := &database.ContainerdMetadata{
+		Snapshots: []database.MetaSnapshot{
+			{Namespace: "k8s.io", Snapshotter: "devbox", Key: "layer-1", Name: "k8s.io/1/layer-1"},
+			{Namespace: "k8s.io", Snapshotter: "devbox", Key: "layer-2", Name: "k8s.io/2/layer-2", Parent: "layer-1"},
+			{Namespace: "k8s.io", Snapshotter: "devbox", Key: "rootfs", Name: "k8s.io/3/rootfs", Parent: "layer-2"},
+			{Namespace: "k8s.io", Snapshotter: "devbox", Key: "pinned", Name: "k8s.io/4/pinned",
+				Labels: map[string]string{LabelGCRoot: "2024-11-01T00:00:00Z"}},
+			{Namespace: "k8s.io", Snapshotter: "devbox", Key: "by-content", Name: "k8s.io/5/by-content"},
+			{Namespace: "k8s.io", Snapshotter: "devbox", Key: "leased", Name: "k8s.io/6/leased"},
+			{Namespace: "k8s.io", Snapshotter: "devbox", Key: "expired", Name: "k8s.io/7/expired"},
+			{Namespace: "k8s.io", Snapshotter: "devbox", Key: "garbage", Name: "k8s.io/8/garbage"},
+		},
+		Content: []database.MetaContent{
+			{Namespace: "k8s.io", Digest: "sha256:config", Labels: map[string]string{
+				"containerd.io/gc.ref.snapshot.devbox": "by-content",
+			}},
+		},
+		Images: []database.MetaImage{
+			{Namespace: "k8s.io", Name: "docker.io/library/busybox:latest", Target: "sha256:config"},
+		},
+		Containers: []database.MetaContainer{
+			{Namespace: "k8s.io", ID: "web", Snapshotter: "devbox", SnapshotKey: "rootfs"},
+		},
+		Leases: []database.MetaLease{
+			{Namespace: "k8s.io", ID: "pull", Snapshots: []string{"devbox/leased"}},
+			{Namespace: "k8s.io", ID: "old", Snapshots: []string{"devbox/expired"},
+				Labels: map[string]string{LabelGCExpire: "2024-11-01T00:00:00Z"}},
+		},
+	}
+
+	var snapshotList []database.SnapshotInfo
+	for i, key := range []string{"layer-1", "layer-2", "rootfs", "pinned", "by-content", "leased", "expired", "garbage"} {
+		snapshotList = append(snapshotList, database.SnapshotInfo{
+			Key:  "k8s.io/" + string(rune('1'+i)) + "/" + key,
+			ID:   uint64(i + 1),
+			Kind: snapshots.KindCommitted,
+			Size: 1024,
+		})
+	}
+	snapshotList = append(snapshotList, database.SnapshotInfo{Key: "stray", ID: 99, Kind: snapshots.KindActive, Size: 4096})
+
+	report := Preview(meta, "devbox", snapshotList, now)
+
+	tests := []struct {
+		key    string
+		status SnapshotStatus
+		reason string
+	}{
+		{"k8s.io/1/layer-1", StatusReferenced, "child:layer-2"},
+		{"k8s.io/2/layer-2", StatusReferenced, "child:rootfs"},
+		{"k8s.io/3/rootfs", StatusRoot, "container:web"},
+		{"k8s.io/4/pinned", StatusRoot, LabelGCRoot},
+		{"k8s.io/5/by-content", StatusReferenced, "content:sha256:config"},
+		{"k8s.io/6/leased", StatusRoot, "lease:pull"},
+		{"k8s.io/7/expired", StatusUnreferenced, ""},
+		{"k8s.io/8/garbage", StatusUnreferenced, ""},
+		{"stray", StatusUnreferenced, "no metadata reference"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			result := findResult(t, report, tt.key)
+			if result.Status != tt.status {
+				t.Errorf("Expected status = %s, got %s", tt.status, result.Status)
+			}
+			if tt.reason == "" {
+				if len(result.Reasons) != 0 {
+					t.Errorf("Expected no reasons, got %v", result.Reasons)
+				}
+				return
+			}
+			found := false
+			for _, reason := range result.Reasons {
+				if reason == tt.reason {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Expected reason %s, got %v", tt.reason, result.Reasons)
+			}
+		})
+	}
+
+	if report.Roots != 3 {
+		t.Errorf("Expected 3 roots, got %d", report.Roots)
+	}
+	if report.Referenced != 3 {
+		t.Errorf("Expected 3 referenced, got %d", report.Referenced)
+	}
+	if report.Unreferenced != 3 {
+		t.Errorf("Expected 3 unreferenced, got %d", report.Unreferenced)
+	}
+	if report.ReclaimableSize != 1024+1024+4096 {
+		t.Errorf("Expected reclaimable size = %d, got %d", 1024+1024+4096, report.ReclaimableSize)
+	}
+}
+
+func TestPreviewBackendParentChain(t *testing.T) {
+	meta := &database.ContainerdMetadata{
+		Snapshots: []database.MetaSnapshot{
+			{Namespace: "default", Snapshotter: "devbox", Key: "child", Name: "default/2/child",
+				Labels: map[string]string{LabelGCRoot: "now"}},
+		},
+	}
+
+	snapshotList := []database.SnapshotInfo{
+		{Key: "default/1/base", ID: 1, Kind: snapshots.KindCommitted, Size: 100},
+		{Key: "default/2/child", ID: 2, Kind: snapshots.KindActive, Parent: "default/1/base", Size: 200},
+	}
+
+	report := Preview(meta, "devbox", snapshotList, time.Now())
+
+	base := findResult(t, report, "default/1/base")
+	if base.Status != StatusReferenced {
+		t.Errorf("Expected backend parent to be referenced, got %s", base.Status)
+	}
+	if report.ReclaimableSize != 0 {
+		t.Errorf("Expected nothing reclaimable, got %d", report.ReclaimableSize)
+	}
+}
+
+func TestPreviewOtherSnapshotter(t *testing.T) {
+	meta := &database.ContainerdMetadata{
+		Snapshots: []database.MetaSnapshot{
+			{Namespace: "default", Snapshotter: "overlayfs", Key: "rootfs", Name: "default/1/rootfs"},
+		},
+		Containers: []database.MetaContainer{
+			{Namespace: "default", ID: "c1", Snapshotter: "overlayfs", SnapshotKey: "rootfs"},
+		},
+	}
+
+	snapshotList := []database.SnapshotInfo{
+		{Key: "default/1/rootfs", ID: 1, Kind: snapshots.KindActive, Size: 10},
+	}
+
+	report := Preview(meta, "devbox", snapshotList, time.Now())
+	if result := findResult(t, report, "default/1/rootfs"); result.Status != StatusUnreferenced {
+		t.Errorf("Expected snapshot of another snapshotter to be ignored, got %s", result.Status)
+	}
+}
@@ -0,0 +1,150 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+// Node types
+const (
+	NodeSnapshot = "snapshot"
+	NodeStorage  = "storage"
+)
+
+// Node is a snapshot or a devbox storage entry in the lineage graph
+type Node struct {
+	ID        string
+	Type      string
+	Key       string // Snapshot key, or content ID of storage entries
+	Kind      string
+	Size      int64
+	ContentID string
+	LvName    string
+	Status    string
+}
+
+// Edge links a parent snapshot to its child, or a snapshot to its storage entry
+type Edge struct {
+	From    string
+	To      string
+	Storage bool
+}
+
+// Graph is the snapshot lineage graph
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+// Options select the part of the lineage that is graphed
+type Options struct {
+	RootKey   string // Only the snapshot with this key and its descendants
+	Namespace string // Only snapshots whose key starts with "<namespace>/"
+	Storage   bool   // Add devbox storage entries linked by content ID
+}
+
+// Build creates the lineage graph of infos. Snapshots are ordered by ID and
+// edges only link nodes that are part of the graph, so a snapshot whose
+// parent was filtered out or is missing starts a tree of its own.
+func Build(infos []database.SnapshotInfo, storage []database.DevboxStorageInfo, opts Options) (*Graph, error) {
+	var selected []database.SnapshotInfo
+	for _, info := range infos {
+		if opts.Namespace == "" || strings.HasPrefix(info.Key, opts.Namespace+"/") {
+			selected = append(selected, info)
+		}
+	}
+
+	if opts.RootKey != "" {
+		var err error
+		selected, err = subtree(selected, opts.RootKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].ID < selected[j].ID
+	})
+
+	g := &Graph{}
+	ids := make(map[string]string, len(selected))
+	for _, info := range selected {
+		id := "s" + strconv.FormatUint(info.ID, 10)
+		ids[info.Key] = id
+		g.Nodes = append(g.Nodes, Node{
+			ID:        id,
+			Type:      NodeSnapshot,
+			Key:       info.Key,
+			Kind:      database.SnapshotKindString(info.Kind),
+			Size:      info.Size,
+			ContentID: info.ContentID,
+		})
+	}
+	for _, info := range selected {
+		if parent, ok := ids[info.Parent]; ok && info.Parent != "" {
+			g.Edges = append(g.Edges, Edge{From: parent, To: ids[info.Key]})
+		}
+	}
+
+	if opts.Storage {
+		users := make(map[string][]string)
+		for _, info := range selected {
+			if info.ContentID != "" {
+				users[info.ContentID] = append(users[info.ContentID], ids[info.Key])
+			}
+		}
+		filtered := opts.RootKey != "" || opts.Namespace != ""
+		for i, item := range storage {
+			// Entries no selected snapshot references only belong to the unfiltered graph
+			if len(users[item.ContentID]) == 0 && filtered {
+				continue
+			}
+			id := "c" + strconv.Itoa(i+1)
+			g.Nodes = append(g.Nodes, Node{
+				ID:        id,
+				Type:      NodeStorage,
+				Key:       item.ContentID,
+				ContentID: item.ContentID,
+				LvName:    item.LvName,
+				Status:    item.Status,
+			})
+			for _, user := range users[item.ContentID] {
+				g.Edges = append(g.Edges, Edge{From: user, To: id, Storage: true})
+			}
+		}
+	}
+	return g, nil
+}
+
+// subtree returns the snapshot with the given key and all its descendants
+func subtree(infos []database.SnapshotInfo, rootKey string) ([]database.SnapshotInfo, error) {
+	children := make(map[string][]database.SnapshotInfo)
+	var root *database.SnapshotInfo
+	for i, info := range infos {
+		if info.Key == rootKey {
+			root = &infos[i]
+		}
+		if info.Parent != "" {
+			children[info.Parent] = append(children[info.Parent], info)
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("snapshot %s not found", rootKey)
+	}
+
+	result := []database.SnapshotInfo{*root}
+	visited := map[string]bool{root.Key: true}
+	for i := 0; i < len(result); i++ {
+		for _, child := range children[result[i].Key] {
+			if !visited[child.Key] {
+				visited[child.Key] = true
+				result = append(result, child)
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,169 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/formatters"
+)
+
+var testSnapshots = []database.SnapshotInfo{
+	{Key: "default/3/container", ID: 3, Kind: snapshots.KindActive, Parent: "default/2/layer", Size: 4096, ContentID: "content-1"},
+	{Key: "default/1/base", ID: 1, Kind: snapshots.KindCommitted, Size: 1 << 20},
+	{Key: "default/2/layer", ID: 2, Kind: snapshots.KindCommitted, Parent: "default/1/base", Size: 2 << 20},
+	{Key: "k8s.io/4/view \"x\"", ID: 4, Kind: snapshots.KindView, Parent: "default/1/base"},
+}
+
+var testStorage = []database.DevboxStorageInfo{
+	{ContentID: "content-1", LvName: "lv-1", Status: "active"},
+	{ContentID: "content-2", LvName: "lv-2", Status: "removed"},
+}
+
+func nodeIDs(g *Graph) []string {
+	var ids []string
+	for _, n := range g.Nodes {
+		ids = append(ids, n.ID)
+	}
+	return ids
+}
+
+func TestBuild(t *testing.T) {
+	g, err := Build(testSnapshots, testStorage, Options{Storage: true})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if ids := nodeIDs(g); !reflect.DeepEqual(ids, []string{"s1", "s2", "s3", "s4", "c1", "c2"}) {
+		t.Errorf("Expected snapshots ordered by ID, then storage, got %v", ids)
+	}
+	expected := []Edge{{From: "s1", To: "s2"}, {From: "s2", To: "s3"}, {From: "s1", To: "s4"}, {From: "s3", To: "c1", Storage: true}}
+	if !reflect.DeepEqual(g.Edges, expected) {
+		t.Errorf("Expected edges %v, got %v", expected, g.Edges)
+	}
+}
+
+func TestBuild_Filters(t *testing.T) {
+	g, err := Build(testSnapshots, testStorage, Options{RootKey: "default/2/layer", Storage: true})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if ids := nodeIDs(g); !reflect.DeepEqual(ids, []string{"s2", "s3", "c1"}) {
+		t.Errorf("Expected the subtree of default/2/layer and its storage, got %v", ids)
+	}
+
+	g, err = Build(testSnapshots, nil, Options{Namespace: "k8s.io"})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if ids := nodeIDs(g); !reflect.DeepEqual(ids, []string{"s4"}) || len(g.Edges) != 0 {
+		t.Errorf("Expected only the k8s.io snapshot without edges, got %v %v", ids, g.Edges)
+	}
+
+	if _, err := Build(testSnapshots, nil, Options{RootKey: "missing"}); err == nil {
+		t.Error("Expected an error for an unknown root key")
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	g, _ := Build(testSnapshots, testStorage, Options{Storage: true})
+	var buf bytes.Buffer
+	if err := WriteDOT(&buf, g, formatters.TableOptions{}); err != nil {
+		t.Fatalf("WriteDOT failed: %v", err)
+	}
+	out := buf.String()
+
+	expected := []string{
+		`s1 [label="default/1/base\ncommitted, 1.0MiB", shape=box, style=filled`,
+		`s3 [label="default/3/container\nactive, 4.0KiB\ncontent: content-1", shape=box, style="filled,bold"`,
+		`s4 [label="k8s.io/4/view \"x\"\nview, 0B", shape=box, style="filled,dashed"`,
+		`c2 [label="content-2\nlv-2\nremoved", shape=cylinder`,
+		"s1 -> s2;",
+		"s3 -> c1 [style=dashed, arrowhead=none];",
+	}
+	for _, s := range expected {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected DOT output to contain %q\n%s", s, out)
+		}
+	}
+
+	// Sizes follow --units
+	buf.Reset()
+	if err := Write(&buf, g, "dot", formatters.TableOptions{Units: formatters.UnitsSI}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if s := `s1 [label="default/1/base\ncommitted, 1.0MB"`; !strings.Contains(buf.String(), s) {
+		t.Errorf("Expected DOT output in SI units to contain %q\n%s", s, buf.String())
+	}
+}
+
+func TestWriteMermaid(t *testing.T) {
+	g, _ := Build(testSnapshots, testStorage, Options{Storage: true})
+	var buf bytes.Buffer
+	if err := WriteMermaid(&buf, g, formatters.TableOptions{}); err != nil {
+		t.Fatalf("WriteMermaid failed: %v", err)
+	}
+	out := buf.String()
+
+	expected := []string{
+		"flowchart TB\n",
+		`s4["k8s.io/4/view #quot;x#quot;<br/>view, 0B"]`,
+		`c1[("content-1<br/>lv-1<br/>active")]`,
+		"s2 --> s3",
+		"s3 -.- c1",
+		"class s3 active",
+		"class c1 storage",
+	}
+	for _, s := range expected {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected Mermaid output to contain %q\n%s", s, out)
+		}
+	}
+}
+
+func TestWriteGraphML(t *testing.T) {
+	g, _ := Build(testSnapshots, testStorage, Options{Storage: true})
+	var buf bytes.Buffer
+	if err := WriteGraphML(&buf, g); err != nil {
+		t.Fatalf("WriteGraphML failed: %v", err)
+	}
+
+	var doc struct {
+		Graph struct {
+			Nodes []struct {
+				ID   string `xml:"id,attr"`
+				Data []struct {
+					Key   string `xml:"key,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"data"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected well-formed GraphML: %v\n%s", err, buf.String())
+	}
+	if len(doc.Graph.Nodes) != 6 || len(doc.Graph.Edges) != 4 {
+		t.Fatalf("Expected 6 nodes and 4 edges, got %d and %d", len(doc.Graph.Nodes), len(doc.Graph.Edges))
+	}
+
+	data := make(map[string]string)
+	for _, d := range doc.Graph.Nodes[3].Data {
+		data[d.Key] = d.Value
+	}
+	if data["key"] != `k8s.io/4/view "x"` || data["kind"] != "view" || data["size"] != "0" {
+		t.Errorf("Unexpected data of s4: %v", data)
+	}
+}
+
+func TestWrite_InvalidFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, &Graph{}, "svg", formatters.TableOptions{}); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,198 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/meta-viewer/internal/formatters"
+)
+
+// Formats lists the supported graph formats
+var Formats = []string{"dot", "mermaid", "graphml"}
+
+// Write renders the graph in the named format. Sizes in node labels are
+// written in the units of opts.
+func Write(w io.Writer, g *Graph, format string, opts formatters.TableOptions) error {
+	switch format {
+	case "dot":
+		return WriteDOT(w, g, opts)
+	case "mermaid":
+		return WriteMermaid(w, g, opts)
+	case "graphml":
+		return WriteGraphML(w, g)
+	default:
+		return fmt.Errorf("invalid graph format '%s'. Use one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// label returns the lines describing a node: the key, then the size and
+// content ID of snapshots or the LV name and status of storage entries
+func label(n Node, opts formatters.TableOptions) []string {
+	if n.Type == NodeStorage {
+		lines := []string{n.ContentID}
+		if n.LvName != "" {
+			lines = append(lines, n.LvName)
+		}
+		if n.Status != "" {
+			lines = append(lines, n.Status)
+		}
+		return lines
+	}
+
+	lines := []string{n.Key, n.Kind + ", " + opts.Size(n.Size)}
+	if n.ContentID != "" {
+		lines = append(lines, "content: "+n.ContentID)
+	}
+	return lines
+}
+
+// dotStyles are the Graphviz attributes of each node kind
+var dotStyles = map[string]string{
+	"committed": `shape=box, style=filled, fillcolor="#e1e4e8"`,
+	"active":    `shape=box, style="filled,bold", fillcolor="#ccf0d2"`,
+	"view":      `shape=box, style="filled,dashed", fillcolor="#d8e8fb"`,
+	NodeStorage: `shape=cylinder, style=filled, fillcolor="#fff1c2"`,
+}
+
+// WriteDOT renders the graph in Graphviz DOT, parents above their children
+func WriteDOT(w io.Writer, g *Graph, opts formatters.TableOptions) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "digraph snapshots {")
+	fmt.Fprintln(out, "  rankdir=TB;")
+	fmt.Fprintln(out, `  node [fontname="Helvetica", fontsize=10];`)
+	for _, n := range g.Nodes {
+		style, ok := dotStyles[n.Kind]
+		if n.Type == NodeStorage {
+			style, ok = dotStyles[NodeStorage], true
+		}
+		if !ok {
+			style = "shape=box"
+		}
+		fmt.Fprintf(out, "  %s [label=%s, %s];\n", n.ID, dotQuote(strings.Join(label(n, opts), "\n")), style)
+	}
+	for _, e := range g.Edges {
+		if e.Storage {
+			fmt.Fprintf(out, "  %s -> %s [style=dashed, arrowhead=none];\n", e.From, e.To)
+			continue
+		}
+		fmt.Fprintf(out, "  %s -> %s;\n", e.From, e.To)
+	}
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
+
+// dotQuote returns s as a DOT string, with newlines as centered line breaks
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
+
+// mermaidClasses are the Mermaid class definitions of each node kind
+var mermaidClasses = []string{
+	"classDef committed fill:#e1e4e8,stroke:#57606a",
+	"classDef active fill:#ccf0d2,stroke:#1a7f37,stroke-width:2px",
+	"classDef view fill:#d8e8fb,stroke:#0969da,stroke-dasharray:4",
+	"classDef storage fill:#fff1c2,stroke:#9a6700",
+}
+
+// WriteMermaid renders the graph as a Mermaid flowchart
+func WriteMermaid(w io.Writer, g *Graph, opts formatters.TableOptions) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "flowchart TB")
+	for _, n := range g.Nodes {
+		text := mermaidText(strings.Join(label(n, opts), "<br/>"))
+		if n.Type == NodeStorage {
+			fmt.Fprintf(out, "  %s[(\"%s\")]\n", n.ID, text)
+			continue
+		}
+		fmt.Fprintf(out, "  %s[\"%s\"]\n", n.ID, text)
+	}
+	for _, e := range g.Edges {
+		if e.Storage {
+			fmt.Fprintf(out, "  %s -.- %s\n", e.From, e.To)
+			continue
+		}
+		fmt.Fprintf(out, "  %s --> %s\n", e.From, e.To)
+	}
+	for _, class := range mermaidClasses {
+		fmt.Fprintf(out, "  %s\n", class)
+	}
+	for _, n := range g.Nodes {
+		class := n.Kind
+		if n.Type == NodeStorage {
+			class = "storage"
+		}
+		if class != "" && class != "unknown" {
+			fmt.Fprintf(out, "  class %s %s\n", n.ID, class)
+		}
+	}
+	return out.Flush()
+}
+
+// mermaidText escapes the characters that end or break a quoted Mermaid label
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<br/>", "<br/>", "<", "#lt;", ">", "#gt;").Replace(s)
+}
+
+// graphMLKeys are the node and edge attributes declared in GraphML output
+var graphMLKeys = []struct{ id, domain, name, typ string }{
+	{"type", "node", "type", "string"},
+	{"key", "node", "key", "string"},
+	{"kind", "node", "kind", "string"},
+	{"size", "node", "size", "long"},
+	{"content_id", "node", "content_id", "string"},
+	{"lv_name", "node", "lv_name", "string"},
+	{"status", "node", "status", "string"},
+	{"relation", "edge", "relation", "string"},
+}
+
+// WriteGraphML renders the graph as GraphML, e.g. for Gephi or yEd
+func WriteGraphML(w io.Writer, g *Graph) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(out, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	for _, k := range graphMLKeys {
+		fmt.Fprintf(out, "  <key id=\"%s\" for=\"%s\" attr.name=\"%s\" attr.type=\"%s\"/>\n", k.id, k.domain, k.name, k.typ)
+	}
+	fmt.Fprintln(out, `  <graph id="snapshots" edgedefault="directed">`)
+
+	for _, n := range g.Nodes {
+		fmt.Fprintf(out, "    <node id=\"%s\">\n", n.ID)
+		data := [][2]string{{"type", n.Type}, {"key", n.Key}}
+		if n.Type == NodeSnapshot {
+			data = append(data, [2]string{"kind", n.Kind}, [2]string{"size", strconv.FormatInt(n.Size, 10)})
+		}
+		data = append(data, [2]string{"content_id", n.ContentID}, [2]string{"lv_name", n.LvName}, [2]string{"status", n.Status})
+		for _, d := range data {
+			if d[1] != "" {
+				fmt.Fprintf(out, "      <data key=\"%s\">%s</data>\n", d[0], xmlText(d[1]))
+			}
+		}
+		fmt.Fprintln(out, "    </node>")
+	}
+	for i, e := range g.Edges {
+		relation := "parent"
+		if e.Storage {
+			relation = "storage"
+		}
+		fmt.Fprintf(out, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i+1, e.From, e.To)
+		fmt.Fprintf(out, "      <data key=\"relation\">%s</data>\n", relation)
+		fmt.Fprintln(out, "    </edge>")
+	}
+
+	fmt.Fprintln(out, "  </graph>")
+	fmt.Fprintln(out, "</graphml>")
+	return out.Flush()
+}
+
+// xmlText escapes s for XML character data
+func xmlText(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
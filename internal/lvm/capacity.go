@@ -0,0 +1,165 @@
+package lvm
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+// DefaultCapacityThreshold is the default data and metadata usage, in percent,
+// above which a thin pool is flagged
+const DefaultCapacityThreshold = 80.0
+
+// Thresholds configures when a thin pool is flagged
+type Thresholds struct {
+	DataPercent     float64
+	MetadataPercent float64
+}
+
+// VGCapacity is the size of a volume group holding devbox storage
+type VGCapacity struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	Free    int64  `json:"free"`
+	LVCount string `json:"lv_count"`
+}
+
+// PoolCapacity is the usage of a thin pool holding devbox storage
+type PoolCapacity struct {
+	VGName          string   `json:"vg_name"`
+	Name            string   `json:"name"`
+	Size            int64    `json:"size"`
+	DataPercent     float64  `json:"data_percent"`
+	MetadataPercent float64  `json:"metadata_percent"`
+	Allocated       int64    `json:"allocated"` // Virtual size of the thin volumes in the pool
+	Overprovision   float64  `json:"overprovision"`
+	Flags           []string `json:"flags,omitempty"`
+}
+
+// StatusUsage sums the devbox entries of one storage status
+type StatusUsage struct {
+	Status      string `json:"status"`
+	Entries     int    `json:"entries"`
+	Allocated   int64  `json:"allocated"` // Sum of LV sizes
+	Used        int64  `json:"used"`      // Sum of LV sizes weighted by data%
+	MissingLV   int    `json:"missing_lv"`
+	AmbiguousLV int    `json:"ambiguous_lv"` // Entries matching LVs in several VGs, not summed
+}
+
+// CapacityReport combines VG, thin pool and devbox storage usage
+type CapacityReport struct {
+	VolumeGroups []VGCapacity   `json:"volume_groups"`
+	Pools        []PoolCapacity `json:"pools"`
+	Statuses     []StatusUsage  `json:"statuses"`
+	Flagged      int            `json:"flagged"`
+}
+
+// Capacity builds a capacity report for the volume groups devbox storage
+// lives in (see OwnedVGs), flagging pools above the thresholds. Storage
+// entries are matched with their LVs as in Reconcile.
+func Capacity(storage []database.DevboxStorageInfo, vgs []VolumeGroup, lvs []LogicalVolume, vgNames []string, thresholds Thresholds) *CapacityReport {
+	owned := OwnedVGs(storage, lvs, vgNames)
+	report := &CapacityReport{
+		VolumeGroups: []VGCapacity{},
+		Pools:        []PoolCapacity{},
+		Statuses:     []StatusUsage{},
+	}
+
+	for _, vg := range vgs {
+		if !owned[vg.Name] {
+			continue
+		}
+		size, _ := ParseSize(vg.Size)
+		free, _ := ParseSize(vg.Free)
+		report.VolumeGroups = append(report.VolumeGroups, VGCapacity{
+			Name:    vg.Name,
+			Size:    size,
+			Free:    free,
+			LVCount: vg.LVCount,
+		})
+	}
+
+	// Thin volumes are allocated against their pool
+	allocated := make(map[string]int64)
+	for _, lv := range lvs {
+		if lv.PoolLV == "" {
+			continue
+		}
+		size, _ := lv.SizeBytes()
+		allocated[lv.VGName+"/"+lv.PoolLV] += size
+	}
+
+	for _, lv := range lvs {
+		if !owned[lv.VGName] || !lv.ThinPool() {
+			continue
+		}
+
+		pool := PoolCapacity{
+			VGName:    lv.VGName,
+			Name:      lv.Name,
+			Allocated: allocated[lv.VGName+"/"+lv.Name],
+		}
+		pool.Size, _ = lv.SizeBytes()
+		pool.DataPercent, _ = ParsePercent(lv.DataPercent)
+		pool.MetadataPercent, _ = ParsePercent(lv.MetadataPercent)
+		if pool.Size > 0 {
+			pool.Overprovision = float64(pool.Allocated) / float64(pool.Size)
+		}
+
+		if pool.DataPercent > thresholds.DataPercent {
+			pool.Flags = append(pool.Flags, fmt.Sprintf("data>%g%%", thresholds.DataPercent))
+		}
+		if pool.MetadataPercent > thresholds.MetadataPercent {
+			pool.Flags = append(pool.Flags, fmt.Sprintf("metadata>%g%%", thresholds.MetadataPercent))
+		}
+		if len(pool.Flags) > 0 {
+			report.Flagged++
+		}
+		report.Pools = append(report.Pools, pool)
+	}
+
+	index := newVolumeIndex(lvs)
+	statuses := make(map[string]*StatusUsage)
+	for _, item := range storage {
+		usage, ok := statuses[item.Status]
+		if !ok {
+			usage = &StatusUsage{Status: item.Status}
+			statuses[item.Status] = usage
+		}
+		usage.Entries++
+
+		matches := index.match(item, vgNames)
+		if len(matches) == 0 {
+			usage.MissingLV++
+			continue
+		}
+		if len(matches) > 1 {
+			usage.AmbiguousLV++
+			continue
+		}
+		lv := matches[0]
+		size, _ := lv.SizeBytes()
+		usage.Allocated += size
+		if percent, ok := ParsePercent(lv.DataPercent); ok {
+			usage.Used += int64(float64(size) * percent / 100)
+		}
+	}
+	for _, usage := range statuses {
+		report.Statuses = append(report.Statuses, *usage)
+	}
+
+	sort.Slice(report.VolumeGroups, func(i, j int) bool {
+		return report.VolumeGroups[i].Name < report.VolumeGroups[j].Name
+	})
+	sort.Slice(report.Pools, func(i, j int) bool {
+		if report.Pools[i].VGName != report.Pools[j].VGName {
+			return report.Pools[i].VGName < report.Pools[j].VGName
+		}
+		return report.Pools[i].Name < report.Pools[j].Name
+	})
+	sort.Slice(report.Statuses, func(i, j int) bool {
+		return report.Statuses[i].Status < report.Statuses[j].Status
+	})
+	return report
+}
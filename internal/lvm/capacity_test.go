@@ -0,0 +1,132 @@
+package lvm
+
+import (
+	"testing"
+
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+const testVGSReport = `{
+  "report": [
+    {
+      "vg": [
+        {"vg_name":"devbox-vg", "vg_size":"214748364800", "vg_free":"107374182400", "lv_count":"4"},
+        {"vg_name":"system", "vg_size":"107374182400", "vg_free":"0", "lv_count":"1"}
+      ]
+    }
+  ]
+}`
+
+func TestCapacity(t *testing.T) {
+	lvs, err := ListLogicalVolumes(writeReport(t, testLVSReport))
+	if err != nil {
+		t.Fatalf("Failed to list logical volumes: %v", err)
+	}
+	vgs, err := ListVolumeGroups(writeReport(t, testVGSReport))
+	if err != nil {
+		t.Fatalf("Failed to list volume groups: %v", err)
+	}
+
+	storage := []database.DevboxStorageInfo{
+		{ContentID: "c1", LvName: "lv-content-1", Status: "active"},
+		{ContentID: "c2", LvName: "lv-content-2", Status: "removed"},
+		{ContentID: "c3", LvName: "lv-content-3", Status: "active"},
+	}
+
+	report := Capacity(storage, vgs, lvs, nil, Thresholds{DataPercent: 40, MetadataPercent: 80})
+
+	if len(report.VolumeGroups) != 1 || report.VolumeGroups[0].Name != "devbox-vg" {
+		t.Fatalf("Expected only devbox-vg, got %+v", report.VolumeGroups)
+	}
+	if vg := report.VolumeGroups[0]; vg.Size != 214748364800 || vg.Free != 107374182400 {
+		t.Errorf("Unexpected VG capacity: %+v", vg)
+	}
+
+	if len(report.Pools) != 1 {
+		t.Fatalf("Expected 1 pool, got %d", len(report.Pools))
+	}
+	pool := report.Pools[0]
+	if pool.Name != "thinpool" || pool.DataPercent != 42.10 || pool.MetadataPercent != 8.05 {
+		t.Errorf("Unexpected pool: %+v", pool)
+	}
+	// lv-content-1, lv-content-2 and lv-leftover are allocated from the pool
+	if pool.Allocated != 10737418240*2+5368709120 {
+		t.Errorf("Expected allocated 26843545600, got %d", pool.Allocated)
+	}
+	if pool.Overprovision != 0.25 {
+		t.Errorf("Expected overprovision 0.25, got %f", pool.Overprovision)
+	}
+	if len(pool.Flags) != 1 || pool.Flags[0] != "data>40%" {
+		t.Errorf("Expected data flag, got %v", pool.Flags)
+	}
+	if report.Flagged != 1 {
+		t.Errorf("Expected 1 flagged pool, got %d", report.Flagged)
+	}
+
+	expected := map[string]StatusUsage{
+		"active":  {Status: "active", Entries: 2, Allocated: 10737418240, Used: 1288490188, MissingLV: 1},
+		"removed": {Status: "removed", Entries: 1, Allocated: 10737418240, Used: 0},
+	}
+	if len(report.Statuses) != len(expected) {
+		t.Fatalf("Expected %d statuses, got %+v", len(expected), report.Statuses)
+	}
+	for _, usage := range report.Statuses {
+		if usage != expected[usage.Status] {
+			t.Errorf("Expected %+v, got %+v", expected[usage.Status], usage)
+		}
+	}
+}
+
+func TestCapacity_BelowThresholds(t *testing.T) {
+	lvs, err := ListLogicalVolumes(writeReport(t, testLVSReport))
+	if err != nil {
+		t.Fatalf("Failed to list logical volumes: %v", err)
+	}
+
+	report := Capacity(nil, nil, lvs, []string{"devbox-vg"}, Thresholds{
+		DataPercent:     DefaultCapacityThreshold,
+		MetadataPercent: DefaultCapacityThreshold,
+	})
+
+	if len(report.Pools) != 1 || len(report.Pools[0].Flags) != 0 || report.Flagged != 0 {
+		t.Errorf("Expected an unflagged pool, got %+v", report.Pools)
+	}
+	if len(report.Statuses) != 0 {
+		t.Errorf("Expected no statuses without storage, got %+v", report.Statuses)
+	}
+}
+
+func TestCapacity_SameNameInSeveralVGs(t *testing.T) {
+	lvs := []LogicalVolume{
+		{Name: "lv-1", VGName: "vg-a", Attr: "Vwi-aotz--", Size: "1024"},
+		{Name: "lv-1", VGName: "vg-b", Attr: "Vwi-a-tz--", Size: "2048"},
+		{Name: "lv-2", VGName: "vg-b", Attr: "Vwi-a-tz--", Size: "4096"},
+	}
+	storage := []database.DevboxStorageInfo{
+		{ContentID: "c1", LvName: "lv-1", Status: "active", Extra: map[string]string{"vg_name": "vg-b"}},
+		{ContentID: "c2", LvName: "/dev/vg-b/lv-2", Status: "active"},
+		{ContentID: "c3", LvName: "vg-a/lv-2", Status: "active"},
+		{ContentID: "c4", LvName: "lv-1", Status: "removed"},
+	}
+
+	report := Capacity(storage, nil, lvs, nil, Thresholds{DataPercent: 80, MetadataPercent: 80})
+
+	expected := map[string]StatusUsage{
+		"active":  {Status: "active", Entries: 3, Allocated: 2048 + 4096, MissingLV: 1},
+		"removed": {Status: "removed", Entries: 1, AmbiguousLV: 1},
+	}
+	if len(report.Statuses) != len(expected) {
+		t.Fatalf("Expected %d statuses, got %+v", len(expected), report.Statuses)
+	}
+	for _, usage := range report.Statuses {
+		if usage != expected[usage.Status] {
+			t.Errorf("Expected %+v, got %+v", expected[usage.Status], usage)
+		}
+	}
+
+	// With --vg the bare name is no longer ambiguous
+	report = Capacity(storage[3:], nil, lvs, []string{"vg-a"}, Thresholds{DataPercent: 80, MetadataPercent: 80})
+	if len(report.Statuses) != 1 || report.Statuses[0].Allocated != 1024 || report.Statuses[0].AmbiguousLV != 0 {
+		t.Errorf("Expected lv-1 of vg-a, got %+v", report.Statuses)
+	}
+}
@@ -0,0 +1,172 @@
+package lvm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// LVSFields are the columns requested from lvs
+var LVSFields = []string{"lv_name", "vg_name", "lv_attr", "lv_size", "pool_lv", "lv_path", "data_percent", "metadata_percent"}
+
+// VGSFields are the columns requested from vgs
+var VGSFields = []string{"vg_name", "vg_size", "vg_free", "lv_count"}
+
+// VolumeGroup is a row of the vgs JSON report
+type VolumeGroup struct {
+	Name    string `json:"vg_name"`
+	Size    string `json:"vg_size"`
+	Free    string `json:"vg_free"`
+	LVCount string `json:"lv_count"`
+}
+
+// LogicalVolume is a row of the lvs JSON report
+type LogicalVolume struct {
+	Name            string `json:"lv_name"`
+	VGName          string `json:"vg_name"`
+	Attr            string `json:"lv_attr"`
+	Size            string `json:"lv_size"`
+	PoolLV          string `json:"pool_lv,omitempty"`
+	Path            string `json:"lv_path,omitempty"`
+	DataPercent     string `json:"data_percent,omitempty"`
+	MetadataPercent string `json:"metadata_percent,omitempty"`
+}
+
+// Active reports whether the volume is activated (lv_attr state bit)
+func (lv LogicalVolume) Active() bool {
+	return len(lv.Attr) > 4 && lv.Attr[4] == 'a'
+}
+
+// ThinPool reports whether the volume is a thin pool (lv_attr type bit)
+func (lv LogicalVolume) ThinPool() bool {
+	return len(lv.Attr) > 0 && lv.Attr[0] == 't'
+}
+
+// SizeBytes returns the volume size in bytes. Reports generated with
+// --units b --nosuffix are plain numbers; other unit suffixes are converted.
+func (lv LogicalVolume) SizeBytes() (int64, bool) {
+	return ParseSize(lv.Size)
+}
+
+// ParsePercent converts an LVM percentage such as "42.10" to a number
+func ParsePercent(percent string) (float64, bool) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// report is the top-level structure of lvs/vgs --reportformat json output
+type report struct {
+	Report []map[string]json.RawMessage `json:"report"`
+}
+
+// ParseReport decodes the rows stored under key ("lv" or "vg") of an LVM JSON report
+func ParseReport(r io.Reader, key string, rows interface{}) error {
+	var rep report
+	if err := json.NewDecoder(r).Decode(&rep); err != nil {
+		return fmt.Errorf("failed to parse LVM report: %w", err)
+	}
+	if len(rep.Report) == 0 {
+		return fmt.Errorf("LVM report is empty")
+	}
+
+	data, ok := rep.Report[0][key]
+	if !ok {
+		return fmt.Errorf("LVM report has no %q section", key)
+	}
+	return json.Unmarshal(data, rows)
+}
+
+// ListLogicalVolumes reads logical volumes from a captured lvs JSON report,
+// or runs lvs when path is empty
+func ListLogicalVolumes(path string) ([]LogicalVolume, error) {
+	data, err := readReport(path, "lvs", LVSFields)
+	if err != nil {
+		return nil, err
+	}
+
+	var lvs []LogicalVolume
+	if err := ParseReport(bytes.NewReader(data), "lv", &lvs); err != nil {
+		return nil, err
+	}
+	return lvs, nil
+}
+
+// ListVolumeGroups reads volume groups from a captured vgs JSON report,
+// or runs vgs when path is empty
+func ListVolumeGroups(path string) ([]VolumeGroup, error) {
+	data, err := readReport(path, "vgs", VGSFields)
+	if err != nil {
+		return nil, err
+	}
+
+	var vgs []VolumeGroup
+	if err := ParseReport(bytes.NewReader(data), "vg", &vgs); err != nil {
+		return nil, err
+	}
+	return vgs, nil
+}
+
+// readReport reads a captured report file, or runs the LVM reporting command
+func readReport(path, command string, fields []string) ([]byte, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s report: %w", command, err)
+		}
+		return data, nil
+	}
+
+	cmd := exec.Command(command, "--reportformat", "json", "--units", "b", "--nosuffix", "-o", strings.Join(fields, ","))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return data, nil
+}
+
+// unitMultipliers maps LVM unit suffixes to bytes
+var unitMultipliers = map[byte]float64{
+	'b': 1,
+	's': 512,
+	'k': 1 << 10,
+	'm': 1 << 20,
+	'g': 1 << 30,
+	't': 1 << 40,
+	'p': 1 << 50,
+	'e': 1 << 60,
+}
+
+// ParseSize converts an LVM size such as "10737418240", "10.00g" or
+// "<10.00g" to bytes
+func ParseSize(size string) (int64, bool) {
+	size = strings.TrimPrefix(strings.TrimSpace(size), "<")
+	if size == "" {
+		return 0, false
+	}
+
+	multiplier := 1.0
+	if last := size[len(size)-1]; last < '0' || last > '9' {
+		m, ok := unitMultipliers[byte(strings.ToLower(string(last))[0])]
+		if !ok {
+			return 0, false
+		}
+		multiplier = m
+		size = size[:len(size)-1]
+	}
+
+	value, err := strconv.ParseFloat(size, 64)
+	if err != nil {
+		return 0, false
+	}
+	return int64(value * multiplier), true
+}
@@ -0,0 +1,118 @@
+package lvm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testLVSReport = `{
+  "report": [
+    {
+      "lv": [
+        {"lv_name":"thinpool", "vg_name":"devbox-vg", "lv_attr":"twi-aotz--", "lv_size":"107374182400", "pool_lv":"", "lv_path":"", "data_percent":"42.10", "metadata_percent":"8.05"},
+        {"lv_name":"lv-content-1", "vg_name":"devbox-vg", "lv_attr":"Vwi-aotz--", "lv_size":"10737418240", "pool_lv":"thinpool", "lv_path":"/dev/devbox-vg/lv-content-1", "data_percent":"12.00", "metadata_percent":""},
+        {"lv_name":"lv-content-2", "vg_name":"devbox-vg", "lv_attr":"Vwi---tz--", "lv_size":"10737418240", "pool_lv":"thinpool", "lv_path":"/dev/devbox-vg/lv-content-2", "data_percent":"", "metadata_percent":""},
+        {"lv_name":"lv-leftover", "vg_name":"devbox-vg", "lv_attr":"Vwi-a-tz--", "lv_size":"5368709120", "pool_lv":"thinpool", "lv_path":"/dev/devbox-vg/lv-leftover", "data_percent":"0.00", "metadata_percent":""},
+        {"lv_name":"root", "vg_name":"system", "lv_attr":"-wi-ao----", "lv_size":"53687091200", "pool_lv":"", "lv_path":"/dev/system/root", "data_percent":"", "metadata_percent":""}
+      ]
+    }
+  ]
+}`
+
+func writeReport(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "lvs.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write report: %v", err)
+	}
+	return path
+}
+
+func TestListLogicalVolumes(t *testing.T) {
+	lvs, err := ListLogicalVolumes(writeReport(t, testLVSReport))
+	if err != nil {
+		t.Fatalf("Failed to list logical volumes: %v", err)
+	}
+	if len(lvs) != 5 {
+		t.Fatalf("Expected 5 logical volumes, got %d", len(lvs))
+	}
+
+	lv := lvs[1]
+	if lv.Name != "lv-content-1" || lv.VGName != "devbox-vg" || lv.PoolLV != "thinpool" {
+		t.Errorf("Unexpected logical volume: %+v", lv)
+	}
+	if !lv.Active() || lv.ThinPool() {
+		t.Errorf("Expected lv-content-1 to be an active thin volume, attr %s", lv.Attr)
+	}
+	if lvs[2].Active() {
+		t.Error("Expected lv-content-2 to be inactive")
+	}
+	if !lvs[0].ThinPool() {
+		t.Error("Expected thinpool to be a thin pool")
+	}
+	if size, ok := lv.SizeBytes(); !ok || size != 10737418240 {
+		t.Errorf("Expected size 10737418240, got %d (%t)", size, ok)
+	}
+}
+
+func TestListLogicalVolumes_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"malformed", `{"report": [`},
+		{"empty report", `{"report": []}`},
+		{"wrong section", `{"report": [{"vg": []}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ListLogicalVolumes(writeReport(t, tt.content)); err == nil {
+				t.Error("Expected error for invalid report")
+			}
+		})
+	}
+
+	if _, err := ListLogicalVolumes(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing report file")
+	}
+}
+
+func TestParseReport(t *testing.T) {
+	var rows []map[string]string
+	err := ParseReport(strings.NewReader(`{"report":[{"vg":[{"vg_name":"vg0"}]}]}`), "vg", &rows)
+	if err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["vg_name"] != "vg0" {
+		t.Errorf("Unexpected rows: %v", rows)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size     string
+		expected int64
+		ok       bool
+	}{
+		{"10737418240", 10737418240, true},
+		{"10.00g", 10737418240, true},
+		{"<10.00g", 10737418240, true},
+		{"512.00m", 512 << 20, true},
+		{"2.00T", 2 << 40, true},
+		{"8s", 4096, true},
+		{"", 0, false},
+		{"10.00x", 0, false},
+		{"abc", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			size, ok := ParseSize(tt.size)
+			if ok != tt.ok || size != tt.expected {
+				t.Errorf("ParseSize(%q) = %d, %t; expected %d, %t", tt.size, size, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package lvm
+
+import (
+	"sort"
+
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+// Reconciliation results
+const (
+	ReconcileOK               = "ok"
+	ReconcileOrphanLV         = "orphan-lv"
+	ReconcileMissingLV        = "missing-lv"
+	ReconcileRemovedLVPresent = "removed-lv-present"
+	ReconcileAmbiguousLV      = "ambiguous-lv"
+)
+
+// Reconciliation matches a devbox storage entry and/or a logical volume
+type Reconciliation struct {
+	LvName    string `json:"lv_name"`
+	VGName    string `json:"vg_name,omitempty"`
+	ContentID string `json:"content_id,omitempty"`
+	Status    string `json:"status,omitempty"` // Devbox storage status
+	Path      string `json:"path,omitempty"`
+	Size      string `json:"size,omitempty"`
+	Attr      string `json:"attr,omitempty"`
+	PoolLV    string `json:"pool_lv,omitempty"`
+	Active    bool   `json:"active"`
+	Result    string `json:"result"`
+}
+
+// Reconcile matches logical volumes to devbox storage entries. An entry
+// names its LV by lv_name; the volume group comes from the entry (see
+// DevboxStorageInfo.Volume) or, when it does not record one, from vgs. An
+// entry matching same-named LVs in several volume groups is ambiguous-lv.
+// Only volumes in vgs are considered for orphans; when vgs is empty, the
+// volume groups holding at least one recorded LV are used. Thin pools are
+// never reported as orphans.
+func Reconcile(storage []database.DevboxStorageInfo, lvs []LogicalVolume, vgs []string) []Reconciliation {
+	index := newVolumeIndex(lvs)
+	owned := OwnedVGs(storage, lvs, vgs)
+
+	var results []Reconciliation
+	recorded := make(map[string]bool)
+	for _, item := range storage {
+		if item.LvName == "" {
+			continue
+		}
+
+		vg, name := item.Volume()
+		result := Reconciliation{
+			LvName:    name,
+			VGName:    vg,
+			ContentID: item.ContentID,
+			Status:    item.Status,
+			Path:      item.Path,
+		}
+
+		matches := index.match(item, vgs)
+		for _, lv := range matches {
+			recorded[lvKey(lv.VGName, lv.Name)] = true
+		}
+		exists := len(matches) > 0
+		if len(matches) == 1 {
+			fillLV(&result, matches[0])
+		}
+
+		switch {
+		case len(matches) > 1:
+			result.Result = ReconcileAmbiguousLV
+		case exists && item.Status == string(database.DevboxStatusRemoved):
+			result.Result = ReconcileRemovedLVPresent
+		case !exists && item.Status != string(database.DevboxStatusRemoved):
+			result.Result = ReconcileMissingLV
+		default:
+			result.Result = ReconcileOK
+		}
+		results = append(results, result)
+	}
+
+	for _, lv := range lvs {
+		if recorded[lvKey(lv.VGName, lv.Name)] || !owned[lv.VGName] || lv.ThinPool() {
+			continue
+		}
+		result := Reconciliation{LvName: lv.Name, Result: ReconcileOrphanLV}
+		fillLV(&result, lv)
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].VGName != results[j].VGName {
+			return results[i].VGName < results[j].VGName
+		}
+		return results[i].LvName < results[j].LvName
+	})
+	return results
+}
+
+// OwnedVGs returns the volume groups devbox storage lives in: vgs when
+// given, otherwise the groups holding at least one recorded LV
+func OwnedVGs(storage []database.DevboxStorageInfo, lvs []LogicalVolume, vgs []string) map[string]bool {
+	owned := make(map[string]bool)
+	for _, vg := range vgs {
+		owned[vg] = true
+	}
+	if len(vgs) > 0 {
+		return owned
+	}
+
+	index := newVolumeIndex(lvs)
+	for _, item := range storage {
+		for _, lv := range index.match(item, nil) {
+			owned[lv.VGName] = true
+		}
+	}
+	return owned
+}
+
+// lvKey identifies a logical volume across volume groups
+func lvKey(vg, name string) string {
+	return vg + "/" + name
+}
+
+// volumeIndex looks up logical volumes by vg/lv and by LV name
+type volumeIndex struct {
+	byKey  map[string]LogicalVolume
+	byName map[string][]LogicalVolume
+}
+
+func newVolumeIndex(lvs []LogicalVolume) volumeIndex {
+	index := volumeIndex{
+		byKey:  make(map[string]LogicalVolume, len(lvs)),
+		byName: make(map[string][]LogicalVolume, len(lvs)),
+	}
+	for _, lv := range lvs {
+		index.byKey[lvKey(lv.VGName, lv.Name)] = lv
+		index.byName[lv.Name] = append(index.byName[lv.Name], lv)
+	}
+	return index
+}
+
+// match returns the volumes a storage entry may refer to: the volume in its
+// volume group when it records one, otherwise the volumes of that name in
+// vgs, or in any volume group when vgs is empty
+func (index volumeIndex) match(item database.DevboxStorageInfo, vgs []string) []LogicalVolume {
+	vg, name := item.Volume()
+	if vg != "" {
+		if lv, ok := index.byKey[lvKey(vg, name)]; ok {
+			return []LogicalVolume{lv}
+		}
+		return nil
+	}
+
+	var matches []LogicalVolume
+	for _, lv := range index.byName[name] {
+		if len(vgs) == 0 || containsString(vgs, lv.VGName) {
+			matches = append(matches, lv)
+		}
+	}
+	return matches
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func fillLV(result *Reconciliation, lv LogicalVolume) {
+	result.VGName = lv.VGName
+	result.Size = lv.Size
+	result.Attr = lv.Attr
+	result.PoolLV = lv.PoolLV
+	result.Active = lv.Active()
+}
@@ -0,0 +1,123 @@
+package lvm
+
+import (
+	"testing"
+
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+func TestReconcile(t *testing.T) {
+	lvs, err := ListLogicalVolumes(writeReport(t, testLVSReport))
+	if err != nil {
+		t.Fatalf("Failed to list logical volumes: %v", err)
+	}
+
+	storage := []database.DevboxStorageInfo{
+		{ContentID: "c1", LvName: "lv-content-1", Path: "/mnt/1", Status: "active"},
+		{ContentID: "c2", LvName: "lv-content-2", Path: "/mnt/2", Status: "removed"},
+		{ContentID: "c3", LvName: "lv-content-3", Path: "/mnt/3", Status: "active"},
+		{ContentID: "c4", LvName: "lv-content-4", Path: "/mnt/4", Status: "removed"},
+		{ContentID: "c5", Path: "/mnt/5", Status: "active"},
+	}
+
+	results := Reconcile(storage, lvs, nil)
+
+	expected := map[string]string{
+		"lv-content-1": ReconcileOK,
+		"lv-content-2": ReconcileRemovedLVPresent,
+		"lv-content-3": ReconcileMissingLV,
+		"lv-content-4": ReconcileOK,
+		"lv-leftover":  ReconcileOrphanLV,
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d: %+v", len(expected), len(results), results)
+	}
+
+	for _, result := range results {
+		if result.Result != expected[result.LvName] {
+			t.Errorf("%s: expected %s, got %s", result.LvName, expected[result.LvName], result.Result)
+		}
+	}
+
+	for _, result := range results {
+		if result.LvName == "lv-content-1" {
+			if result.VGName != "devbox-vg" || result.Size != "10737418240" || !result.Active || result.ContentID != "c1" {
+				t.Errorf("Unexpected LV details: %+v", result)
+			}
+		}
+	}
+}
+
+func TestReconcile_ExplicitVGs(t *testing.T) {
+	lvs, err := ListLogicalVolumes(writeReport(t, testLVSReport))
+	if err != nil {
+		t.Fatalf("Failed to list logical volumes: %v", err)
+	}
+
+	// The system volume group is only checked for orphans when asked for
+	results := Reconcile(nil, lvs, []string{"system"})
+	if len(results) != 1 || results[0].LvName != "root" || results[0].Result != ReconcileOrphanLV {
+		t.Errorf("Expected only root as orphan, got %+v", results)
+	}
+}
+
+func TestReconcile_SameNameInSeveralVGs(t *testing.T) {
+	lvs := []LogicalVolume{
+		{Name: "thinpool", VGName: "vg-a", Attr: "twi-aotz--"},
+		{Name: "lv-1", VGName: "vg-a", Attr: "Vwi-aotz--", Size: "1024", PoolLV: "thinpool"},
+		{Name: "lv-1", VGName: "vg-b", Attr: "Vwi-a-tz--", Size: "2048"},
+		{Name: "lv-2", VGName: "vg-b", Attr: "Vwi-a-tz--", Size: "4096"},
+	}
+
+	t.Run("volume group from the entry", func(t *testing.T) {
+		storage := []database.DevboxStorageInfo{
+			{ContentID: "c1", LvName: "lv-1", Status: "active", Extra: map[string]string{"vg_name": "vg-b"}},
+			{ContentID: "c2", LvName: "vg-b/lv-2", Status: "active"},
+		}
+		results := Reconcile(storage, lvs, nil)
+
+		expected := map[string]string{
+			"vg-b/lv-1": ReconcileOK,
+			"vg-b/lv-2": ReconcileOK,
+		}
+		if len(results) != len(expected) {
+			t.Fatalf("Expected %d results, got %d: %+v", len(expected), len(results), results)
+		}
+		for _, result := range results {
+			key := result.VGName + "/" + result.LvName
+			if result.Result != expected[key] {
+				t.Errorf("%s: expected %s, got %s", key, expected[key], result.Result)
+			}
+		}
+		if results[0].Size != "2048" {
+			t.Errorf("Expected the vg-b volume, got %+v", results[0])
+		}
+	})
+
+	t.Run("volume group from --vg", func(t *testing.T) {
+		storage := []database.DevboxStorageInfo{{ContentID: "c1", LvName: "lv-1", Status: "active"}}
+		results := Reconcile(storage, lvs, []string{"vg-a"})
+		if len(results) != 1 || results[0].VGName != "vg-a" || results[0].Result != ReconcileOK {
+			t.Errorf("Expected lv-1 of vg-a only, got %+v", results)
+		}
+	})
+
+	t.Run("ambiguous", func(t *testing.T) {
+		storage := []database.DevboxStorageInfo{{ContentID: "c1", LvName: "lv-1", Status: "active"}}
+		results := Reconcile(storage, lvs, nil)
+
+		expected := map[string]string{
+			"/lv-1":     ReconcileAmbiguousLV,
+			"vg-b/lv-2": ReconcileOrphanLV,
+		}
+		if len(results) != len(expected) {
+			t.Fatalf("Expected %d results, got %d: %+v", len(expected), len(results), results)
+		}
+		for _, result := range results {
+			key := result.VGName + "/" + result.LvName
+			if result.Result != expected[key] {
+				t.Errorf("%s: expected %s, got %s", key, expected[key], result.Result)
+			}
+		}
+	})
+}
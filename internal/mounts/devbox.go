@@ -0,0 +1,86 @@
+package mounts
+
+import (
+	"sort"
+
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+// Devbox verification results
+const (
+	VerifyOK               = "ok"
+	VerifyActiveNotMounted = "active-not-mounted"
+	VerifyRemovedMounted   = "removed-but-mounted"
+	VerifyLVMismatch       = "lv-mismatch"
+	VerifyNoPath           = "no-path"
+	VerifyUnknownStatus    = "unknown-status"
+)
+
+// DevboxCheck is the result of verifying a devbox storage entry against the mount table
+type DevboxCheck struct {
+	ContentID string `json:"content_id"`
+	LvName    string `json:"lv_name"`
+	Path      string `json:"path"`
+	Status    string `json:"status"`
+	Mounted   bool   `json:"mounted"`
+	Source    string `json:"source,omitempty"`
+	FSType    string `json:"fs_type,omitempty"`
+	Options   string `json:"options,omitempty"`
+	Result    string `json:"result"`
+}
+
+// VerifyDevbox checks every devbox storage entry against the mount table:
+// active entries must be mounted from their LV, removed entries must not be mounted
+func VerifyDevbox(storage []database.DevboxStorageInfo, table Table) []DevboxCheck {
+	checks := make([]DevboxCheck, 0, len(storage))
+	for _, item := range storage {
+		check := DevboxCheck{
+			ContentID: item.ContentID,
+			LvName:    item.LvName,
+			Path:      item.Path,
+			Status:    item.Status,
+		}
+
+		if item.Path != "" {
+			if mount, ok := table.Lookup(item.Path); ok {
+				check.Mounted = true
+				check.Source = mount.Source
+				check.FSType = mount.FSType
+				check.Options = mount.Options
+			}
+		}
+
+		check.Result = verifyResult(item, check)
+		checks = append(checks, check)
+	}
+
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].ContentID < checks[j].ContentID
+	})
+	return checks
+}
+
+func verifyResult(item database.DevboxStorageInfo, check DevboxCheck) string {
+	if item.Path == "" {
+		return VerifyNoPath
+	}
+
+	switch item.Status {
+	case string(database.DevboxStatusActive):
+		if !check.Mounted {
+			return VerifyActiveNotMounted
+		}
+		// Sources that are not LVM device paths (e.g. /dev/dm-3) cannot be compared
+		if lv, ok := LVName(check.Source); ok && item.LvName != "" && lv != item.LvName {
+			return VerifyLVMismatch
+		}
+		return VerifyOK
+	case string(database.DevboxStatusRemoved):
+		if check.Mounted {
+			return VerifyRemovedMounted
+		}
+		return VerifyOK
+	default:
+		return VerifyUnknownStatus
+	}
+}
@@ -0,0 +1,54 @@
+package mounts
+
+import (
+	"testing"
+
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+func TestVerifyDevbox(t *testing.T) {
+	table, err := ReadMountInfo(writeMountInfo(t, testMountInfo))
+	if err != nil {
+		t.Fatalf("Failed to read mountinfo: %v", err)
+	}
+
+	storage := []database.DevboxStorageInfo{
+		{ContentID: "c1", LvName: "lv-content-1", Path: "/mnt/devbox/content-1", Status: "active"},
+		{ContentID: "c2", LvName: "lv-content-2", Path: "/mnt/devbox/content-2", Status: "active"},
+		{ContentID: "c3", LvName: "lv-content-3", Path: "/mnt/devbox/content-3", Status: "active"},
+		{ContentID: "c4", LvName: "lv-content-4", Path: "/mnt/devbox/content-4", Status: "active"},
+		{ContentID: "c5", LvName: "lv-content-1", Path: "/mnt/devbox/content-1", Status: "removed"},
+		{ContentID: "c6", LvName: "lv-content-6", Path: "/mnt/devbox/content-6", Status: "removed"},
+		{ContentID: "c7", LvName: "lv-content-7", Status: "active"},
+		{ContentID: "c8", LvName: "lv-content-8", Path: "/mnt/devbox/content-8", Status: "pending"},
+	}
+
+	expected := map[string]string{
+		"c1": VerifyOK,
+		"c2": VerifyLVMismatch,
+		"c3": VerifyOK, // mounted from /dev/dm-5, LV cannot be resolved
+		"c4": VerifyActiveNotMounted,
+		"c5": VerifyRemovedMounted,
+		"c6": VerifyOK,
+		"c7": VerifyNoPath,
+		"c8": VerifyUnknownStatus,
+	}
+
+	checks := VerifyDevbox(storage, table)
+	if len(checks) != len(storage) {
+		t.Fatalf("Expected %d checks, got %d", len(storage), len(checks))
+	}
+
+	for _, check := range checks {
+		if check.Result != expected[check.ContentID] {
+			t.Errorf("%s: expected %s, got %s", check.ContentID, expected[check.ContentID], check.Result)
+		}
+	}
+
+	if checks[0].ContentID != "c1" || !checks[0].Mounted || checks[0].FSType != "ext4" || checks[0].Options != "rw,relatime" {
+		t.Errorf("Unexpected mount details for c1: %+v", checks[0])
+	}
+	if checks[3].Mounted || checks[3].Source != "" {
+		t.Errorf("Expected c4 not to be mounted: %+v", checks[3])
+	}
+}
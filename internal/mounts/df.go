@@ -0,0 +1,107 @@
+package mounts
+
+import (
+	"os"
+	"sort"
+
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/utils"
+	"github.com/moby/sys/mountinfo"
+	"golang.org/x/sys/unix"
+)
+
+// Filesystem usage states
+const (
+	UsageOK            = "ok"
+	UsageMissing       = "missing"
+	UsageNotMountpoint = "not-mountpoint"
+	UsageNoPath        = "no-path"
+)
+
+// Usage is the filesystem usage of a devbox entry's mount path
+type Usage struct {
+	ContentID   string  `json:"content_id"`
+	LvName      string  `json:"lv_name"`
+	Path        string  `json:"path"`
+	Status      string  `json:"status"`
+	State       string  `json:"state"`
+	Total       uint64  `json:"total"`
+	Used        uint64  `json:"used"`
+	Available   uint64  `json:"available"`
+	UsedPercent float64 `json:"used_percent"`
+	Inodes      uint64  `json:"inodes"`
+	InodesUsed  uint64  `json:"inodes_used"`
+	InodesFree  uint64  `json:"inodes_free"`
+}
+
+// DevboxUsage runs statfs on the path of every devbox entry. Paths are
+// resolved under utils.HostRoot. Entries are sorted by usage, fullest first.
+// Paths that are not mountpoints report the filesystem they live on.
+func DevboxUsage(storage []database.DevboxStorageInfo) []Usage {
+	usages := make([]Usage, 0, len(storage))
+	for _, item := range storage {
+		usage := Usage{
+			ContentID: item.ContentID,
+			LvName:    item.LvName,
+			Path:      item.Path,
+			Status:    item.Status,
+		}
+		statUsage(&usage)
+		usages = append(usages, usage)
+	}
+
+	sort.SliceStable(usages, func(i, j int) bool {
+		if usages[i].UsedPercent != usages[j].UsedPercent {
+			return usages[i].UsedPercent > usages[j].UsedPercent
+		}
+		return usages[i].Path < usages[j].Path
+	})
+	return usages
+}
+
+// FilterUsage keeps entries whose usage is at least threshold percent
+func FilterUsage(usages []Usage, threshold float64) []Usage {
+	var filtered []Usage
+	for _, usage := range usages {
+		if usage.UsedPercent >= threshold {
+			filtered = append(filtered, usage)
+		}
+	}
+	return filtered
+}
+
+func statUsage(usage *Usage) {
+	if usage.Path == "" {
+		usage.State = UsageNoPath
+		return
+	}
+
+	path := utils.HostPath(usage.Path)
+	if _, err := os.Stat(path); err != nil {
+		usage.State = UsageMissing
+		return
+	}
+
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		usage.State = UsageMissing
+		return
+	}
+
+	bsize := uint64(st.Bsize)
+	usage.Total = st.Blocks * bsize
+	usage.Used = (st.Blocks - st.Bfree) * bsize
+	usage.Available = st.Bavail * bsize
+	// Like df, reserved blocks count neither as used nor as available
+	if capacity := usage.Used + usage.Available; capacity > 0 {
+		usage.UsedPercent = float64(usage.Used) * 100 / float64(capacity)
+	}
+	usage.Inodes = st.Files
+	usage.InodesFree = st.Ffree
+	usage.InodesUsed = st.Files - st.Ffree
+
+	usage.State = UsageOK
+	if mounted, err := mountinfo.Mounted(path); err == nil && !mounted {
+		usage.State = UsageNotMountpoint
+	}
+}
@@ -0,0 +1,85 @@
+package mounts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/utils"
+)
+
+func TestDevboxUsage(t *testing.T) {
+	dir := t.TempDir()
+
+	storage := []database.DevboxStorageInfo{
+		{ContentID: "c1", LvName: "lv-1", Path: dir, Status: "active"},
+		{ContentID: "c2", LvName: "lv-2", Path: filepath.Join(dir, "missing"), Status: "active"},
+		{ContentID: "c3", LvName: "lv-3", Status: "active"},
+		{ContentID: "c4", LvName: "lv-4", Path: "/", Status: "active"},
+	}
+
+	usages := DevboxUsage(storage)
+	if len(usages) != 4 {
+		t.Fatalf("Expected 4 usages, got %d", len(usages))
+	}
+
+	byID := make(map[string]Usage)
+	for _, usage := range usages {
+		byID[usage.ContentID] = usage
+	}
+
+	if state := byID["c1"].State; state != UsageNotMountpoint {
+		t.Errorf("Expected temp dir to be %s, got %s", UsageNotMountpoint, state)
+	}
+	if usage := byID["c1"]; usage.Total == 0 || usage.Inodes == 0 || usage.Used > usage.Total {
+		t.Errorf("Expected statfs results for temp dir, got %+v", usage)
+	}
+	if state := byID["c2"].State; state != UsageMissing {
+		t.Errorf("Expected %s, got %s", UsageMissing, state)
+	}
+	if state := byID["c3"].State; state != UsageNoPath {
+		t.Errorf("Expected %s, got %s", UsageNoPath, state)
+	}
+	if state := byID["c4"].State; state != UsageOK {
+		t.Errorf("Expected / to be %s, got %s", UsageOK, state)
+	}
+
+	for i := 1; i < len(usages); i++ {
+		if usages[i].UsedPercent > usages[i-1].UsedPercent {
+			t.Errorf("Expected usages sorted fullest first, got %v before %v", usages[i-1].UsedPercent, usages[i].UsedPercent)
+		}
+	}
+}
+
+func TestDevboxUsage_HostRoot(t *testing.T) {
+	hostRoot := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(hostRoot, "mnt/devbox/c1"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	orig := utils.HostRoot
+	utils.HostRoot = hostRoot
+	defer func() { utils.HostRoot = orig }()
+
+	usages := DevboxUsage([]database.DevboxStorageInfo{{ContentID: "c1", Path: "/mnt/devbox/c1", Status: "active"}})
+	if usages[0].State == UsageMissing {
+		t.Error("Expected path to be resolved under the host root")
+	}
+	if usages[0].Path != "/mnt/devbox/c1" {
+		t.Errorf("Expected host path to be displayed, got %s", usages[0].Path)
+	}
+}
+
+func TestFilterUsage(t *testing.T) {
+	usages := []Usage{
+		{ContentID: "c1", UsedPercent: 95},
+		{ContentID: "c2", UsedPercent: 80},
+		{ContentID: "c3", UsedPercent: 10},
+	}
+
+	filtered := FilterUsage(usages, 80)
+	if len(filtered) != 2 || filtered[0].ContentID != "c1" || filtered[1].ContentID != "c2" {
+		t.Errorf("Expected c1 and c2, got %+v", filtered)
+	}
+}
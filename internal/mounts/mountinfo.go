@@ -0,0 +1,79 @@
+package mounts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/meta-viewer/internal/blockdev"
+	"github.com/containerd/meta-viewer/internal/utils"
+	"github.com/moby/sys/mountinfo"
+)
+
+// DefaultMountInfoPath is the host mount table, read through the host's init process
+const DefaultMountInfoPath = "/proc/1/mountinfo"
+
+// Mount is a single entry of the mount table
+type Mount struct {
+	MountPoint string `json:"mount_point"`
+	Source     string `json:"source"`
+	FSType     string `json:"fs_type"`
+	Options    string `json:"options"`
+}
+
+// Table indexes a mount table by mount point. When several filesystems are
+// stacked on the same mount point, the last one mounted is visible.
+type Table map[string]Mount
+
+// ReadMountInfo parses a mountinfo file. An empty path reads the host mount
+// table from DefaultMountInfoPath under utils.HostRoot.
+func ReadMountInfo(path string) (Table, error) {
+	if path == "" {
+		path = utils.HostPath(DefaultMountInfoPath)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mountinfo: %w", err)
+	}
+	defer f.Close()
+
+	infos, err := mountinfo.GetMountsFromReader(f, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mountinfo %s: %w", path, err)
+	}
+
+	table := make(Table, len(infos))
+	for _, info := range infos {
+		table[info.Mountpoint] = Mount{
+			MountPoint: info.Mountpoint,
+			Source:     info.Source,
+			FSType:     info.FSType,
+			Options:    info.Options,
+		}
+	}
+	return table, nil
+}
+
+// Lookup returns the mount at path, if path is a mount point
+func (t Table) Lookup(path string) (Mount, bool) {
+	mount, ok := t[filepath.Clean(path)]
+	return mount, ok
+}
+
+// LVName extracts the logical volume name from an LVM device path, either
+// /dev/mapper/<vg>-<lv> (with dashes in names doubled) or /dev/<vg>/<lv>.
+// It returns false for sources that are not LVM device paths.
+func LVName(source string) (string, bool) {
+	dir, name := filepath.Split(source)
+	switch {
+	case dir == "/dev/mapper/":
+		_, lv, ok := blockdev.SplitDMName(name)
+		return lv, ok
+	case strings.HasPrefix(dir, "/dev/") && strings.Count(dir, "/") == 3 && name != "":
+		return name, true
+	default:
+		return "", false
+	}
+}
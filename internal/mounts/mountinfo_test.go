@@ -0,0 +1,107 @@
+package mounts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/meta-viewer/internal/utils"
+)
+
+const testMountInfo = `22 1 253:0 / / rw,relatime shared:1 - ext4 /dev/mapper/vg0-root rw
+30 22 253:3 / /mnt/devbox/content-1 rw,relatime shared:10 - ext4 /dev/mapper/devbox--vg-lv--content--1 rw,stripe=16
+31 22 253:4 / /mnt/devbox/content-2 ro,noatime shared:11 - xfs /dev/devbox-vg/lv-other rw
+32 22 0:50 / /mnt/devbox/content-3 rw shared:12 - tmpfs tmpfs rw
+33 32 253:5 / /mnt/devbox/content-3 rw shared:13 - ext4 /dev/dm-5 rw
+34 22 253:6 / /mnt/with\040space rw shared:14 - ext4 /dev/mapper/vg0-space rw
+`
+
+func writeMountInfo(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "mountinfo")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write mountinfo: %v", err)
+	}
+	return path
+}
+
+func TestReadMountInfo(t *testing.T) {
+	table, err := ReadMountInfo(writeMountInfo(t, testMountInfo))
+	if err != nil {
+		t.Fatalf("Failed to read mountinfo: %v", err)
+	}
+
+	mount, ok := table.Lookup("/mnt/devbox/content-1/")
+	if !ok {
+		t.Fatal("Expected /mnt/devbox/content-1 to be mounted")
+	}
+	if mount.Source != "/dev/mapper/devbox--vg-lv--content--1" || mount.FSType != "ext4" || mount.Options != "rw,relatime" {
+		t.Errorf("Unexpected mount: %+v", mount)
+	}
+
+	// The last mount stacked on a mount point is the visible one
+	if mount, _ := table.Lookup("/mnt/devbox/content-3"); mount.Source != "/dev/dm-5" {
+		t.Errorf("Expected top mount /dev/dm-5, got %s", mount.Source)
+	}
+
+	if _, ok := table.Lookup("/mnt/with space"); !ok {
+		t.Error("Expected escaped mount point to be unescaped")
+	}
+
+	if _, ok := table.Lookup("/mnt/devbox/missing"); ok {
+		t.Error("Expected missing mount point not to be found")
+	}
+}
+
+func TestReadMountInfo_HostRoot(t *testing.T) {
+	hostRoot := t.TempDir()
+	path := filepath.Join(hostRoot, "proc/1/mountinfo")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(testMountInfo), 0644); err != nil {
+		t.Fatalf("Failed to write mountinfo: %v", err)
+	}
+
+	orig := utils.HostRoot
+	utils.HostRoot = hostRoot
+	defer func() { utils.HostRoot = orig }()
+
+	table, err := ReadMountInfo("")
+	if err != nil {
+		t.Fatalf("Failed to read host mountinfo: %v", err)
+	}
+	if _, ok := table.Lookup("/mnt/devbox/content-1"); !ok {
+		t.Error("Expected host mount table to be read")
+	}
+}
+
+func TestReadMountInfo_Missing(t *testing.T) {
+	if _, err := ReadMountInfo(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for missing mountinfo file")
+	}
+}
+
+func TestLVName(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+		ok       bool
+	}{
+		{"/dev/mapper/vg0-lv0", "lv0", true},
+		{"/dev/mapper/devbox--vg-lv--content--1", "lv-content-1", true},
+		{"/dev/devbox-vg/lv-other", "lv-other", true},
+		{"/dev/dm-5", "", false},
+		{"/dev/sda1", "", false},
+		{"tmpfs", "", false},
+		{"/dev/mapper/nodash", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			lv, ok := LVName(tt.source)
+			if ok != tt.ok || lv != tt.expected {
+				t.Errorf("LVName(%s) = %s, %t; expected %s, %t", tt.source, lv, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package mounts
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+// DefaultOverlayOptions are the options containerd's overlayfs snapshotter
+// adds on kernels supporting the overlay index feature
+var DefaultOverlayOptions = []string{"index=off"}
+
+// Spec is a mount as returned by a snapshotter's Mounts call. Snapshot mounts
+// have no target; the caller chooses where to mount the rootfs.
+type Spec struct {
+	Type    string   `json:"type"`
+	Source  string   `json:"source"`
+	Target  string   `json:"target,omitempty"`
+	Options []string `json:"options,omitempty"`
+}
+
+// SnapshotMounts is the mount specification computed for a snapshot
+type SnapshotMounts struct {
+	Key         string   `json:"key"`
+	ID          uint64   `json:"id"`
+	Kind        string   `json:"kind"`
+	Snapshotter string   `json:"snapshotter"`
+	ParentIDs   []uint64 `json:"parent_ids,omitempty"`
+	Mounts      []Spec   `json:"mounts"`
+}
+
+// ParentIDs walks the parent chain of the snapshot with the given key and
+// returns the parent IDs, closest parent first
+func ParentIDs(key string, infos []database.SnapshotInfo) ([]uint64, error) {
+	byKey := make(map[string]database.SnapshotInfo, len(infos))
+	for _, info := range infos {
+		byKey[info.Key] = info
+	}
+
+	info, ok := byKey[key]
+	if !ok {
+		return nil, fmt.Errorf("snapshot %s not found", key)
+	}
+
+	var ids []uint64
+	seen := map[string]bool{key: true}
+	for parent := info.Parent; parent != ""; parent = byKey[parent].Parent {
+		if seen[parent] {
+			return nil, fmt.Errorf("parent chain of %s loops at %s", key, parent)
+		}
+		seen[parent] = true
+
+		parentInfo, ok := byKey[parent]
+		if !ok {
+			return nil, fmt.Errorf("parent %s of snapshot %s not found", parent, key)
+		}
+		ids = append(ids, parentInfo.ID)
+	}
+	return ids, nil
+}
+
+// checkMountable rejects committed snapshots, which containerd does not mount
+func checkMountable(info database.SnapshotInfo) error {
+	if info.Kind != snapshots.KindActive && info.Kind != snapshots.KindView {
+		return fmt.Errorf("snapshot %s is %s; only active and view snapshots have mounts",
+			info.Key, database.SnapshotKindString(info.Kind))
+	}
+	return nil
+}
+
+// OverlayMounts returns the mounts containerd's overlayfs snapshotter
+// produces: a bind mount when there is a single layer to show, an overlay
+// with the lowerdir chain built from the parent IDs otherwise. Active
+// snapshots get an upperdir and workdir.
+func OverlayMounts(root string, info database.SnapshotInfo, parentIDs []uint64, options []string) ([]Spec, error) {
+	if err := checkMountable(info); err != nil {
+		return nil, err
+	}
+
+	upperPath := func(id uint64) string {
+		return filepath.Join(root, "snapshots", strconv.FormatUint(id, 10), "fs")
+	}
+	options = append([]string(nil), options...)
+
+	// Overlay needs at least one lower directory
+	if len(parentIDs) == 0 {
+		roFlag := "rw"
+		if info.Kind == snapshots.KindView {
+			roFlag = "ro"
+		}
+		return []Spec{{
+			Type:    "bind",
+			Source:  upperPath(info.ID),
+			Options: append(options, roFlag, "rbind"),
+		}}, nil
+	}
+
+	if info.Kind == snapshots.KindActive {
+		workPath := filepath.Join(root, "snapshots", strconv.FormatUint(info.ID, 10), "work")
+		options = append(options, "workdir="+workPath, "upperdir="+upperPath(info.ID))
+	} else if len(parentIDs) == 1 {
+		return []Spec{{
+			Type:    "bind",
+			Source:  upperPath(parentIDs[0]),
+			Options: append(options, "ro", "rbind"),
+		}}, nil
+	}
+
+	lowerPaths := make([]string, len(parentIDs))
+	for i, id := range parentIDs {
+		lowerPaths[i] = upperPath(id)
+	}
+	options = append(options, "lowerdir="+strings.Join(lowerPaths, ":"))
+
+	return []Spec{{
+		Type:    "overlay",
+		Source:  "overlay",
+		Options: options,
+	}}, nil
+}
+
+// NativeMounts returns the bind mount containerd's native snapshotter
+// produces. Active snapshots are full copies of their parent and bind their
+// own directory; views bind their closest parent directly.
+func NativeMounts(root string, info database.SnapshotInfo, parentIDs []uint64) ([]Spec, error) {
+	if err := checkMountable(info); err != nil {
+		return nil, err
+	}
+
+	roFlag := "rw"
+	if info.Kind == snapshots.KindView {
+		roFlag = "ro"
+	}
+	id := info.ID
+	if len(parentIDs) > 0 && info.Kind != snapshots.KindActive {
+		id = parentIDs[0]
+	}
+	return []Spec{{
+		Type:    "bind",
+		Source:  filepath.Join(root, "snapshots", strconv.FormatUint(id, 10)),
+		Options: []string{roFlag, "rbind"},
+	}}, nil
+}
+
+// DevboxMounts returns the mount of a devbox snapshot's logical volume at
+// its storage path. The LV is addressed as /dev/<vg>/<lv>; the volume group
+// comes from the storage entry (see DevboxStorageInfo.Volume) or, when it
+// does not record one, from vgName. The filesystem type is left to mount to
+// detect.
+func DevboxMounts(info database.SnapshotInfo, storage database.DevboxStorageInfo, vgName string) ([]Spec, error) {
+	if storage.LvName == "" {
+		return nil, fmt.Errorf("storage entry %s of snapshot %s has no logical volume", storage.ContentID, info.Key)
+	}
+
+	path := storage.Path
+	if path == "" {
+		path = info.Path
+	}
+	if path == "" {
+		return nil, fmt.Errorf("storage entry %s of snapshot %s has no path", storage.ContentID, info.Key)
+	}
+
+	vg, name := storage.Volume()
+	if vg == "" {
+		vg = vgName
+	}
+	if vg == "" {
+		return nil, fmt.Errorf("volume group of logical volume %s of storage entry %s is unknown", name, storage.ContentID)
+	}
+	return []Spec{{
+		Source: filepath.Join("/dev", vg, name),
+		Target: path,
+	}}, nil
+}
+
+// Command renders the mount as a mount(8) command line. Specs without their
+// own target are mounted at target.
+func (s Spec) Command(target string) string {
+	if s.Target != "" {
+		target = s.Target
+	}
+
+	args := []string{"mount"}
+	// Bind mounts are selected through the rbind option
+	if s.Type != "" && s.Type != "bind" {
+		args = append(args, "-t", shellQuote(s.Type))
+	}
+	if len(s.Options) > 0 {
+		args = append(args, "-o", shellQuote(strings.Join(s.Options, ",")))
+	}
+	return strings.Join(append(args, shellQuote(s.Source), shellQuote(target)), " ")
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_/.:,=@%+-]+$`)
+
+// shellQuote quotes s for a POSIX shell when it contains special characters
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
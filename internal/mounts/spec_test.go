@@ -0,0 +1,231 @@
+package mounts
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/database"
+)
+
+func testSnapshotChain() []database.SnapshotInfo {
+	return []database.SnapshotInfo{
+		{Key: "layer1", ID: 1, Kind: snapshots.KindCommitted},
+		{Key: "layer2", ID: 2, Kind: snapshots.KindCommitted, Parent: "layer1"},
+		{Key: "layer3", ID: 3, Kind: snapshots.KindCommitted, Parent: "layer2"},
+		{Key: "container", ID: 4, Kind: snapshots.KindActive, Parent: "layer3"},
+		{Key: "view", ID: 5, Kind: snapshots.KindView, Parent: "layer1"},
+		{Key: "scratch", ID: 6, Kind: snapshots.KindActive},
+		{Key: "orphan", ID: 7, Kind: snapshots.KindActive, Parent: "gone"},
+	}
+}
+
+func TestParentIDs(t *testing.T) {
+	infos := testSnapshotChain()
+
+	ids, err := ParentIDs("container", infos)
+	if err != nil {
+		t.Fatalf("ParentIDs failed: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []uint64{3, 2, 1}) {
+		t.Errorf("Expected parent IDs closest first, got %v", ids)
+	}
+
+	if ids, err := ParentIDs("scratch", infos); err != nil || len(ids) != 0 {
+		t.Errorf("Expected no parents, got %v (%v)", ids, err)
+	}
+	if _, err := ParentIDs("orphan", infos); err == nil {
+		t.Error("Expected an error for a missing parent")
+	}
+	if _, err := ParentIDs("unknown", infos); err == nil {
+		t.Error("Expected an error for an unknown key")
+	}
+
+	loop := []database.SnapshotInfo{
+		{Key: "a", ID: 1, Parent: "b"},
+		{Key: "b", ID: 2, Parent: "a"},
+	}
+	if _, err := ParentIDs("a", loop); err == nil {
+		t.Error("Expected an error for a parent loop")
+	}
+}
+
+func TestOverlayMounts(t *testing.T) {
+	root := "/var/lib/overlay"
+	tests := []struct {
+		name      string
+		info      database.SnapshotInfo
+		parentIDs []uint64
+		expected  []Spec
+	}{
+		{
+			name:      "active with parents",
+			info:      database.SnapshotInfo{Key: "container", ID: 4, Kind: snapshots.KindActive},
+			parentIDs: []uint64{3, 2, 1},
+			expected: []Spec{{
+				Type:   "overlay",
+				Source: "overlay",
+				Options: []string{
+					"index=off",
+					"workdir=/var/lib/overlay/snapshots/4/work",
+					"upperdir=/var/lib/overlay/snapshots/4/fs",
+					"lowerdir=/var/lib/overlay/snapshots/3/fs:/var/lib/overlay/snapshots/2/fs:/var/lib/overlay/snapshots/1/fs",
+				},
+			}},
+		},
+		{
+			name:     "active without parents",
+			info:     database.SnapshotInfo{Key: "scratch", ID: 6, Kind: snapshots.KindActive},
+			expected: []Spec{{Type: "bind", Source: "/var/lib/overlay/snapshots/6/fs", Options: []string{"index=off", "rw", "rbind"}}},
+		},
+		{
+			name:      "view of a single layer",
+			info:      database.SnapshotInfo{Key: "view", ID: 5, Kind: snapshots.KindView},
+			parentIDs: []uint64{1},
+			expected:  []Spec{{Type: "bind", Source: "/var/lib/overlay/snapshots/1/fs", Options: []string{"index=off", "ro", "rbind"}}},
+		},
+		{
+			name:      "view of several layers",
+			info:      database.SnapshotInfo{Key: "view", ID: 5, Kind: snapshots.KindView},
+			parentIDs: []uint64{2, 1},
+			expected: []Spec{{
+				Type:    "overlay",
+				Source:  "overlay",
+				Options: []string{"index=off", "lowerdir=/var/lib/overlay/snapshots/2/fs:/var/lib/overlay/snapshots/1/fs"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs, err := OverlayMounts(root, tt.info, tt.parentIDs, DefaultOverlayOptions)
+			if err != nil {
+				t.Fatalf("OverlayMounts failed: %v", err)
+			}
+			if !reflect.DeepEqual(specs, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, specs)
+			}
+		})
+	}
+
+	if len(DefaultOverlayOptions) != 1 {
+		t.Errorf("Expected default options to be left untouched, got %v", DefaultOverlayOptions)
+	}
+
+	committed := database.SnapshotInfo{Key: "layer1", ID: 1, Kind: snapshots.KindCommitted}
+	if _, err := OverlayMounts(root, committed, nil, nil); err == nil {
+		t.Error("Expected an error for a committed snapshot")
+	}
+}
+
+func TestNativeMounts(t *testing.T) {
+	root := "/var/lib/native"
+	tests := []struct {
+		name      string
+		info      database.SnapshotInfo
+		parentIDs []uint64
+		expected  []Spec
+	}{
+		{
+			name:      "active snapshot binds its copy",
+			info:      database.SnapshotInfo{Key: "active", ID: 6, Kind: snapshots.KindActive},
+			parentIDs: []uint64{5, 1},
+			expected:  []Spec{{Type: "bind", Source: root + "/snapshots/6", Options: []string{"rw", "rbind"}}},
+		},
+		{
+			name:      "view binds its parent",
+			info:      database.SnapshotInfo{Key: "view", ID: 7, Kind: snapshots.KindView},
+			parentIDs: []uint64{5, 1},
+			expected:  []Spec{{Type: "bind", Source: root + "/snapshots/5", Options: []string{"ro", "rbind"}}},
+		},
+		{
+			name:     "view without parent binds itself",
+			info:     database.SnapshotInfo{Key: "scratch", ID: 8, Kind: snapshots.KindView},
+			expected: []Spec{{Type: "bind", Source: root + "/snapshots/8", Options: []string{"ro", "rbind"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs, err := NativeMounts(root, tt.info, tt.parentIDs)
+			if err != nil {
+				t.Fatalf("NativeMounts failed: %v", err)
+			}
+			if !reflect.DeepEqual(specs, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, specs)
+			}
+		})
+	}
+}
+
+func TestDevboxMounts(t *testing.T) {
+	info := database.SnapshotInfo{Key: "devbox", ID: 8, Kind: snapshots.KindActive, ContentID: "abc", Path: "/snapshot/path"}
+	storage := database.DevboxStorageInfo{ContentID: "abc", LvName: "lv-abc", Path: "/mnt/abc"}
+
+	specs, err := DevboxMounts(info, storage, "devbox-vg")
+	if err != nil {
+		t.Fatalf("DevboxMounts failed: %v", err)
+	}
+	if expected := []Spec{{Source: "/dev/devbox-vg/lv-abc", Target: "/mnt/abc"}}; !reflect.DeepEqual(specs, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, specs)
+	}
+
+	// The volume group recorded by the entry wins over vgName
+	for _, storage := range []database.DevboxStorageInfo{
+		{ContentID: "abc", LvName: "lv-abc", Extra: map[string]string{"vg_name": "other-vg"}},
+		{ContentID: "abc", LvName: "other-vg/lv-abc"},
+		{ContentID: "abc", LvName: "/dev/other-vg/lv-abc"},
+	} {
+		specs, err = DevboxMounts(info, storage, "devbox-vg")
+		if err != nil {
+			t.Fatalf("DevboxMounts failed: %v", err)
+		}
+		if specs[0].Source != "/dev/other-vg/lv-abc" || specs[0].Target != "/snapshot/path" {
+			t.Errorf("Expected the entry's volume group and snapshot path fallback for %+v, got %+v", storage, specs[0])
+		}
+	}
+
+	if _, err := DevboxMounts(info, storage, ""); err == nil || !strings.Contains(err.Error(), "volume group") {
+		t.Errorf("Expected an error for an unknown volume group, got %v", err)
+	}
+
+	if _, err := DevboxMounts(info, database.DevboxStorageInfo{ContentID: "abc"}, ""); err == nil {
+		t.Error("Expected an error for a storage entry without LV")
+	}
+}
+
+func TestSpecCommand(t *testing.T) {
+	tests := []struct {
+		spec     Spec
+		target   string
+		expected string
+	}{
+		{
+			spec:     Spec{Type: "overlay", Source: "overlay", Options: []string{"index=off", "lowerdir=/a:/b"}},
+			target:   "/mnt/rootfs",
+			expected: "mount -t overlay -o index=off,lowerdir=/a:/b overlay /mnt/rootfs",
+		},
+		{
+			spec:     Spec{Type: "bind", Source: "/var/lib/snap 1/fs", Options: []string{"ro", "rbind"}},
+			target:   "/mnt",
+			expected: "mount -o ro,rbind '/var/lib/snap 1/fs' /mnt",
+		},
+		{
+			spec:     Spec{Source: "/dev/vg/lv", Target: "/mnt/abc"},
+			target:   "/ignored",
+			expected: "mount /dev/vg/lv /mnt/abc",
+		},
+		{
+			spec:     Spec{Type: "bind", Source: "it's", Options: []string{"rbind"}},
+			target:   "/mnt",
+			expected: `mount -o rbind 'it'\''s' /mnt`,
+		},
+	}
+
+	for _, tt := range tests {
+		if result := tt.spec.Command(tt.target); result != tt.expected {
+			t.Errorf("Command() = %s, expected %s", result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package procs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/meta-viewer/internal/utils"
+	"github.com/moby/sys/mountinfo"
+)
+
+// DefaultProcRoot is where the host's process table is mounted
+const DefaultProcRoot = "/proc"
+
+// Use types
+const (
+	UseFD    = "fd"
+	UseCwd   = "cwd"
+	UseMount = "mount"
+)
+
+// containerIDPattern matches the container ID embedded in cgroup paths
+// such as /kubepods/.../cri-containerd-<id>.scope
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// Use is a reference a process holds on a path
+type Use struct {
+	Type string `json:"type"`
+	FD   int    `json:"fd,omitempty"`
+	Path string `json:"path"`
+}
+
+// User is a process that uses one of the inspected paths
+type User struct {
+	PID         int    `json:"pid"`
+	Command     string `json:"command"`
+	Cgroup      string `json:"cgroup,omitempty"`
+	ContainerID string `json:"container_id,omitempty"`
+	Uses        []Use  `json:"uses"`
+}
+
+// FindUsers scans every process under procRoot for open files, working
+// directories and mounts under any of paths. An empty procRoot reads the host
+// process table from DefaultProcRoot under utils.HostRoot.
+//
+// Processes sharing a mount namespace see the same mounts, so mount uses
+// are reported once per namespace, on its lowest PID. Bind mounts of paths
+// are found wherever they are mounted (see mountSource). Processes that
+// cannot be inspected, e.g. for lack of permission, are skipped.
+func FindUsers(procRoot string, paths []string) ([]User, error) {
+	if procRoot == "" {
+		procRoot = utils.HostPath(DefaultProcRoot)
+	}
+
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read process table: %w", err)
+	}
+
+	var pids []int
+	for _, entry := range entries {
+		if pid, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() {
+			pids = append(pids, pid)
+		}
+	}
+	sort.Ints(pids)
+
+	users := []User{}
+	mounts := &mountScanner{paths: paths, seen: make(map[string]bool)}
+	for _, pid := range pids {
+		dir := filepath.Join(procRoot, strconv.Itoa(pid))
+
+		uses := fdUses(dir, paths)
+		if cwd, err := os.Readlink(filepath.Join(dir, "cwd")); err == nil && underAny(cwd, paths) {
+			uses = append(uses, Use{Type: UseCwd, Path: cwd})
+		}
+
+		uses = append(uses, mounts.uses(dir)...)
+
+		if len(uses) == 0 {
+			continue
+		}
+
+		user := User{
+			PID:     pid,
+			Command: readCommand(dir),
+			Cgroup:  readCgroup(dir),
+			Uses:    uses,
+		}
+		user.ContainerID = containerIDPattern.FindString(user.Cgroup)
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// fdUses returns the open files of a process under any of paths
+func fdUses(dir string, paths []string) []Use {
+	fdDir := filepath.Join(dir, "fd")
+	entries, err := os.ReadDir(fdDir)
+	if err != nil {
+		return nil
+	}
+
+	var uses []Use
+	for _, entry := range entries {
+		fd, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		target, err := os.Readlink(filepath.Join(fdDir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		if underAny(strings.TrimSuffix(target, " (deleted)"), paths) {
+			uses = append(uses, Use{Type: UseFD, FD: fd, Path: target})
+		}
+	}
+	return uses
+}
+
+// mountScanner finds the mount uses of processes, once per mount namespace
+type mountScanner struct {
+	paths []string
+
+	// sources locate paths in the mount namespace of the lowest PID, which
+	// is the host's when scanning the host process table
+	sources  []mountSource
+	resolved bool
+
+	// seen holds the mount namespaces already scanned
+	seen map[string]bool
+}
+
+// uses returns the mounts of a process's namespace placed under any of the
+// paths, bind mounting them, or whose filesystem options (e.g. overlay
+// lowerdir) refer to them. Namespaces already scanned report nothing.
+func (s *mountScanner) uses(dir string) []Use {
+	ns, nsErr := os.Readlink(filepath.Join(dir, "ns", "mnt"))
+	if nsErr == nil {
+		if s.seen[ns] {
+			return nil
+		}
+		s.seen[ns] = true
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "mountinfo"))
+	if err != nil {
+		return nil
+	}
+	if nsErr != nil {
+		// Without the namespace link, namespaces are told apart by their mounts
+		ns = "mountinfo:" + string(data)
+		if s.seen[ns] {
+			return nil
+		}
+		s.seen[ns] = true
+	}
+
+	infos, err := mountinfo.GetMountsFromReader(bytes.NewReader(data), nil)
+	if err != nil {
+		return nil
+	}
+	if !s.resolved && len(infos) > 0 {
+		s.sources = resolveSources(infos, s.paths)
+		s.resolved = true
+	}
+
+	var uses []Use
+	for _, info := range infos {
+		if strictlyUnderAny(info.Mountpoint, s.paths) || bindOfAny(info, s.sources) || optionsReferTo(info.VFSOptions, s.paths) {
+			uses = append(uses, Use{Type: UseMount, Path: info.Mountpoint})
+		}
+	}
+	return uses
+}
+
+// mountSource identifies an inspected path by the filesystem holding it:
+// the device of the mount it lies on and its path within that filesystem.
+// Bind mounts of the path, wherever they are mounted, show the same device
+// with a root at or below that path in mountinfo.
+type mountSource struct {
+	path  string
+	major int
+	minor int
+	root  string
+}
+
+// resolveSources locates paths on the mounts of a mount table
+func resolveSources(infos []*mountinfo.Info, paths []string) []mountSource {
+	var sources []mountSource
+	for _, path := range paths {
+		path = filepath.Clean(path)
+
+		// The last of the longest mount points holding the path is visible
+		var holder *mountinfo.Info
+		for _, info := range infos {
+			if underAny(path, []string{info.Mountpoint}) && (holder == nil || len(info.Mountpoint) >= len(holder.Mountpoint)) {
+				holder = info
+			}
+		}
+		if holder == nil {
+			continue
+		}
+		rel, err := filepath.Rel(holder.Mountpoint, path)
+		if err != nil {
+			continue
+		}
+		sources = append(sources, mountSource{
+			path:  path,
+			major: holder.Major,
+			minor: holder.Minor,
+			root:  filepath.Join(holder.Root, rel),
+		})
+	}
+	return sources
+}
+
+// bindOfAny reports whether a mount shows one of the sources, or a
+// directory below it, somewhere other than at the source path itself
+func bindOfAny(info *mountinfo.Info, sources []mountSource) bool {
+	for _, source := range sources {
+		if info.Major == source.major && info.Minor == source.minor &&
+			underAny(info.Root, []string{source.root}) && info.Mountpoint != source.path {
+			return true
+		}
+	}
+	return false
+}
+
+// optionsReferTo reports whether any option value names a path under paths.
+// Values may be colon separated lists, as for overlay's lowerdir.
+func optionsReferTo(options string, paths []string) bool {
+	for _, option := range strings.Split(options, ",") {
+		_, value, ok := strings.Cut(option, "=")
+		if !ok {
+			continue
+		}
+		for _, path := range strings.Split(value, ":") {
+			if underAny(path, paths) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// underAny reports whether path is one of paths or lies below one of them
+func underAny(path string, paths []string) bool {
+	for _, p := range paths {
+		if path == p || strings.HasPrefix(path, strings.TrimSuffix(p, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// strictlyUnderAny reports whether path lies below one of paths. A mount at
+// the path itself, such as a devbox LV at its storage path, is not a user.
+func strictlyUnderAny(path string, paths []string) bool {
+	for _, p := range paths {
+		if path != p && strings.HasPrefix(path, strings.TrimSuffix(p, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// readCommand returns the command line of a process, or its name in
+// brackets for kernel threads and processes that cleared their arguments
+func readCommand(dir string) string {
+	if data, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		if cmdline := strings.TrimSpace(strings.ReplaceAll(string(data), "\x00", " ")); cmdline != "" {
+			return cmdline
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+		return "[" + strings.TrimSpace(string(data)) + "]"
+	}
+	return ""
+}
+
+// readCgroup returns the cgroup v2 path of a process, falling back to the
+// first hierarchy listed on cgroup v1 hosts
+func readCgroup(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "cgroup"))
+	if err != nil {
+		return ""
+	}
+
+	var first string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			return fields[2]
+		}
+		if first == "" {
+			first = fields[2]
+		}
+	}
+	return first
+}
@@ -0,0 +1,213 @@
+package procs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/containerd/meta-viewer/internal/utils"
+)
+
+const testContainerID = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// fakeProcess describes a process written to a fake /proc tree
+type fakeProcess struct {
+	pid       int
+	cmdline   string
+	comm      string
+	cgroup    string
+	cwd       string
+	fds       map[int]string
+	mountNS   string
+	mountinfo string
+}
+
+func setupFakeProc(t *testing.T, processes []fakeProcess) string {
+	t.Helper()
+	root := t.TempDir()
+
+	for _, p := range processes {
+		dir := filepath.Join(root, strconv.Itoa(p.pid))
+		for _, sub := range []string{"fd", "ns"} {
+			if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+				t.Fatalf("Failed to create directory: %v", err)
+			}
+		}
+
+		files := map[string]string{
+			"cmdline":   p.cmdline,
+			"comm":      p.comm,
+			"cgroup":    p.cgroup,
+			"mountinfo": p.mountinfo,
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write %s: %v", name, err)
+			}
+		}
+
+		links := map[string]string{"cwd": p.cwd, "ns/mnt": p.mountNS}
+		for fd, target := range p.fds {
+			links[filepath.Join("fd", strconv.Itoa(fd))] = target
+		}
+		for name, target := range links {
+			if target == "" {
+				continue
+			}
+			if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+				t.Fatalf("Failed to create link %s: %v", name, err)
+			}
+		}
+	}
+
+	// Non-process entries are ignored
+	if err := os.MkdirAll(filepath.Join(root, "sys"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	return root
+}
+
+const hostMountInfo = `22 1 8:1 / / rw,relatime - ext4 /dev/sda1 rw
+40 22 0:40 / /run/containerd/rootfs rw,relatime - overlay overlay rw,lowerdir=/var/lib/snap/snapshots/2/fs:/var/lib/snap/snapshots/1/fs,upperdir=/var/lib/snap/snapshots/3/fs,workdir=/var/lib/snap/snapshots/3/work
+41 22 253:3 / /mnt/devbox/abc rw,relatime - ext4 /dev/mapper/vg-lv--abc rw
+42 41 0:41 / /mnt/devbox/abc/proc rw - proc proc rw
+`
+
+func TestFindUsers(t *testing.T) {
+	root := setupFakeProc(t, []fakeProcess{
+		{
+			pid:       1,
+			cmdline:   "/sbin/init\x00splash\x00",
+			cgroup:    "0::/init.scope\n",
+			cwd:       "/",
+			mountNS:   "mnt:[1]",
+			mountinfo: hostMountInfo,
+		},
+		{
+			// Same mount namespace as PID 1, so its mounts are not reported again
+			pid:       20,
+			cmdline:   "sh\x00",
+			cgroup:    "0::/kubepods/burstable/pod1/cri-containerd-" + testContainerID + ".scope\n",
+			cwd:       "/mnt/devbox/abc/work",
+			fds:       map[int]string{0: "/dev/null", 3: "/var/lib/snap/snapshots/3/fs/log (deleted)", 4: "/var/lib/snap/snapshots/30/fs/x"},
+			mountNS:   "mnt:[1]",
+			mountinfo: hostMountInfo,
+		},
+		{
+			pid:     30,
+			comm:    "kworker\n",
+			cgroup:  "12:pids:/system.slice\n11:memory:/system.slice\n",
+			cwd:     "/",
+			mountNS: "mnt:[2]",
+		},
+	})
+
+	users, err := FindUsers(root, []string{"/var/lib/snap/snapshots/3", "/mnt/devbox/abc/"})
+	if err != nil {
+		t.Fatalf("FindUsers failed: %v", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("Expected 2 users, got %d: %+v", len(users), users)
+	}
+
+	initUser := users[0]
+	if initUser.PID != 1 || initUser.Command != "/sbin/init splash" || initUser.Cgroup != "/init.scope" || initUser.ContainerID != "" {
+		t.Errorf("Unexpected init user %+v", initUser)
+	}
+	expectedMounts := []Use{
+		{Type: UseMount, Path: "/run/containerd/rootfs"},
+		{Type: UseMount, Path: "/mnt/devbox/abc/proc"},
+	}
+	if !reflect.DeepEqual(initUser.Uses, expectedMounts) {
+		t.Errorf("Expected mount uses %+v, got %+v", expectedMounts, initUser.Uses)
+	}
+
+	shell := users[1]
+	if shell.PID != 20 || shell.ContainerID != testContainerID {
+		t.Errorf("Unexpected shell user %+v", shell)
+	}
+	expectedUses := []Use{
+		{Type: UseFD, FD: 3, Path: "/var/lib/snap/snapshots/3/fs/log (deleted)"},
+		{Type: UseCwd, Path: "/mnt/devbox/abc/work"},
+	}
+	if !reflect.DeepEqual(shell.Uses, expectedUses) {
+		t.Errorf("Expected uses %+v, got %+v", expectedUses, shell.Uses)
+	}
+}
+
+// containerMountInfo bind mounts the devbox LV mounted at /mnt/devbox/abc
+// on the host at /data, and a directory of a snapshot at /cache
+const containerMountInfo = `500 400 0:50 / / rw - overlay overlay rw,lowerdir=/var/lib/snap/snapshots/9/fs
+501 500 253:3 / /data rw,relatime - ext4 /dev/mapper/vg-lv--abc rw
+502 500 8:1 /var/lib/snap/snapshots/3/fs/cache /cache rw,relatime - ext4 /dev/sda1 rw
+503 500 8:1 /var/lib/other /other rw,relatime - ext4 /dev/sda1 rw
+`
+
+func TestFindUsers_BindMounts(t *testing.T) {
+	root := setupFakeProc(t, []fakeProcess{
+		{pid: 1, cmdline: "init", cwd: "/", mountNS: "mnt:[1]", mountinfo: hostMountInfo},
+		// Without a readable ns/mnt link, namespaces are told apart by their mounts
+		{pid: 40, cmdline: "app", cwd: "/", mountinfo: containerMountInfo},
+		{pid: 41, cmdline: "app worker", cwd: "/", mountinfo: containerMountInfo},
+	})
+
+	users, err := FindUsers(root, []string{"/var/lib/snap/snapshots/3", "/mnt/devbox/abc"})
+	if err != nil {
+		t.Fatalf("FindUsers failed: %v", err)
+	}
+	if len(users) != 2 || users[1].PID != 40 {
+		t.Fatalf("Expected init and PID 40 as users, got %+v", users)
+	}
+
+	expected := []Use{
+		{Type: UseMount, Path: "/data"},
+		{Type: UseMount, Path: "/cache"},
+	}
+	if !reflect.DeepEqual(users[1].Uses, expected) {
+		t.Errorf("Expected bind mount uses %+v, got %+v", expected, users[1].Uses)
+	}
+}
+
+func TestFindUsers_NoUsers(t *testing.T) {
+	root := setupFakeProc(t, []fakeProcess{{pid: 1, cmdline: "init", cwd: "/"}})
+
+	users, err := FindUsers(root, []string{"/var/lib/snap/snapshots/3"})
+	if err != nil {
+		t.Fatalf("FindUsers failed: %v", err)
+	}
+	if users == nil || len(users) != 0 {
+		t.Errorf("Expected an empty list, got %#v", users)
+	}
+}
+
+func TestFindUsers_HostRoot(t *testing.T) {
+	hostRoot := t.TempDir()
+	procRoot := setupFakeProc(t, []fakeProcess{{pid: 7, cmdline: "vim", cwd: "/data/x"}})
+	if err := os.Symlink(procRoot, filepath.Join(hostRoot, "proc")); err != nil {
+		t.Fatalf("Failed to link proc: %v", err)
+	}
+
+	old := utils.HostRoot
+	utils.HostRoot = hostRoot
+	defer func() { utils.HostRoot = old }()
+
+	users, err := FindUsers("", []string{"/data"})
+	if err != nil {
+		t.Fatalf("FindUsers failed: %v", err)
+	}
+	if len(users) != 1 || users[0].PID != 7 {
+		t.Errorf("Expected process from the host's /proc, got %+v", users)
+	}
+}
+
+func TestReadCgroup_V1(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cgroup"), []byte("4:cpu:/docker/abc\n3:pids:/docker/abc\n"), 0644); err != nil {
+		t.Fatalf("Failed to write cgroup: %v", err)
+	}
+	if cgroup := readCgroup(dir); cgroup != "/docker/abc" {
+		t.Errorf("Expected first v1 hierarchy, got %s", cgroup)
+	}
+}
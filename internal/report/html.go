@@ -0,0 +1,145 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+
+	"github.com/containerd/meta-viewer/internal/formatters"
+)
+
+// htmlTemplate renders a single file report: styles and the table sorting
+// script are inline, so it can be attached to a ticket and opened offline.
+// Cells holding sizes carry the byte count in data-value for sorting.
+var htmlTemplate = template.Must(template.New("html").Funcs(templateFuncs(formatters.TableOptions{})).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Snapshotter metadata report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #1f2328; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.25em; margin-top: 2em; border-bottom: 1px solid #d0d7de; padding-bottom: .3em; }
+dl { display: grid; grid-template-columns: max-content auto; gap: .2em 1em; }
+dt { font-weight: 600; }
+dd { margin: 0; }
+table { border-collapse: collapse; margin: .5em 0; }
+th, td { border: 1px solid #d0d7de; padding: .3em .7em; text-align: left; }
+th { background: #f6f8fa; cursor: pointer; user-select: none; white-space: nowrap; }
+th[data-order="asc"]::after { content: " \25B2"; }
+th[data-order="desc"]::after { content: " \25BC"; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+td.mono { font-family: ui-monospace, Menlo, Consolas, monospace; word-break: break-all; }
+tr:nth-child(even) td { background: #fafbfc; }
+</style>
+</head>
+<body>
+<h1>Snapshotter metadata report</h1>
+<dl>
+<dt>Source</dt><dd>{{.Source}}</dd>
+<dt>Snapshotter</dt><dd>{{.Snapshotter}}</dd>
+<dt>Generated</dt><dd>{{time .GeneratedAt}}</dd>
+<dt>Snapshots</dt><dd>{{.TotalSnapshots}} ({{size .TotalSize}})</dd>
+</dl>
+
+<h2>Buckets</h2>
+<table class="sortable">
+<thead><tr><th>Bucket</th><th>Keys</th></tr></thead>
+<tbody>
+{{- range .Buckets}}
+<tr><td class="mono">{{.Name}}</td><td class="num">{{.KeyCount}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+
+<h2>Snapshots by kind</h2>
+<table class="sortable">
+<thead><tr><th>Kind</th><th>Count</th><th>Size</th></tr></thead>
+<tbody>
+{{- range .Kinds}}
+<tr><td>{{.Kind}}</td><td class="num">{{.Count}}</td><td class="num" data-value="{{.Size}}">{{size .Size}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+
+<h2>Largest snapshots</h2>
+<table class="sortable">
+<thead><tr><th>ID</th><th>Key</th><th>Kind</th><th>Parent</th><th>Size</th><th>Created</th></tr></thead>
+<tbody>
+{{- range .Largest}}
+<tr><td class="num">{{.ID}}</td><td class="mono">{{.Key}}</td><td>{{kind .Kind}}</td><td class="mono">{{dash .Parent}}</td><td class="num" data-value="{{.Size}}">{{size .Size}}</td><td data-value="{{.CreatedAt.Unix}}">{{time .CreatedAt}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+
+<h2>Lineage</h2>
+<table class="sortable">
+<thead><tr><th>Root</th><th>Descendants</th><th>Committed</th><th>Active</th><th>Views</th><th>Depth</th><th>Size</th></tr></thead>
+<tbody>
+{{- range .Lineage}}
+<tr><td class="mono">{{.Root}}</td><td class="num">{{.Descendants}}</td><td class="num">{{.Committed}}</td><td class="num">{{.Active}}</td><td class="num">{{.Views}}</td><td class="num">{{.Depth}}</td><td class="num" data-value="{{.Size}}">{{size .Size}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- if .Devbox}}
+
+<h2>Devbox storage by status</h2>
+<table class="sortable">
+<thead><tr><th>Status</th><th>Entries</th><th>Snapshots</th></tr></thead>
+<tbody>
+{{- range .Statuses}}
+<tr><td>{{.Status}}</td><td class="num">{{.Entries}}</td><td class="num">{{.Snapshots}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+
+<h2>LVM map</h2>
+<table class="sortable">
+<thead><tr><th>LV name</th><th>Path</th></tr></thead>
+<tbody>
+{{- range .LVMMap}}
+<tr><td class="mono">{{.LvName}}</td><td class="mono">{{.Path}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+
+<script>
+document.querySelectorAll("table.sortable th").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var body = th.closest("table").tBodies[0];
+    var index = th.cellIndex;
+    var ascending = th.dataset.order !== "asc";
+    var value = function (row) {
+      var cell = row.cells[index];
+      return cell.dataset.value !== undefined ? cell.dataset.value : cell.textContent;
+    };
+    var rows = Array.prototype.slice.call(body.rows);
+    rows.sort(function (a, b) {
+      var x = value(a), y = value(b);
+      var nx = Number(x), ny = Number(y);
+      var order = (x !== "" && y !== "" && !isNaN(nx) && !isNaN(ny)) ? nx - ny : x.localeCompare(y);
+      return ascending ? order : -order;
+    });
+    rows.forEach(function (row) { body.appendChild(row); });
+    th.parentNode.querySelectorAll("th").forEach(function (other) { delete other.dataset.order; });
+    th.dataset.order = ascending ? "asc" : "desc";
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// WriteHTML renders the report as a self-contained HTML document, with sizes
+// and times as in tables laid out with opts
+func WriteHTML(w io.Writer, r *Report, opts formatters.TableOptions) error {
+	t, err := htmlTemplate.Clone()
+	if err != nil {
+		return err
+	}
+	if err := t.Funcs(templateFuncs(opts)).Execute(w, r); err != nil {
+		return fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/formatters"
+)
+
+// templateFuncs are the helpers shared by the Markdown and HTML templates.
+// Sizes and times are written in the units and time zone of opts.
+func templateFuncs(opts formatters.TableOptions) map[string]interface{} {
+	return map[string]interface{}{
+		"size": opts.Size,
+		"kind": database.SnapshotKindString,
+		"time": opts.Time,
+		"dash": func(s string) string {
+			if s == "" {
+				return "-"
+			}
+			return s
+		},
+	}
+}
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(templateFuncs(formatters.TableOptions{})).Funcs(template.FuncMap{
+	"cell": markdownCell,
+}).Parse(`# Snapshotter metadata report
+
+- Source: {{cell .Source}}
+- Snapshotter: {{.Snapshotter}}
+- Generated: {{time .GeneratedAt}}
+- Snapshots: {{.TotalSnapshots}} ({{size .TotalSize}})
+
+## Buckets
+
+| Bucket | Keys |
+|---|---:|
+{{- range .Buckets}}
+| {{cell .Name}} | {{.KeyCount}} |
+{{- end}}
+
+## Snapshots by kind
+
+| Kind | Count | Size |
+|---|---:|---:|
+{{- range .Kinds}}
+| {{.Kind}} | {{.Count}} | {{size .Size}} |
+{{- end}}
+
+## Largest snapshots
+
+| ID | Key | Kind | Parent | Size | Created |
+|---:|---|---|---|---:|---|
+{{- range .Largest}}
+| {{.ID}} | {{cell .Key}} | {{kind .Kind}} | {{cell (dash .Parent)}} | {{size .Size}} | {{time .CreatedAt}} |
+{{- end}}
+
+## Lineage
+
+| Root | Descendants | Committed | Active | Views | Depth | Size |
+|---|---:|---:|---:|---:|---:|---:|
+{{- range .Lineage}}
+| {{cell .Root}} | {{.Descendants}} | {{.Committed}} | {{.Active}} | {{.Views}} | {{.Depth}} | {{size .Size}} |
+{{- end}}
+{{- if .Devbox}}
+
+## Devbox storage by status
+
+| Status | Entries | Snapshots |
+|---|---:|---:|
+{{- range .Statuses}}
+| {{cell .Status}} | {{.Entries}} | {{.Snapshots}} |
+{{- end}}
+
+## LVM map
+
+| LV name | Path |
+|---|---|
+{{- range .LVMMap}}
+| {{cell .LvName}} | {{cell .Path}} |
+{{- end}}
+{{- end}}
+`))
+
+// markdownCell escapes s for a Markdown table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// WriteMarkdown renders the report as a Markdown document, with sizes and
+// times as in tables laid out with opts
+func WriteMarkdown(w io.Writer, r *Report, opts formatters.TableOptions) error {
+	t, err := markdownTemplate.Clone()
+	if err != nil {
+		return err
+	}
+	if err := t.Funcs(templateFuncs(opts)).Execute(w, r); err != nil {
+		return fmt.Errorf("failed to render Markdown report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/formatters"
+)
+
+// DefaultTop is the default number of snapshots in the largest snapshots section
+const DefaultTop = 10
+
+// Input is the database content a report is built from
+type Input struct {
+	Source      string
+	Snapshotter string
+	Buckets     []database.BucketInfo
+	Snapshots   []database.SnapshotInfo
+	Storage     []database.DevboxStorageInfo
+	Devbox      bool // The database has a devbox style storage bucket
+}
+
+// KindCount is the number and total size of the snapshots of one kind
+type KindCount struct {
+	Kind  string
+	Count int
+	Size  int64
+}
+
+// Lineage summarises the snapshots descending from a root snapshot, one
+// without a parent or whose parent is missing
+type Lineage struct {
+	Root        string
+	Descendants int
+	Committed   int
+	Active      int
+	Views       int
+	Depth       int   // Longest parent chain below the root, the root alone is 1
+	Size        int64 // Total size of the root and its descendants
+}
+
+// StatusCount is the number of devbox storage entries with a status and
+// the number of snapshots referencing them
+type StatusCount struct {
+	Status    string
+	Entries   int
+	Snapshots int
+}
+
+// Report is the summary of a snapshotter database rendered by the report command
+type Report struct {
+	Source         string
+	Snapshotter    string
+	GeneratedAt    time.Time
+	Buckets        []database.BucketInfo
+	TotalSnapshots int
+	TotalSize      int64
+	Kinds          []KindCount
+	Largest        []database.SnapshotInfo
+	Lineage        []Lineage
+	Devbox         bool
+	Statuses       []StatusCount
+	LVMMap         []formatters.LVMMapping
+}
+
+// Build summarises the input, keeping the top largest snapshots
+func Build(in Input, top int, now time.Time) *Report {
+	r := &Report{
+		Source:         in.Source,
+		Snapshotter:    in.Snapshotter,
+		GeneratedAt:    now,
+		Buckets:        in.Buckets,
+		TotalSnapshots: len(in.Snapshots),
+		Devbox:         in.Devbox,
+	}
+
+	kinds := make(map[snapshots.Kind]*KindCount)
+	for _, snapshot := range in.Snapshots {
+		r.TotalSize += snapshot.Size
+		count, ok := kinds[snapshot.Kind]
+		if !ok {
+			count = &KindCount{Kind: database.SnapshotKindString(snapshot.Kind)}
+			kinds[snapshot.Kind] = count
+		}
+		count.Count++
+		count.Size += snapshot.Size
+	}
+	for _, kind := range []snapshots.Kind{snapshots.KindCommitted, snapshots.KindActive, snapshots.KindView, snapshots.KindUnknown} {
+		if count, ok := kinds[kind]; ok {
+			r.Kinds = append(r.Kinds, *count)
+		}
+	}
+
+	largest := append([]database.SnapshotInfo(nil), in.Snapshots...)
+	sort.SliceStable(largest, func(i, j int) bool {
+		if largest[i].Size != largest[j].Size {
+			return largest[i].Size > largest[j].Size
+		}
+		return largest[i].Key < largest[j].Key
+	})
+	if top >= 0 && len(largest) > top {
+		largest = largest[:top]
+	}
+	r.Largest = largest
+
+	r.Lineage = buildLineage(in.Snapshots)
+
+	if in.Devbox {
+		r.Statuses = statusCounts(in.Storage, in.Snapshots)
+		r.LVMMap = formatters.LVMMappings(in.Storage)
+	}
+	return r
+}
+
+// buildLineage summarises the tree below each root snapshot, largest trees first
+func buildLineage(infos []database.SnapshotInfo) []Lineage {
+	byKey := make(map[string]database.SnapshotInfo, len(infos))
+	for _, info := range infos {
+		byKey[info.Key] = info
+	}
+	children := make(map[string][]string)
+	var roots []string
+	for _, info := range infos {
+		if _, ok := byKey[info.Parent]; info.Parent == "" || !ok {
+			roots = append(roots, info.Key)
+			continue
+		}
+		children[info.Parent] = append(children[info.Parent], info.Key)
+	}
+
+	lineage := make([]Lineage, 0, len(roots))
+	for _, root := range roots {
+		l := Lineage{Root: root}
+		visited := make(map[string]bool)
+		var walk func(key string, depth int)
+		walk = func(key string, depth int) {
+			if visited[key] {
+				return
+			}
+			visited[key] = true
+
+			info := byKey[key]
+			l.Size += info.Size
+			if depth > l.Depth {
+				l.Depth = depth
+			}
+			if key != root {
+				l.Descendants++
+			}
+			switch info.Kind {
+			case snapshots.KindCommitted:
+				l.Committed++
+			case snapshots.KindActive:
+				l.Active++
+			case snapshots.KindView:
+				l.Views++
+			}
+			for _, child := range children[key] {
+				walk(child, depth+1)
+			}
+		}
+		walk(root, 1)
+		lineage = append(lineage, l)
+	}
+
+	sort.Slice(lineage, func(i, j int) bool {
+		if lineage[i].Descendants != lineage[j].Descendants {
+			return lineage[i].Descendants > lineage[j].Descendants
+		}
+		return lineage[i].Root < lineage[j].Root
+	})
+	return lineage
+}
+
+// statusCounts counts devbox storage entries and the snapshots referencing them by status
+func statusCounts(storage []database.DevboxStorageInfo, infos []database.SnapshotInfo) []StatusCount {
+	references := make(map[string]int)
+	for _, info := range infos {
+		if info.ContentID != "" {
+			references[info.ContentID]++
+		}
+	}
+
+	byStatus := make(map[string]*StatusCount)
+	for _, item := range storage {
+		status := item.Status
+		if status == "" {
+			status = "unknown"
+		}
+		count, ok := byStatus[status]
+		if !ok {
+			count = &StatusCount{Status: status}
+			byStatus[status] = count
+		}
+		count.Entries++
+		count.Snapshots += references[item.ContentID]
+	}
+
+	counts := make([]StatusCount, 0, len(byStatus))
+	for _, count := range byStatus {
+		counts = append(counts, *count)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Status < counts[j].Status
+	})
+	return counts
+}
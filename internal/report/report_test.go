@@ -0,0 +1,174 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/snapshots"
+	"github.com/containerd/meta-viewer/internal/database"
+	"github.com/containerd/meta-viewer/internal/formatters"
+)
+
+func testInput() Input {
+	return Input{
+		Source:      "/var/lib/metadata.db",
+		Snapshotter: "devbox",
+		Buckets:     []database.BucketInfo{{Name: "v1", KeyCount: 12}},
+		Snapshots: []database.SnapshotInfo{
+			{Key: "base", ID: 1, Kind: snapshots.KindCommitted, Size: 100},
+			{Key: "layer", ID: 2, Kind: snapshots.KindCommitted, Parent: "base", Size: 300},
+			{Key: "c|1", ID: 3, Kind: snapshots.KindActive, Parent: "layer", Size: 50, ContentID: "content-1"},
+			{Key: "c2", ID: 4, Kind: snapshots.KindActive, Parent: "layer", Size: 50, ContentID: "content-1"},
+			{Key: "lonely", ID: 5, Kind: snapshots.KindView, Parent: "missing", Size: 10},
+		},
+		Storage: []database.DevboxStorageInfo{
+			{ContentID: "content-1", LvName: "lv-1", Path: "/data/1", Status: "active"},
+			{ContentID: "content-2", LvName: "lv-2", Status: "removed"},
+			{ContentID: "content-3"},
+		},
+		Devbox: true,
+	}
+}
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := Build(testInput(), 2, now)
+
+	if r.TotalSnapshots != 5 || r.TotalSize != 510 {
+		t.Errorf("Expected 5 snapshots of 510 bytes, got %d of %d", r.TotalSnapshots, r.TotalSize)
+	}
+
+	expectedKinds := []KindCount{
+		{Kind: "committed", Count: 2, Size: 400},
+		{Kind: "active", Count: 2, Size: 100},
+		{Kind: "view", Count: 1, Size: 10},
+	}
+	if len(r.Kinds) != len(expectedKinds) {
+		t.Fatalf("Expected kinds %+v, got %+v", expectedKinds, r.Kinds)
+	}
+	for i, kind := range expectedKinds {
+		if r.Kinds[i] != kind {
+			t.Errorf("Expected kind %+v, got %+v", kind, r.Kinds[i])
+		}
+	}
+
+	if len(r.Largest) != 2 || r.Largest[0].Key != "layer" || r.Largest[1].Key != "base" {
+		t.Errorf("Expected the two largest snapshots layer and base, got %+v", r.Largest)
+	}
+
+	if len(r.Lineage) != 2 {
+		t.Fatalf("Expected two roots, got %+v", r.Lineage)
+	}
+	expected := Lineage{Root: "base", Descendants: 3, Committed: 2, Active: 2, Depth: 3, Size: 500}
+	if r.Lineage[0] != expected {
+		t.Errorf("Expected lineage %+v, got %+v", expected, r.Lineage[0])
+	}
+	if r.Lineage[1].Root != "lonely" || r.Lineage[1].Views != 1 {
+		t.Errorf("Expected the snapshot with a missing parent to be a root, got %+v", r.Lineage[1])
+	}
+
+	expectedStatuses := []StatusCount{
+		{Status: "active", Entries: 1, Snapshots: 2},
+		{Status: "removed", Entries: 1},
+		{Status: "unknown", Entries: 1},
+	}
+	for i, status := range expectedStatuses {
+		if i >= len(r.Statuses) || r.Statuses[i] != status {
+			t.Errorf("Expected statuses %+v, got %+v", expectedStatuses, r.Statuses)
+			break
+		}
+	}
+	if len(r.LVMMap) != 1 || r.LVMMap[0].LvName != "lv-1" {
+		t.Errorf("Expected only lv-1 in the LVM map, got %+v", r.LVMMap)
+	}
+}
+
+func TestBuild_NotDevbox(t *testing.T) {
+	in := testInput()
+	in.Devbox = false
+	r := Build(in, DefaultTop, time.Now())
+
+	if r.Statuses != nil || r.LVMMap != nil {
+		t.Errorf("Expected no devbox sections, got %+v and %+v", r.Statuses, r.LVMMap)
+	}
+	if len(r.Largest) != 5 {
+		t.Errorf("Expected all 5 snapshots within the default top, got %d", len(r.Largest))
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, r, formatters.TableOptions{}); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "LVM map") {
+		t.Error("Expected no LVM map section for a non-devbox database")
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	r := Build(testInput(), DefaultTop, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, r, formatters.TableOptions{}); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	out := buf.String()
+
+	expected := []string{
+		"- Snapshots: 5 (510B)",
+		"| v1 | 12 |",
+		"| committed | 2 | 400B |",
+		`| 3 | c\|1 | active | layer | 50B | `,
+		"| base | 3 | 2 | 2 | 0 | 3 | 500B |",
+		"| active | 1 | 2 |",
+		"| lv-1 | /data/1 |",
+	}
+	for _, s := range expected {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected Markdown to contain %q\n%s", s, out)
+		}
+	}
+}
+
+func TestWriteMarkdown_Options(t *testing.T) {
+	r := Build(testInput(), DefaultTop, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	opts := formatters.TableOptions{Units: formatters.UnitsBytes, Location: time.FixedZone("CST", 8*3600)}
+
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, r, opts); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, s := range []string{"- Snapshots: 5 (510)", "| committed | 2 | 400 |", "- Generated: 2024-01-01 08:00:00 CST"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected Markdown to contain %q\n%s", s, out)
+		}
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	in := testInput()
+	in.Snapshots[0].Key = "<script>alert(1)</script>"
+	in.Snapshots[1].Parent = in.Snapshots[0].Key
+	r := Build(in, DefaultTop, time.Now())
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, r, formatters.TableOptions{}); err != nil {
+		t.Fatalf("WriteHTML failed: %v", err)
+	}
+	out := buf.String()
+
+	if strings.Contains(out, "<script>alert") {
+		t.Error("Expected snapshot keys to be escaped")
+	}
+	if strings.Contains(out, "http://") || strings.Contains(out, "https://") || strings.Contains(out, " src=") {
+		t.Error("Expected no external assets")
+	}
+	for _, s := range []string{`class="sortable"`, `data-value="300">300B</td>`, "<h2>LVM map</h2>", "<script>"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected HTML to contain %q", s)
+		}
+	}
+}
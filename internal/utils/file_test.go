@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.json")
+
+	write := func(content string) func(w io.Writer) error {
+		return func(w io.Writer) error {
+			_, err := io.WriteString(w, content)
+			return err
+		}
+	}
+
+	if err := WriteFileAtomic(path, write("first")); err != nil {
+		t.Fatalf("WriteFileAtomic failed: %v", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatalf("Failed to chmod: %v", err)
+	}
+	if err := WriteFileAtomic(path, write("second")); err != nil {
+		t.Fatalf("WriteFileAtomic failed: %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "second" {
+		t.Errorf("Expected the replaced content, got %q", content)
+	}
+	if stat, _ := os.Stat(path); stat.Mode().Perm() != 0600 {
+		t.Errorf("Expected the mode of the replaced file to be kept, got %v", stat.Mode().Perm())
+	}
+
+	// A failed write leaves the previous file and no temporary file behind
+	failed := errors.New("failed")
+	err := WriteFileAtomic(path, func(w io.Writer) error {
+		_, _ = io.WriteString(w, "partial")
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("Expected the write error, got %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "second" {
+		t.Errorf("Expected the previous content after a failed write, got %q", content)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected only %s in the directory, got %d entries", path, len(entries))
+	}
+
+	if err := WriteFileAtomic(filepath.Join(dir, "missing", "report.json"), write("x")); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
+
+func TestWriteFileAtomic_Symlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "reports", "latest.json")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(target, []byte("old"), 0600); err != nil {
+		t.Fatalf("Failed to write target: %v", err)
+	}
+	link := filepath.Join(dir, "report.json")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("Failed to create link: %v", err)
+	}
+
+	err := WriteFileAtomic(link, func(w io.Writer) error {
+		_, err := io.WriteString(w, "new")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WriteFileAtomic failed: %v", err)
+	}
+
+	if stat, err := os.Lstat(link); err != nil || stat.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Expected the link to be kept, got %v, %v", stat, err)
+	}
+	if content, _ := os.ReadFile(target); string(content) != "new" {
+		t.Errorf("Expected the link target to be replaced, got %q", content)
+	}
+	if stat, _ := os.Stat(target); stat.Mode().Perm() != 0600 {
+		t.Errorf("Expected the mode of the target to be kept, got %v", stat.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(target)); len(entries) != 1 {
+		t.Errorf("Expected the temporary file next to the target to be gone, got %d entries", len(entries))
+	}
+}
\ No newline at end of file
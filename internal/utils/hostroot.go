@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// HostRoot is the directory the host filesystem is mounted at when running
+// inside a debug container, e.g. "/host". Empty or "/" means the tool runs
+// directly on the host.
+var HostRoot string
+
+// HostPath resolves a host-absolute path under HostRoot so it can be opened
+func HostPath(path string) string {
+	if HostRoot == "" || HostRoot == "/" || !filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(HostRoot, path)
+}
+
+// DisplayPath converts a path under HostRoot back to the host-absolute path
+func DisplayPath(path string) string {
+	if HostRoot == "" || HostRoot == "/" {
+		return path
+	}
+
+	root := filepath.Clean(HostRoot)
+	if path == root {
+		return "/"
+	}
+	if rel := strings.TrimPrefix(path, root+string(filepath.Separator)); rel != path {
+		return string(filepath.Separator) + rel
+	}
+	return path
+}
@@ -0,0 +1,57 @@
+package utils
+
+import "testing"
+
+func TestHostPath(t *testing.T) {
+	orig := HostRoot
+	defer func() { HostRoot = orig }()
+
+	tests := []struct {
+		name     string
+		hostRoot string
+		path     string
+		expected string
+	}{
+		{"no host root", "", "/var/lib/containerd", "/var/lib/containerd"},
+		{"slash host root", "/", "/var/lib/containerd", "/var/lib/containerd"},
+		{"prefixed", "/host", "/var/lib/containerd", "/host/var/lib/containerd"},
+		{"trailing slash", "/host/", "/proc/1/mountinfo", "/host/proc/1/mountinfo"},
+		{"relative path untouched", "/host", "copy.db", "copy.db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			HostRoot = tt.hostRoot
+			if result := HostPath(tt.path); result != tt.expected {
+				t.Errorf("HostPath(%s) = %s, expected %s", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDisplayPath(t *testing.T) {
+	orig := HostRoot
+	defer func() { HostRoot = orig }()
+
+	tests := []struct {
+		name     string
+		hostRoot string
+		path     string
+		expected string
+	}{
+		{"no host root", "", "/host/var/lib", "/host/var/lib"},
+		{"prefixed", "/host", "/host/var/lib/containerd", "/var/lib/containerd"},
+		{"trailing slash", "/host/", "/host/var/lib", "/var/lib"},
+		{"root itself", "/host", "/host", "/"},
+		{"outside host root", "/host", "/hostile/path", "/hostile/path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			HostRoot = tt.hostRoot
+			if result := DisplayPath(tt.path); result != tt.expected {
+				t.Errorf("DisplayPath(%s) = %s, expected %s", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
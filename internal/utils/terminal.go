@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// IsTerminal reports whether f is a terminal
+func IsTerminal(f *os.File) bool {
+	_, err := unix.IoctlGetTermios(int(f.Fd()), unix.TCGETS)
+	return err == nil
+}
+
+// TerminalWidth returns the number of columns of the terminal f is attached
+// to, or 0 when f is not a terminal. The COLUMNS environment variable
+// overrides the size reported by the terminal.
+func TerminalWidth(f *os.File) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+
+	ws, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0
+	}
+	return int(ws.Col)
+}
\ No newline at end of file